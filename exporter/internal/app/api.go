@@ -2,11 +2,14 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"slices"
 	"strconv"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 	"go.uber.org/atomic"
@@ -24,6 +27,7 @@ import (
 type API struct {
 	conf *ClusterConfig
 	yc   yt.Client
+	jobs *exporter.JobQueue
 
 	l log.Structured
 
@@ -31,8 +35,8 @@ type API struct {
 }
 
 // NewAPI creates new API.
-func NewAPI(c *ClusterConfig, yc yt.Client, l log.Structured) *API {
-	return &API{conf: c, yc: yc, l: l}
+func NewAPI(c *ClusterConfig, yc yt.Client, jobs *exporter.JobQueue, l log.Structured) *API {
+	return &API{conf: c, yc: yc, jobs: jobs, l: l}
 }
 
 func (a *API) Routes() chi.Router {
@@ -56,6 +60,23 @@ func (a *API) Routes() chi.Router {
 		r.Get("/", a.exportQueryResult)
 	})
 
+	r.Route("/export-select", func(r chi.Router) {
+		r.Use(waitReady(&a.ready))
+		r.Get("/", a.exportSelect)
+	})
+
+	r.Route("/export-workbook", func(r chi.Router) {
+		r.Use(waitReady(&a.ready))
+		r.Post("/", a.exportWorkbook)
+	})
+
+	r.Route("/export-jobs", func(r chi.Router) {
+		r.Use(waitReady(&a.ready))
+		r.Post("/", a.submitExportJob)
+		r.Get("/{id}", a.getExportJob)
+		r.Get("/{id}/result", a.getExportJobResult)
+	})
+
 	return r
 }
 
@@ -68,43 +89,93 @@ func (a *API) SetReady() {
 
 // exportTable exports data from static yt table to excel.
 func (a *API) exportTable(w http.ResponseWriter, r *http.Request) {
-	paths, ok := r.URL.Query()["path"]
-	if !ok || len(paths) != 1 {
-		err := xerrors.Errorf("single path is required, got %d", len(paths))
+	req, err := a.makeExportRequest(r)
+	if err != nil {
 		replyError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	opts := &exporter.ExportOptions{MaxExcelFileSize: a.conf.maxExcelFileSize, Cluster: a.conf.Proxy}
+	rsp, err := exporter.Export(r.Context(), a.yc, req, opts)
+	if err != nil {
+		if errors.Is(err, exporter.ErrBadRequest) {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, exporter.ErrTableChanged) {
+			replyError(w, r, err, http.StatusPreconditionFailed)
+			return
+		}
+		replyError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = rsp.Close() }()
+
+	a.replyFile(w, r, rsp)
+}
+
+// makeExportRequest parses and validates static table export request from the request query.
+func (a *API) makeExportRequest(r *http.Request) (*exporter.ExportRequest, error) {
+	paths, ok := r.URL.Query()["path"]
+	if !ok || len(paths) != 1 {
+		return nil, xerrors.Errorf("single path is required, got %d", len(paths))
+	}
+
 	numberPrecisionMode := exporter.NumberPrecisionMode(r.URL.Query().Get("number_precision_mode"))
 
 	req, err := exporter.MakeExportRequest(paths[0], numberPrecisionMode)
 	if err != nil {
-		err = xerrors.Errorf("error parsing request: %w", err)
-		replyError(w, r, err, http.StatusBadRequest)
-		return
+		return nil, xerrors.Errorf("error parsing request: %w", err)
 	}
 
-	a.l.Info("parsed url params", log.Any("export_request", req))
+	req.MultiSheet = r.URL.Query().Get("multi_sheet") == "true"
+	req.SheetPerRange = r.URL.Query().Get("sheet_per_range") == "true"
 
-	if err := a.validateExportRequest(r.Context(), req); err != nil {
-		replyError(w, r, err, http.StatusBadRequest)
-		return
+	req.Format, req.Delimiter, err = parseFormat(r)
+	if err != nil {
+		return nil, err
 	}
 
-	opts := &exporter.ExportOptions{MaxExcelFileSize: a.conf.maxExcelFileSize}
-	rsp, err := exporter.Export(r.Context(), a.yc, req, opts)
+	req.ComplexTypeFormat, err = parseComplexTypeFormat(r)
 	if err != nil {
-		if errors.Is(err, exporter.ErrBadRequest) {
-			replyError(w, r, err, http.StatusBadRequest)
-			return
+		return nil, err
+	}
+
+	req.Header, err = parseHeaderOptions(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Query, err = parseRowQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec := r.URL.Query().Get("column_spec"); spec != "" {
+		req.ColumnSpecs, err = exporter.ParseColumnSpecs(spec)
+		if err != nil {
+			return nil, err
 		}
-		replyError(w, r, err, http.StatusInternalServerError)
-		return
 	}
 
-	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", rsp.Filename))
-	_ = rsp.File.Write(w)
+	req.Profile = exporter.Profile(r.URL.Query().Get("profile"))
+	if req.Profile != "" && !slices.Contains(exporter.Profiles, req.Profile) {
+		return nil, xerrors.Errorf("unexpected profile: %q; expected one of %q", req.Profile, exporter.Profiles)
+	}
+
+	req.Metadata = r.URL.Query().Get("metadata") == "true"
+
+	req.ExpectedRevision, err = parseExpectedRevision(r)
+	if err != nil {
+		return nil, err
+	}
+
+	a.l.Info("parsed url params", log.Any("export_request", req))
+
+	if err := a.validateExportRequest(r.Context(), req); err != nil {
+		return nil, err
+	}
+	return req, nil
 }
 
 func validateNumberPrecisionMode(mode *exporter.NumberPrecisionMode) error {
@@ -128,37 +199,167 @@ func validateNumberPrecisionMode(mode *exporter.NumberPrecisionMode) error {
 	return nil
 }
 
-func (a *API) validateExportRequest(ctx context.Context, req *exporter.ExportRequest) error {
-	if req.StartRow < 0 {
-		return xerrors.Errorf("start row cannot be negative; got %d", req.StartRow)
+// parseFormat parses output format and csv delimiter from the request query.
+func parseFormat(r *http.Request) (exporter.Format, rune, error) {
+	format := exporter.Format(r.URL.Query().Get("format"))
+	if format == "" {
+		format = exporter.FormatXLSX
+	}
+
+	if !slices.Contains(exporter.Formats, format) {
+		return "", 0, xerrors.Errorf("unexpected format: %q; expected one of %q", format, exporter.Formats)
+	}
+
+	delimiter := r.URL.Query().Get("delimiter")
+	if delimiter == "" {
+		return format, 0, nil
+	}
+
+	if format != exporter.FormatCSV {
+		return "", 0, xerrors.Errorf("delimiter is supported only for %q format", exporter.FormatCSV)
+	}
+
+	d := []rune(delimiter)
+	if len(d) != 1 || d[0] == '"' || d[0] == '\r' || d[0] == '\n' || d[0] == utf8.RuneError {
+		return "", 0, xerrors.Errorf("invalid delimiter %q; expected a single character", delimiter)
+	}
+
+	return format, d[0], nil
+}
+
+// parseHeaderOptions parses header mode and sheet presentation flags from the request query.
+func parseHeaderOptions(r *http.Request) (exporter.HeaderOptions, error) {
+	opts := exporter.HeaderOptions{
+		Mode:       exporter.HeaderMode(r.URL.Query().Get("header")),
+		Style:      r.URL.Query().Get("style_header") == "true",
+		AutoFilter: r.URL.Query().Get("autofilter") == "true",
+		AutoWidth:  r.URL.Query().Get("auto_width") == "true",
+	}
+
+	if opts.Mode == "" {
+		opts.Mode = exporter.HeaderNamesAndTypes
+	}
+	if !slices.Contains(exporter.HeaderModes, opts.Mode) {
+		return exporter.HeaderOptions{}, xerrors.Errorf("unexpected header: %q; expected one of %q",
+			opts.Mode, exporter.HeaderModes)
+	}
+	return opts, nil
+}
+
+// parseComplexTypeFormat parses text format of container values from the request query.
+func parseComplexTypeFormat(r *http.Request) (exporter.ComplexTypeFormat, error) {
+	format := exporter.ComplexTypeFormat(r.URL.Query().Get("complex_type_format"))
+	if format == "" {
+		return exporter.ComplexTypeFormatYSON, nil
+	}
+
+	if !slices.Contains(exporter.ComplexTypeFormats, format) {
+		return "", xerrors.Errorf("unexpected complex type format: %q; expected one of %q",
+			format, exporter.ComplexTypeFormats)
 	}
+	return format, nil
+}
 
-	if req.RowCount > exporter.MaxRowCount {
-		return xerrors.Errorf("too many rows to export; max is %d", exporter.MaxRowCount)
+// parseRowQuery parses filter, order_by, offset and limit params from the request query.
+//
+// Returns nil if none of them is set.
+func parseRowQuery(r *http.Request) (*exporter.RowQuery, error) {
+	q := r.URL.Query()
+	if !q.Has("filter") && !q.Has("order_by") && !q.Has("offset") && !q.Has("limit") {
+		return nil, nil
 	}
 
-	if req.RowCount == 0 {
-		tableRowCount, err := a.readTableRowCount(ctx, req.Path)
+	parseCount := func(name string) (int64, error) {
+		v := q.Get(name)
+		if v == "" {
+			return 0, nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
 		if err != nil {
-			return err
+			return 0, xerrors.Errorf("error parsing %s: %w", name, err)
 		}
+		return n, nil
+	}
 
-		if tableRowCount > exporter.MaxRowCount {
-			return xerrors.Errorf("too many rows to export; max is %d", exporter.MaxRowCount)
-		}
+	offset, err := parseCount("offset")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := parseCount("limit")
+	if err != nil {
+		return nil, err
+	}
 
-		req.RowCount = exporter.MaxRowCount
+	return exporter.ParseRowQuery(q.Get("filter"), q.Get("order_by"), offset, limit)
+}
+
+// parseExpectedRevision parses expected_revision param or its short form revision from the request query.
+//
+// Returns nil if neither is set.
+func parseExpectedRevision(r *http.Request) (*uint64, error) {
+	q := r.URL.Query()
+	name := "expected_revision"
+	if !q.Has(name) {
+		name = "revision"
+	}
+	if !q.Has(name) {
+		return nil, nil
 	}
 
-	return validateNumberPrecisionMode(&req.NumberPrecisionMode)
+	revision, err := strconv.ParseUint(q.Get(name), 10, 64)
+	if err != nil {
+		return nil, xerrors.Errorf("error parsing %s: %w", name, err)
+	}
+	return &revision, nil
 }
 
-func (a *API) readTableRowCount(ctx context.Context, path ypath.Path) (int64, error) {
-	var tableRowCount int64
-	if err := a.yc.GetNode(ctx, path.Attr("row_count"), &tableRowCount, nil); err != nil {
-		return 0, xerrors.Errorf("error reading table row count: %w", err)
+func (a *API) validateExportRequest(ctx context.Context, req *exporter.ExportRequest) error {
+	if req.StartRow < 0 {
+		return xerrors.Errorf("start row cannot be negative; got %d", req.StartRow)
+	}
+
+	for _, rng := range req.Ranges {
+		for _, l := range []*ypath.ReadLimit{rng.Lower, rng.Upper, rng.Exact} {
+			if l != nil && l.RowIndex != nil && *l.RowIndex < 0 {
+				return xerrors.Errorf("row index cannot be negative; got %d", *l.RowIndex)
+			}
+		}
+	}
+
+	if (req.SheetPerRange || req.MultiSheet) && !req.Format.SupportsSheets() {
+		return xerrors.Errorf("%s format does not support several sheets", req.Format)
+	}
+
+	if req.SheetPerRange && len(req.Ranges) > exporter.MaxSheetCount {
+		return xerrors.Errorf("too many ranges to export into separate sheets; max is %d", exporter.MaxSheetCount)
+	}
+
+	if req.Query != nil {
+		// Rows selected by the query are counted while they are converted.
+		return validateNumberPrecisionMode(&req.NumberPrecisionMode)
+	}
+
+	rowLimit := req.RowLimit()
+
+	if len(req.Ranges) > 0 {
+		// Ranges bounded by keys or open ranges are resolved by exporter.Export.
+		if counts, ok := req.RangeRowCounts(); ok {
+			if rowCount, _ := req.RangeRowCount(); rowCount > rowLimit {
+				return xerrors.Errorf("too many rows to export; max is %d", rowLimit)
+			}
+			if err := req.CheckRangeRowCounts(counts); err != nil {
+				return err
+			}
+		}
+		return validateNumberPrecisionMode(&req.NumberPrecisionMode)
 	}
-	return tableRowCount, nil
+
+	// Row count of the whole table is checked by exporter.Export under snapshot lock.
+	if req.RowCount > rowLimit {
+		return xerrors.Errorf("too many rows to export; max is %d", rowLimit)
+	}
+
+	return validateNumberPrecisionMode(&req.NumberPrecisionMode)
 }
 
 func (a *API) validateQueryResultExportRequest(ctx context.Context, req *exporter.ExportQueryResultRequest) error {
@@ -214,25 +415,70 @@ func makeQueryResultExportRequestFromQuery(r *http.Request) (*exporter.ExportQue
 
 	exportRequest.NumberPrecisionMode = exporter.NumberPrecisionMode(r.URL.Query().Get("number_precision_mode"))
 
+	exportRequest.Format, exportRequest.Delimiter, err = parseFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	exportRequest.ComplexTypeFormat, err = parseComplexTypeFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	exportRequest.Header, err = parseHeaderOptions(r)
+	if err != nil {
+		return nil, err
+	}
+
 	return &exportRequest, nil
 }
 
 // exportQueryResult exports data from query tracker result to excel.
 func (a *API) exportQueryResult(w http.ResponseWriter, r *http.Request) {
-	req, err := makeQueryResultExportRequestFromQuery(r)
+	req, err := a.makeQueryResultExportRequest(r)
 	if err != nil {
-		err = xerrors.Errorf("error parsing request: %w", err)
 		replyError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
+	opts := &exporter.ExportOptions{MaxExcelFileSize: a.conf.maxExcelFileSize}
+	rsp, err := exporter.ExportQueryResult(r.Context(), a.yc, req, opts)
+	if err != nil {
+		if errors.Is(err, exporter.ErrBadRequest) {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		replyError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = rsp.Close() }()
+
+	a.replyFile(w, r, rsp)
+}
+
+// makeQueryResultExportRequest parses and validates query result export request from the request query.
+func (a *API) makeQueryResultExportRequest(r *http.Request) (*exporter.ExportQueryResultRequest, error) {
+	req, err := makeQueryResultExportRequestFromQuery(r)
+	if err != nil {
+		return nil, xerrors.Errorf("error parsing request: %w", err)
+	}
+
 	if err = a.validateQueryResultExportRequest(r.Context(), req); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// exportSelect exports the result of YT QL query to excel.
+func (a *API) exportSelect(w http.ResponseWriter, r *http.Request) {
+	req, err := a.makeSelectExportRequest(r)
+	if err != nil {
 		replyError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
 	opts := &exporter.ExportOptions{MaxExcelFileSize: a.conf.maxExcelFileSize}
-	rsp, err := exporter.ExportQueryResult(r.Context(), a.yc, req, opts)
+	rsp, err := exporter.ExportSelect(r.Context(), a.yc, req, opts)
 	if err != nil {
 		if errors.Is(err, exporter.ErrBadRequest) {
 			replyError(w, r, err, http.StatusBadRequest)
@@ -241,8 +487,212 @@ func (a *API) exportQueryResult(w http.ResponseWriter, r *http.Request) {
 		replyError(w, r, err, http.StatusInternalServerError)
 		return
 	}
+	defer func() { _ = rsp.Close() }()
 
-	w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	a.replyFile(w, r, rsp)
+}
+
+// makeSelectExportRequest parses and validates YT QL query export request from the request query.
+func (a *API) makeSelectExportRequest(r *http.Request) (*exporter.ExportSelectRequest, error) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		return nil, xerrors.Errorf("query is required")
+	}
+
+	req := &exporter.ExportSelectRequest{
+		Filename:            r.URL.Query().Get("filename"),
+		Query:               query,
+		NumberPrecisionMode: exporter.NumberPrecisionMode(r.URL.Query().Get("number_precision_mode")),
+		MultiSheet:          r.URL.Query().Get("multi_sheet") == "true",
+	}
+
+	var err error
+	req.Format, req.Delimiter, err = parseFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.ComplexTypeFormat, err = parseComplexTypeFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header, err = parseHeaderOptions(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.MultiSheet && !req.Format.SupportsSheets() {
+		return nil, xerrors.Errorf("%s format does not support several sheets", req.Format)
+	}
+
+	a.l.Info("parsed url params", log.Any("export_select_request", req))
+
+	if err := validateNumberPrecisionMode(&req.NumberPrecisionMode); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// maxWorkbookRequestSize is the max size of the body of workbook export request.
+const maxWorkbookRequestSize = 1 << 20
+
+// workbookSource is a source of workbook export in the request body: either a path or a query result.
+type workbookSource struct {
+	Sheet         string   `json:"sheet"`
+	Path          string   `json:"path"`
+	QueryID       string   `json:"query_id"`
+	ResultIndex   int64    `json:"result_index"`
+	LowerRowIndex *int64   `json:"lower_row_index"`
+	UpperRowIndex *int64   `json:"upper_row_index"`
+	Columns       []string `json:"columns"`
+}
+
+// exportWorkbook exports several static tables and query results to a single excel file, one sheet per source.
+func (a *API) exportWorkbook(w http.ResponseWriter, r *http.Request) {
+	req, err := a.makeWorkbookExportRequest(r)
+	if err != nil {
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	opts := &exporter.ExportOptions{MaxExcelFileSize: a.conf.maxExcelFileSize, Cluster: a.conf.Proxy}
+	rsp, err := exporter.ExportWorkbook(r.Context(), a.yc, req, opts)
+	if err != nil {
+		if errors.Is(err, exporter.ErrBadRequest) {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		replyError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = rsp.Close() }()
+
+	a.replyFile(w, r, rsp)
+}
+
+// makeWorkbookExportRequest parses and validates workbook export request
+// from the sources of the request body and the options of the request query.
+func (a *API) makeWorkbookExportRequest(r *http.Request) (*exporter.ExportWorkbookRequest, error) {
+	var body struct {
+		Sources []workbookSource `json:"sources"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxWorkbookRequestSize)).Decode(&body); err != nil {
+		return nil, xerrors.Errorf("error parsing request body: %w", err)
+	}
+
+	req := &exporter.ExportWorkbookRequest{
+		Filename:            r.URL.Query().Get("filename"),
+		NumberPrecisionMode: exporter.NumberPrecisionMode(r.URL.Query().Get("number_precision_mode")),
+	}
+
+	var err error
+	if req.Format, _, err = parseFormat(r); err != nil {
+		return nil, err
+	}
+	if !req.Format.SupportsSheets() {
+		return nil, xerrors.Errorf("%s format does not support several sheets", req.Format)
+	}
+
+	req.ComplexTypeFormat, err = parseComplexTypeFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header, err = parseHeaderOptions(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateNumberPrecisionMode(&req.NumberPrecisionMode); err != nil {
+		return nil, err
+	}
+
+	if len(body.Sources) > exporter.MaxSheetCount {
+		return nil, xerrors.Errorf("too many sources to export; max is %d", exporter.MaxSheetCount)
+	}
+
+	for i, src := range body.Sources {
+		source, err := a.makeWorkbookSource(r.Context(), &src, req)
+		if err != nil {
+			return nil, xerrors.Errorf("source %d: %w", i, err)
+		}
+		req.Sources = append(req.Sources, *source)
+	}
+
+	a.l.Info("parsed workbook export request", log.Any("export_workbook_request", req))
+	return req, nil
+}
+
+// makeWorkbookSource parses and validates a single source of workbook export.
+func (a *API) makeWorkbookSource(
+	ctx context.Context,
+	src *workbookSource,
+	req *exporter.ExportWorkbookRequest,
+) (*exporter.WorkbookSource, error) {
+	switch {
+	case src.Path != "" && src.QueryID != "":
+		return nil, xerrors.Errorf("path and query_id can not be set together")
+	case src.Path != "":
+		// Columns and rows of tables are selected by the path itself.
+		if src.Columns != nil || src.LowerRowIndex != nil || src.UpperRowIndex != nil {
+			return nil, xerrors.Errorf("columns, lower_row_index and upper_row_index are supported only for query results; " +
+				"use columns and ranges of the path instead")
+		}
+		table, err := exporter.MakeExportRequest(src.Path, req.NumberPrecisionMode)
+		if err != nil {
+			return nil, xerrors.Errorf("error parsing path: %w", err)
+		}
+		table.Format = req.Format
+		if err := a.validateExportRequest(ctx, table); err != nil {
+			return nil, err
+		}
+		return &exporter.WorkbookSource{Sheet: src.Sheet, Table: table}, nil
+	case src.QueryID != "":
+		id, err := guid.ParseString(src.QueryID)
+		if err != nil {
+			return nil, xerrors.Errorf("error parsing query id: %w", err)
+		}
+		result := &exporter.ExportQueryResultRequest{
+			ID:                  yt.QueryID(id),
+			Index:               src.ResultIndex,
+			LowerRowIndex:       src.LowerRowIndex,
+			UpperRowIndex:       src.UpperRowIndex,
+			Columns:             src.Columns,
+			NumberPrecisionMode: req.NumberPrecisionMode,
+		}
+		if err := a.validateQueryResultExportRequest(ctx, result); err != nil {
+			return nil, err
+		}
+		return &exporter.WorkbookSource{Sheet: src.Sheet, QueryResult: result}, nil
+	default:
+		return nil, xerrors.Errorf("either path or query_id is required")
+	}
+}
+
+// replyFile streams exported file to the client.
+//
+// Conversion errors are replied as usual until the first byte of the file is sent.
+// After that the only way to signal an error is to abort the connection,
+// so that the client does not mistake a truncated file for a complete one.
+func (a *API) replyFile(w http.ResponseWriter, r *http.Request, rsp *exporter.ExportResponse) {
+	w.Header().Set("Content-Type", rsp.ContentType)
 	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", rsp.Filename))
-	_ = rsp.File.Write(w)
+
+	cw := &countingWriter{w: w}
+	if err := rsp.Write(cw); err != nil {
+		if cw.n == 0 {
+			w.Header().Del("Content-Type")
+			w.Header().Del("Content-Disposition")
+			if errors.Is(err, exporter.ErrBadRequest) {
+				replyError(w, r, err, http.StatusBadRequest)
+				return
+			}
+			replyError(w, r, err, http.StatusInternalServerError)
+			return
+		}
+
+		a.l.Error("error streaming file", log.String("filename", rsp.Filename), log.Error(err))
+		panic(http.ErrAbortHandler)
+	}
 }
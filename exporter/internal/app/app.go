@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	_ "net/http/pprof"
+	"os"
 	"path"
 	"sync"
 	"time"
@@ -15,6 +16,7 @@ import (
 	"go.ytsaurus.tech/library/go/httputil/middleware/httpmetrics"
 	"go.ytsaurus.tech/yt/go/yt"
 	"go.ytsaurus.tech/yt/go/yt/ythttp"
+	"go.ytsaurus.tech/yt/microservices/excel/exporter/internal/exporter"
 )
 
 const (
@@ -55,6 +57,26 @@ func (a *App) Run(ctx context.Context) error {
 		return gctx.Err()
 	})
 
+	if a.conf.Jobs.SpoolDir != "" {
+		if err := os.MkdirAll(a.conf.Jobs.SpoolDir, 0o700); err != nil {
+			return err
+		}
+	}
+
+	jobs := exporter.NewJobQueue(&exporter.JobOptions{
+		MaxQueued:   a.conf.Jobs.MaxQueued,
+		Concurrency: a.conf.Jobs.Concurrency,
+		Retention:   a.conf.Jobs.Retention,
+		Timeout:     a.conf.Jobs.Timeout,
+		SpoolDir:    a.conf.Jobs.SpoolDir,
+
+		MaxFinished:          a.conf.Jobs.MaxFinished,
+		MaxMemoryResultBytes: a.conf.Jobs.MaxMemoryResultBytes,
+	})
+	g.Go(func() error {
+		return jobs.Run(gctx)
+	})
+
 	r := chi.NewMux()
 	r.Use(httpmetrics.New(a.metrics.WithPrefix("http")))
 	r.Use(timeout(a.conf.HTTPHandlerTimeout))
@@ -71,7 +93,7 @@ func (a *App) Run(ctx context.Context) error {
 			return err
 		}
 
-		api := NewAPI(c, yc, a.l)
+		api := NewAPI(c, yc, jobs, a.l)
 		apiRouter := r.
 			With(ForwardCookie(a.conf.AuthCookieName)).
 			With(ForwardCookieRenamed(a.conf.SSOCookieName, ssoCookieForwardedName)).
@@ -12,6 +12,14 @@ const (
 	defaultHTTPHandlerTimeout = 2 * time.Minute
 	defaultMaxExcelFileSize   = 1024 * 1024 * 100
 
+	defaultJobsMaxQueued   = 100
+	defaultJobsConcurrency = 4
+	defaultJobsRetention   = time.Hour
+	defaultJobsTimeout     = 30 * time.Minute
+	defaultJobsMaxFinished = 1000
+	// defaultJobsMaxMemoryResultBytes is 1 Gb.
+	defaultJobsMaxMemoryResultBytes = 1024 * 1024 * 1024
+
 	defaultAuthCookieName = "Session_id"
 	defaultSSOCookieName  = "yt_oauth_access_token"
 )
@@ -31,6 +39,8 @@ type Config struct {
 
 	CORS *CORSConfig `yaml:"cors"`
 
+	Jobs *JobsConfig `yaml:"jobs"`
+
 	Clusters        []*ClusterConfig          `yaml:"clusters"`
 	clustersByProxy map[string]*ClusterConfig `yaml:"-"`
 }
@@ -64,6 +74,11 @@ func (c *Config) UnmarshalYAML(unmarshal func(any) error) error {
 		c.SSOCookieName = defaultSSOCookieName
 	}
 
+	if c.Jobs == nil {
+		c.Jobs = &JobsConfig{}
+	}
+	c.Jobs.setDefaults()
+
 	if len(c.Clusters) == 0 {
 		return xerrors.New("clusters can not be empty")
 	}
@@ -93,6 +108,53 @@ type CORSConfig struct {
 	AllowedHostSuffixes []string `yaml:"allowed_host_suffixes"`
 }
 
+// JobsConfig configures asynchronous export jobs.
+type JobsConfig struct {
+	// MaxQueued is the max number of jobs waiting for execution.
+	// Default: 100.
+	MaxQueued int `yaml:"max_queued"`
+	// Concurrency is the number of jobs executed simultaneously.
+	// Default: 4.
+	Concurrency int `yaml:"concurrency"`
+	// Retention is the time a finished job and its result are kept for.
+	// Default: 1h.
+	Retention time.Duration `yaml:"retention"`
+	// Timeout limits execution time of a single job.
+	// Default: 30m.
+	Timeout time.Duration `yaml:"timeout"`
+	// SpoolDir is a local directory to store job results in.
+	// Results are kept in memory if empty.
+	SpoolDir string `yaml:"spool_dir"`
+	// MaxFinished is the max number of finished jobs kept; the oldest ones are removed first.
+	// Default: 1000.
+	MaxFinished int `yaml:"max_finished"`
+	// MaxMemoryResultBytes is the max total size of job results kept in memory;
+	// jobs with the oldest results are removed first.
+	// Default: 1073741824 (1 Gb).
+	MaxMemoryResultBytes int64 `yaml:"max_memory_result_bytes"`
+}
+
+func (c *JobsConfig) setDefaults() {
+	if c.MaxQueued == 0 {
+		c.MaxQueued = defaultJobsMaxQueued
+	}
+	if c.Concurrency == 0 {
+		c.Concurrency = defaultJobsConcurrency
+	}
+	if c.Retention == 0 {
+		c.Retention = defaultJobsRetention
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defaultJobsTimeout
+	}
+	if c.MaxFinished == 0 {
+		c.MaxFinished = defaultJobsMaxFinished
+	}
+	if c.MaxMemoryResultBytes == 0 {
+		c.MaxMemoryResultBytes = defaultJobsMaxMemoryResultBytes
+	}
+}
+
 type ClusterConfig struct {
 	// Proxy identifies cluster.
 	Proxy string `yaml:"proxy"`
@@ -1,6 +1,7 @@
 package app
 
 import (
+	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -13,7 +14,7 @@ func CORS(conf *CORSConfig) func(next http.Handler) http.Handler {
 	c := cors.New(cors.Options{
 		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{"Origin", "Accept", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders: []string{"Content-Disposition"},
+		ExposedHeaders: []string{"Content-Disposition", "Location"},
 		AllowOriginFunc: func(origin string) bool {
 			u, err := url.Parse(origin)
 			if err != nil {
@@ -60,3 +61,15 @@ func Origin(r *http.Request) string {
 	host, _, _ := net.SplitHostPort(r.RemoteAddr)
 	return host
 }
+
+// countingWriter is an io.Writer that counts bytes written to the underlying writer.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,171 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+
+	"go.ytsaurus.tech/library/go/core/log"
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/yterrors"
+	"go.ytsaurus.tech/yt/microservices/excel/exporter/internal/exporter"
+)
+
+// submitExportJob starts asynchronous export of a static table, a query result or a YT QL query.
+//
+// Parameters are the same as of /export, of /export-query-result if query_id is set,
+// or of /export-select if query is set.
+func (a *API) submitExportJob(w http.ResponseWriter, r *http.Request) {
+	owner, err := a.whoAmI(r.Context())
+	if err != nil {
+		replyError(w, r, err, whoAmIErrorStatus(err))
+		return
+	}
+
+	var run exporter.JobFunc
+	switch {
+	case r.URL.Query().Has("query"):
+		req, err := a.makeSelectExportRequest(r)
+		if err != nil {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		run = func(ctx context.Context, progress *exporter.Progress) (*exporter.ExportResponse, error) {
+			return exporter.ExportSelect(ctx, a.yc, req, a.jobExportOptions(progress))
+		}
+	case r.URL.Query().Has("query_id"):
+		req, err := a.makeQueryResultExportRequest(r)
+		if err != nil {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		run = func(ctx context.Context, progress *exporter.Progress) (*exporter.ExportResponse, error) {
+			return exporter.ExportQueryResult(ctx, a.yc, req, a.jobExportOptions(progress))
+		}
+	default:
+		req, err := a.makeExportRequest(r)
+		if err != nil {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		run = func(ctx context.Context, progress *exporter.Progress) (*exporter.ExportResponse, error) {
+			return exporter.Export(ctx, a.yc, req, a.jobExportOptions(progress))
+		}
+	}
+
+	job, err := a.jobs.Submit(r.Context(), owner, a.conf.Proxy, run)
+	if err != nil {
+		if errors.Is(err, exporter.ErrJobQueueFull) {
+			replyError(w, r, err, http.StatusTooManyRequests)
+			return
+		}
+		replyError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	a.l.Info("export job submitted", log.String("job_id", job.ID), log.String("owner", owner))
+
+	w.Header().Set("Location", path.Join(r.URL.Path, job.ID))
+	replyJSON(w, http.StatusAccepted, job.Status())
+}
+
+// getExportJob replies with the status of the job.
+func (a *API) getExportJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.lookupJob(w, r)
+	if !ok {
+		return
+	}
+	replyJSON(w, http.StatusOK, job.Status())
+}
+
+// getExportJobResult streams the resulting file of the completed job.
+//
+// The error of the failed job is replied as is.
+func (a *API) getExportJobResult(w http.ResponseWriter, r *http.Request) {
+	job, ok := a.lookupJob(w, r)
+	if !ok {
+		return
+	}
+
+	filename, contentType, result, err := job.Result()
+	if err != nil {
+		if jobErr := job.Err(); jobErr != nil {
+			if errors.Is(jobErr, exporter.ErrBadRequest) {
+				replyError(w, r, jobErr, http.StatusBadRequest)
+				return
+			}
+			if errors.Is(jobErr, exporter.ErrTableChanged) {
+				replyError(w, r, jobErr, http.StatusPreconditionFailed)
+				return
+			}
+			replyError(w, r, jobErr, http.StatusInternalServerError)
+			return
+		}
+		if errors.Is(err, exporter.ErrJobNotCompleted) {
+			replyError(w, r, err, http.StatusConflict)
+			return
+		}
+		replyError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = result.Close() }()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if _, err := io.Copy(w, result); err != nil {
+		a.l.Error("error streaming job result", log.String("job_id", job.ID), log.Error(err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// lookupJob finds the job from the request path.
+//
+// Jobs of other users and clusters are reported as missing.
+func (a *API) lookupJob(w http.ResponseWriter, r *http.Request) (*exporter.Job, bool) {
+	owner, err := a.whoAmI(r.Context())
+	if err != nil {
+		replyError(w, r, err, whoAmIErrorStatus(err))
+		return nil, false
+	}
+
+	id := chi.URLParam(r, "id")
+	job, ok := a.jobs.Get(id)
+	if !ok || job.Owner != owner || job.Cluster != a.conf.Proxy {
+		replyError(w, r, xerrors.Errorf("export job %q not found", id), http.StatusNotFound)
+		return nil, false
+	}
+	return job, true
+}
+
+func (a *API) jobExportOptions(progress *exporter.Progress) *exporter.ExportOptions {
+	return &exporter.ExportOptions{MaxExcelFileSize: a.conf.maxExcelFileSize, Cluster: a.conf.Proxy, Progress: progress}
+}
+
+// whoAmI returns the login of the requester.
+func (a *API) whoAmI(ctx context.Context) (string, error) {
+	rsp, err := a.yc.WhoAmI(ctx, nil)
+	if err != nil {
+		return "", xerrors.Errorf("error authenticating user: %w", err)
+	}
+	return rsp.Login, nil
+}
+
+func whoAmIErrorStatus(err error) int {
+	if yterrors.ContainsErrorCode(err, yterrors.CodeAuthenticationError) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
+
+func replyJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -44,9 +44,8 @@ func requestLog(l log.Structured) func(next http.Handler) http.Handler {
 			requestID := guid.New()
 			requestIDField := log.String("request_id", requestID.String())
 
+			// The response body is not copied, since exported files are streamed and may be large.
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
-			resp := &bytes.Buffer{}
-			ww.Tee(resp)
 
 			const bodySizeLimit = 1024 * 1024
 			body, err := io.ReadAll(io.LimitReader(r.Body, bodySizeLimit))
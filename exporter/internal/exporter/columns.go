@@ -0,0 +1,364 @@
+package exporter
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// ColumnSpec describes a column of the exported sheet.
+//
+// Either Column or Expr must be set. Expr is one of the following functions:
+//
+//	concat(first_name, ' ', last_name)          — text of the column values and strings joined together
+//	format_time(created_at, '%Y-%m-%d %H:%M')  — date, datetime or timestamp column formatted as text
+type ColumnSpec struct {
+	// Column is the name of the exported table column.
+	Column string `json:"column,omitempty"`
+	// Expr computes the value of the column from the row.
+	Expr string `json:"expr,omitempty"`
+	// Title is the text of the header cell; the name of the column or the expression by default.
+	Title string `json:"title,omitempty"`
+	// NumberFormat is excel number format of the column cells, e.g. "#,##0.00" or "dd.mm.yyyy".
+	NumberFormat string `json:"number_format,omitempty"`
+
+	expr *columnExpr
+}
+
+// ParseColumnSpecs parses JSON list of column specs.
+//
+// Expressions are checked against the table schema later, on export.
+func ParseColumnSpecs(data string) ([]ColumnSpec, error) {
+	var specs []ColumnSpec
+	if err := json.Unmarshal([]byte(data), &specs); err != nil {
+		return nil, xerrors.Errorf("error parsing column spec: %w", err)
+	}
+	if len(specs) == 0 {
+		return nil, xerrors.Errorf("column spec is empty")
+	}
+	if len(specs) > excelMaxColCount {
+		return nil, xerrors.Errorf("exceeding max number of excel columns %d", excelMaxColCount)
+	}
+
+	for i := range specs {
+		s := &specs[i]
+		switch {
+		case s.Column != "" && s.Expr != "":
+			return nil, xerrors.Errorf("column spec %d has both column and expr", i)
+		case s.Column == "" && s.Expr == "":
+			return nil, xerrors.Errorf("column spec %d has neither column nor expr", i)
+		case s.Expr != "":
+			p, err := newQueryParser(s.Expr)
+			if err != nil {
+				return nil, xerrors.Errorf("error parsing expr of column spec %d: %w", i, err)
+			}
+			if s.expr, err = p.parseColumnExpr(); err != nil {
+				return nil, xerrors.Errorf("error parsing expr of column spec %d: %w", i, err)
+			}
+		}
+	}
+	return specs, nil
+}
+
+// columnSpecColumns returns the table columns used by the specs.
+func columnSpecColumns(specs []ColumnSpec) []string {
+	var columns []string
+	add := func(name string) {
+		if !slices.Contains(columns, name) {
+			columns = append(columns, name)
+		}
+	}
+
+	for _, s := range specs {
+		if s.expr != nil {
+			for _, a := range s.expr.args {
+				if a.kind == tokenIdent {
+					add(a.text)
+				}
+			}
+		} else {
+			add(s.Column)
+		}
+	}
+	return columns
+}
+
+// columnExpr is a function call computing the value of the column.
+type columnExpr struct {
+	function string
+	// args are column names (tokenIdent) and string literals (tokenString).
+	args []token
+}
+
+// parseColumnExpr parses a function call with columns and strings as arguments.
+func (p *queryParser) parseColumnExpr() (*columnExpr, error) {
+	t := p.advance()
+	if t.kind != tokenIdent || t.quoted {
+		return nil, p.unexpected(t)
+	}
+	e := &columnExpr{function: strings.ToLower(t.text)}
+
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	for {
+		t := p.advance()
+		if t.kind != tokenIdent && t.kind != tokenString {
+			return nil, p.unexpected(t)
+		}
+		e.args = append(e.args, t)
+
+		t = p.advance()
+		if t.kind == tokenPunct && t.text == ")" {
+			break
+		}
+		if t.kind != tokenPunct || t.text != "," {
+			return nil, p.unexpected(t)
+		}
+	}
+	if t := p.advance(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+
+	switch e.function {
+	case "concat":
+	case "format_time":
+		if len(e.args) != 2 || e.args[0].kind != tokenIdent || e.args[1].kind != tokenString {
+			return nil, xerrors.Errorf("format_time expects a column and a format string")
+		}
+	default:
+		return nil, xerrors.Errorf("unknown function %q; expected concat or format_time", e.function)
+	}
+	return e, nil
+}
+
+// sheetColumn is a column of the sheet that gets its value from the row.
+type sheetColumn struct {
+	title string
+	// typ is the description of the type written on the second row of the header.
+	typ          string
+	numberFormat string
+	// style is the id of the cell style with numberFormat.
+	style int
+	value func(c *converter, row map[string]any) (excelize.Cell, error)
+}
+
+// makeSheetColumns returns the columns of the sheet in the order they are written.
+//
+// Without column specs the requested columns are written in the order of the table schema.
+func makeSheetColumns(columns []string, specs []ColumnSpec, s *schema.Schema) ([]sheetColumn, error) {
+	if len(specs) == 0 {
+		header := makeHeader(columns, s)
+		sheetColumns := make([]sheetColumn, len(header))
+		for name, col := range header {
+			sheetColumns[col.Index-1] = newValueColumn(name, col.Column)
+		}
+		return sheetColumns, nil
+	}
+
+	schemaColumns := make(map[string]schema.Column, len(s.Columns))
+	for _, c := range s.Columns {
+		schemaColumns[c.Name] = c
+	}
+
+	sheetColumns := make([]sheetColumn, len(specs))
+	for i, spec := range specs {
+		var err error
+		if spec.expr == nil {
+			col, ok := schemaColumns[spec.Column]
+			if !ok {
+				return nil, xerrors.Errorf("column %q not found in the table schema", spec.Column)
+			}
+			sheetColumns[i] = newValueColumn(spec.Column, col)
+		} else {
+			sheetColumns[i], err = newExprColumn(spec, schemaColumns)
+			if err != nil {
+				return nil, xerrors.Errorf("invalid expr %q: %w", spec.Expr, err)
+			}
+		}
+
+		if spec.Title != "" {
+			sheetColumns[i].title = spec.Title
+		}
+		sheetColumns[i].numberFormat = spec.NumberFormat
+	}
+	return sheetColumns, nil
+}
+
+// newValueColumn returns sheet column with the values of the table column.
+func newValueColumn(name string, col schema.Column) sheetColumn {
+	return sheetColumn{
+		title: name,
+		typ:   describeColumnType(col),
+		value: func(c *converter, row map[string]any) (excelize.Cell, error) {
+			v := row[name]
+			if v == nil {
+				return excelize.Cell{}, nil
+			}
+			return c.convertColumn(col, v)
+		},
+	}
+}
+
+// newExprColumn returns sheet column with the text computed by the expression.
+func newExprColumn(spec ColumnSpec, columns map[string]schema.Column) (sheetColumn, error) {
+	e := spec.expr
+
+	type arg struct {
+		column string
+		typ    schema.Type
+		text   string
+	}
+	args := make([]arg, len(e.args))
+	for i, a := range e.args {
+		if a.kind == tokenString {
+			args[i] = arg{text: a.text}
+			continue
+		}
+
+		col, ok := columns[a.text]
+		if !ok {
+			return sheetColumn{}, xerrors.Errorf("column %q not found in the table schema", a.text)
+		}
+		typ, _, err := columnValueKind(col)
+		if err != nil {
+			return sheetColumn{}, err
+		}
+		args[i] = arg{column: a.text, typ: typ}
+	}
+
+	var compute func(row map[string]any) (string, bool)
+	switch e.function {
+	case "concat":
+		compute = func(row map[string]any) (string, bool) {
+			var b strings.Builder
+			for _, a := range args {
+				if a.column == "" {
+					b.WriteString(a.text)
+				} else if v := row[a.column]; v != nil {
+					b.WriteString(valueText(a.typ, v))
+				}
+			}
+			return b.String(), true
+		}
+	case "format_time":
+		column, typ, layout := args[0].column, args[0].typ, args[1].text
+		if !isDateType(typ) {
+			return sheetColumn{}, xerrors.Errorf("column %q of type %s is not a date, datetime or timestamp", column, typ)
+		}
+		compute = func(row map[string]any) (string, bool) {
+			t, ok := toTime(typ, row[column])
+			if !ok {
+				return "", false
+			}
+			return formatTime(t, layout), true
+		}
+	}
+
+	return sheetColumn{
+		title: spec.Expr,
+		typ:   string(schema.TypeString),
+		value: func(c *converter, row map[string]any) (excelize.Cell, error) {
+			s, ok := compute(row)
+			if !ok {
+				return excelize.Cell{}, nil
+			}
+			return c.convertString(s)
+		},
+	}, nil
+}
+
+// valueText returns the text of the comparable value as it is shown in csv files.
+func valueText(typ schema.Type, v any) string {
+	if t, ok := toTime(typ, v); ok {
+		switch typ {
+		case schema.TypeDate, typeDate32:
+			return t.Format(time.DateOnly)
+		case schema.TypeDatetime, typeDatetime64:
+			return t.Format(time.RFC3339)
+		default:
+			return t.Format(strTimestampFormat)
+		}
+	}
+	return formatCellText(v, "")
+}
+
+// toTime returns the time of the value of date, datetime or timestamp type.
+func toTime(typ schema.Type, v any) (time.Time, bool) {
+	if !isDateType(typ) {
+		return time.Time{}, false
+	}
+
+	n, ok := asInt64(v)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	switch typ {
+	case schema.TypeDate, typeDate32:
+		return time.Unix(n*86400, 0).UTC(), true
+	case schema.TypeDatetime, typeDatetime64:
+		return time.Unix(n, 0).UTC(), true
+	default:
+		return time.UnixMicro(n).UTC(), true
+	}
+}
+
+// formatTime formats time in UTC according to the strftime-like layout.
+//
+// Supported directives are %Y, %y, %m, %d, %H, %I, %M, %S, %f (microseconds), %p, %b, %B, %a, %A, %j and %%.
+func formatTime(t time.Time, layout string) string {
+	var b strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' || i+1 == len(layout) {
+			b.WriteByte(layout[i])
+			continue
+		}
+
+		i++
+		switch layout[i] {
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'y':
+			b.WriteString(t.Format("06"))
+		case 'm':
+			b.WriteString(t.Format("01"))
+		case 'd':
+			b.WriteString(t.Format("02"))
+		case 'H':
+			b.WriteString(t.Format("15"))
+		case 'I':
+			b.WriteString(t.Format("03"))
+		case 'M':
+			b.WriteString(t.Format("04"))
+		case 'S':
+			b.WriteString(t.Format("05"))
+		case 'f':
+			b.WriteString(t.Format(".000000")[1:])
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'b':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'j':
+			b.WriteString(t.Format("002"))
+		default:
+			b.WriteByte('%')
+			if layout[i] != '%' {
+				b.WriteByte(layout[i])
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package exporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func TestParseColumnSpecs(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		data    string
+		columns []string
+		isError bool
+	}{
+		{
+			name:    "columns",
+			data:    `[{"column": "b", "title": "B"}, {"column": "a", "number_format": "0.00"}]`,
+			columns: []string{"b", "a"},
+		},
+		{
+			name:    "exprs",
+			data:    `[{"expr": "concat(first, ' ', [last name])"}, {"expr": "FORMAT_TIME(ts, '%Y')"}, {"column": "first"}]`,
+			columns: []string{"first", "last name", "ts"},
+		},
+		{name: "invalid_json", data: `{"column": "a"}`, isError: true},
+		{name: "empty", data: `[]`, isError: true},
+		{name: "both", data: `[{"column": "a", "expr": "concat(a)"}]`, isError: true},
+		{name: "neither", data: `[{"title": "a"}]`, isError: true},
+		{name: "unknown_function", data: `[{"expr": "upper(a)"}]`, isError: true},
+		{name: "format_time_args", data: `[{"expr": "format_time('%Y', ts)"}]`, isError: true},
+		{name: "trailing", data: `[{"expr": "concat(a) b"}]`, isError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			specs, err := ParseColumnSpecs(tc.data)
+			if tc.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.columns, columnSpecColumns(specs))
+		})
+	}
+}
+
+func TestMakeSheetColumns_error(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64},
+		{Name: "tags", ComplexType: schema.List{Item: schema.TypeString}},
+	}}
+
+	for _, data := range []string{
+		`[{"column": "missing"}]`,
+		`[{"expr": "concat(id, missing)"}]`,
+		`[{"expr": "concat(tags)"}]`,
+		`[{"expr": "format_time(id, '%Y')"}]`,
+	} {
+		specs, err := ParseColumnSpecs(data)
+		require.NoError(t, err)
+
+		_, err = makeSheetColumns(nil, specs, s)
+		require.Error(t, err, data)
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	tm := time.Date(2024, time.March, 5, 14, 7, 9, 123456000, time.UTC)
+
+	require.Equal(t, "2024-03-05 14:07:09.123456", formatTime(tm, "%Y-%m-%d %H:%M:%S.%f"))
+	require.Equal(t, "05 Mar 24, 02 PM, day 065", formatTime(tm, "%d %b %y, %I %p, day %j"))
+	require.Equal(t, "100% 2006 %q %", formatTime(tm, "100%% 2006 %q %"))
+}
+
+func TestConvert_columnSpecs(t *testing.T) {
+	rows := []any{
+		map[string]any{"id": 1, "first": "Ann", "last": "Lee", "day": uint64(19723), "ts": uint64(1704164645000000), "amount": 1.5},
+		map[string]any{"id": 2, "first": "Bob", "ts": uint64(1704164645000000)},
+	}
+
+	specs, err := ParseColumnSpecs(`[
+		{"expr": "concat(last, ', ', first, ' #', id)", "title": "Name"},
+		{"column": "amount", "title": "Amount", "number_format": "#,##0.00"},
+		{"expr": "format_time(ts, '%d.%m.%Y %H:%M')", "title": "Created"},
+		{"column": "id"},
+		{"expr": "concat(day)"}
+	]`)
+	require.NoError(t, err)
+
+	opts := &ConvertOptions{
+		Columns:     columnSpecColumns(specs),
+		ColumnSpecs: specs,
+		Schema: &schema.Schema{Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeInt64},
+			{Name: "first", Type: schema.TypeString},
+			{Name: "last", ComplexType: schema.Optional{Item: schema.TypeString}},
+			{Name: "day", Type: schema.TypeDate},
+			{Name: "ts", Type: schema.TypeTimestamp},
+			{Name: "amount", Type: schema.TypeFloat64},
+		}},
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+		NumberPrecisionMode: NumberPrecisionModeString,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, opts))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+
+	actual, err := f.GetRows(SheetName, excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"Name", "Amount", "Created", "id", "concat(day)"},
+		{"utf8", "double", "utf8", "int64", "utf8"},
+		{"Lee, Ann #1", "1.5", "02.01.2024 03:04", "1", "2024-01-01"},
+		{", Bob #2", "", "02.01.2024 03:04", "2"},
+	}, actual)
+
+	styleID, err := f.GetCellStyle(SheetName, "B3")
+	require.NoError(t, err)
+	style, err := f.GetStyle(styleID)
+	require.NoError(t, err)
+	require.NotNil(t, style.CustomNumFmt)
+	require.Equal(t, "#,##0.00", *style.CustomNumFmt)
+}
@@ -0,0 +1,496 @@
+package exporter
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yson"
+)
+
+// Simple types of type_v3 schemas that have no constants in the schema package.
+const (
+	typeUUID        schema.Type = "uuid"
+	typeJSON        schema.Type = "json"
+	typeNull        schema.Type = "null"
+	typeVoid        schema.Type = "void"
+	typeDate32      schema.Type = "date32"
+	typeDatetime64  schema.Type = "datetime64"
+	typeTimestamp64 schema.Type = "timestamp64"
+	typeInterval64  schema.Type = "interval64"
+)
+
+// ComplexTypeFormat is a text format of values of container types, e.g. lists and structs.
+type ComplexTypeFormat string
+
+const (
+	ComplexTypeFormatYSON ComplexTypeFormat = "yson"
+	ComplexTypeFormatJSON ComplexTypeFormat = "json"
+)
+
+// ComplexTypeFormats lists all supported formats of container values.
+var ComplexTypeFormats = []ComplexTypeFormat{ComplexTypeFormatYSON, ComplexTypeFormatJSON}
+
+var (
+	// excelUnixEpochDays is the excel serial number of unix epoch.
+	excelUnixEpochDays = int64(unixEpoch.Add(day).Sub(excelEpoch).Hours() / 24)
+
+	// Dates outside of [excelMinUnix, excelMaxUnix) are written as strings.
+	// Excel dates start from 1900 and dates before March 1900 are shifted by the fictional 29 February.
+	excelMinUnix = time.Date(1900, time.March, 1, 0, 0, 0, 0, time.UTC).Unix()
+	excelMaxUnix = time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC).Unix()
+)
+
+// convertColumn converts value of the column using its type_v3 if it is present.
+func (c *converter) convertColumn(col schema.Column, v any) (excelize.Cell, error) {
+	if col.ComplexType == nil {
+		return c.convert(col.Type, v)
+	}
+	return c.convertComplex(col.ComplexType, v)
+}
+
+// convertComplex converts value of type_v3 type.
+//
+// Optional and tagged types are written as their items, decimals as numbers or strings
+// depending on the precision mode, and containers as text of ComplexTypeFormat.
+func (c *converter) convertComplex(t schema.ComplexType, v any) (excelize.Cell, error) {
+	switch t := t.(type) {
+	case schema.Type:
+		return c.convert(t, v)
+	case schema.Decimal:
+		return c.convertDecimal(t, v)
+	case schema.Tagged:
+		return c.convertComplex(t.Item, v)
+	case schema.Optional:
+		// Nested optional values are wrapped into lists to distinguish outer and inner nulls.
+		if _, nested := unwrapTagged(t.Item).(schema.Optional); !nested {
+			return c.convertComplex(t.Item, v)
+		}
+	}
+	return c.convertContainer(t, v)
+}
+
+func (c *converter) convertContainer(t schema.ComplexType, v any) (excelize.Cell, error) {
+	v = normalizeComplexValue(t, v)
+
+	var data []byte
+	var err error
+	if c.complexTypeFormat == ComplexTypeFormatJSON {
+		data, err = json.Marshal(v)
+	} else {
+		data, err = yson.Marshal(v)
+	}
+	if err != nil {
+		return excelize.Cell{}, xerrors.Errorf("error converting %v to %s: %w", v, c.complexTypeFormat, err)
+	}
+
+	if data, err = fitCellText(data, c.lossless); err != nil {
+		return excelize.Cell{}, err
+	}
+	return excelize.Cell{Value: data}, nil
+}
+
+// convertDecimal converts decimal in YT binary representation.
+func (c *converter) convertDecimal(t schema.Decimal, v any) (excelize.Cell, error) {
+	data, ok := v.(string)
+	if !ok {
+		return excelize.Cell{}, xerrors.Errorf("unexpected decimal value of type %T", v)
+	}
+
+	text, err := decodeDecimal([]byte(data), t)
+	if err != nil {
+		return excelize.Cell{}, err
+	}
+
+	switch text {
+	case "nan", "inf", "-inf":
+		return excelize.Cell{Value: text}, nil
+	}
+
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return excelize.Cell{}, xerrors.Errorf("error parsing decimal %s: %w", text, err)
+	}
+
+	if fitsInNumber(text) {
+		return excelize.Cell{Value: f}, nil
+	}
+
+	switch c.numberPrecisionMode {
+	case NumberPrecisionModeError:
+		return excelize.Cell{}, xerrors.Errorf("can not fit %s in excel; use another long numbers handle", text)
+	case NumberPrecisionModeString:
+		return excelize.Cell{Value: text}, nil
+	case NumberPrecisionModeLose:
+		return excelize.Cell{Value: f}, nil
+	}
+	return excelize.Cell{}, xerrors.Errorf("long numbers handle not recognized")
+}
+
+func (c *converter) convertUUID(v any) (excelize.Cell, error) {
+	data, ok := v.(string)
+	if !ok {
+		return excelize.Cell{}, xerrors.Errorf("unexpected uuid value of type %T", v)
+	}
+	return excelize.Cell{Value: formatUUID(data)}, nil
+}
+
+func (c *converter) convertDate32(v any) (excelize.Cell, error) {
+	days, ok := toInt64(v)
+	if !ok {
+		return excelize.Cell{}, xerrors.Errorf("unexpected date32 value of type %T", v)
+	}
+
+	if sec := days * 86400; sec >= excelMinUnix && sec < excelMaxUnix {
+		return excelize.Cell{StyleID: c.styles.Date, Value: days + excelUnixEpochDays}, nil
+	}
+	return excelize.Cell{Value: time.Unix(days*86400, 0).UTC().Format(time.DateOnly)}, nil
+}
+
+func (c *converter) convertDatetime64(v any) (excelize.Cell, error) {
+	sec, ok := toInt64(v)
+	if !ok {
+		return excelize.Cell{}, xerrors.Errorf("unexpected datetime64 value of type %T", v)
+	}
+
+	if sec >= excelMinUnix && sec < excelMaxUnix {
+		excelDatetime := float64(sec+excelUnixEpochDays*86400) / 86400
+		return excelize.Cell{StyleID: c.styles.Datetime, Value: excelDatetime}, nil
+	}
+	return excelize.Cell{Value: time.Unix(sec, 0).UTC().Format(time.RFC3339)}, nil
+}
+
+// convertTimestamp64 is like convertTimestamp but also supports timestamps before unix epoch.
+func (c *converter) convertTimestamp64(v any) (excelize.Cell, error) {
+	us, ok := toInt64(v)
+	if !ok {
+		return excelize.Cell{}, xerrors.Errorf("unexpected timestamp64 value of type %T", v)
+	}
+
+	t := time.UnixMicro(us).UTC()
+	if sec := t.Unix(); us%1000 == 0 && sec >= excelMinUnix && sec < excelMaxUnix {
+		excelTimestamp := float64(us+excelUnixEpochDays*86400*1e6) / 86400 / 1e6
+		return excelize.Cell{StyleID: c.styles.Timestamp, Value: excelTimestamp}, nil
+	}
+	return excelize.Cell{Value: t.Format(strTimestampFormat)}, nil
+}
+
+// unwrapTagged returns item of tagged type.
+func unwrapTagged(t schema.ComplexType) schema.ComplexType {
+	for {
+		tagged, ok := t.(schema.Tagged)
+		if !ok {
+			return t
+		}
+		t = tagged.Item
+	}
+}
+
+// normalizeComplexValue replaces binary decimals and uuids inside the container value with their text.
+//
+// Structs are expected in named or positional form, dicts as lists of key-value pairs
+// and variants as pairs of alternative name or index and value.
+func normalizeComplexValue(t schema.ComplexType, v any) any {
+	if v == nil {
+		return nil
+	}
+
+	switch t := t.(type) {
+	case schema.Type:
+		if s, ok := v.(string); ok && t == typeUUID {
+			return formatUUID(s)
+		}
+	case schema.Decimal:
+		if s, ok := v.(string); ok {
+			if text, err := decodeDecimal([]byte(s), t); err == nil {
+				return text
+			}
+		}
+	case schema.Tagged:
+		return normalizeComplexValue(t.Item, v)
+	case schema.Optional:
+		if _, nested := unwrapTagged(t.Item).(schema.Optional); nested {
+			if l, ok := v.([]any); ok && len(l) == 1 {
+				return []any{normalizeComplexValue(t.Item, l[0])}
+			}
+			return v
+		}
+		return normalizeComplexValue(t.Item, v)
+	case schema.List:
+		if l, ok := v.([]any); ok {
+			out := make([]any, len(l))
+			for i, item := range l {
+				out[i] = normalizeComplexValue(t.Item, item)
+			}
+			return out
+		}
+	case schema.Tuple:
+		if l, ok := v.([]any); ok {
+			out := make([]any, len(l))
+			for i, item := range l {
+				out[i] = item
+				if i < len(t.Elements) {
+					out[i] = normalizeComplexValue(t.Elements[i].Type, item)
+				}
+			}
+			return out
+		}
+	case schema.Struct:
+		switch s := v.(type) {
+		case map[string]any:
+			out := make(map[string]any, len(s))
+			for k, item := range s {
+				out[k] = item
+			}
+			for _, m := range t.Members {
+				if item, ok := s[m.Name]; ok {
+					out[m.Name] = normalizeComplexValue(m.Type, item)
+				}
+			}
+			return out
+		case []any:
+			out := make([]any, len(s))
+			for i, item := range s {
+				out[i] = item
+				if i < len(t.Members) {
+					out[i] = normalizeComplexValue(t.Members[i].Type, item)
+				}
+			}
+			return out
+		}
+	case schema.Dict:
+		if l, ok := v.([]any); ok {
+			out := make([]any, len(l))
+			for i, item := range l {
+				out[i] = item
+				if pair, ok := item.([]any); ok && len(pair) == 2 {
+					out[i] = []any{normalizeComplexValue(t.Key, pair[0]), normalizeComplexValue(t.Value, pair[1])}
+				}
+			}
+			return out
+		}
+	case schema.Variant:
+		if pair, ok := v.([]any); ok && len(pair) == 2 {
+			if item, ok := variantItemType(t, pair[0]); ok {
+				return []any{pair[0], normalizeComplexValue(item, pair[1])}
+			}
+		}
+	}
+	return v
+}
+
+// variantItemType returns type of the variant alternative by its name or index.
+func variantItemType(t schema.Variant, alt any) (schema.ComplexType, bool) {
+	if name, ok := alt.(string); ok {
+		for _, m := range t.Members {
+			if m.Name == name {
+				return m.Type, true
+			}
+		}
+		return nil, false
+	}
+
+	i, ok := toInt64(alt)
+	switch {
+	case !ok || i < 0:
+		return nil, false
+	case len(t.Members) > 0 && i < int64(len(t.Members)):
+		return t.Members[i].Type, true
+	case i < int64(len(t.Elements)):
+		return t.Elements[i].Type, true
+	}
+	return nil, false
+}
+
+// decodeDecimal converts decimal in YT binary representation to text.
+//
+// Binary decimal is a big-endian integer of 4, 8, 16 or 32 bytes depending on the precision
+// with the sign bit inverted, that is scaled by 10^scale.
+// Maximum integer denotes nan, the next to maximum one is inf and its negation is -inf.
+func decodeDecimal(data []byte, t schema.Decimal) (string, error) {
+	if len(data) != decimalByteSize(t.Precision) {
+		return "", xerrors.Errorf("unexpected size %d of binary decimal with precision %d", len(data), t.Precision)
+	}
+
+	b := make([]byte, len(data))
+	copy(b, data)
+	b[0] ^= 0x80
+
+	v := new(big.Int).SetBytes(b)
+	if b[0]&0x80 != 0 {
+		// Negative two's complement value.
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+
+	maxValue := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8-1)), big.NewInt(1))
+	inf := new(big.Int).Sub(maxValue, big.NewInt(1))
+	switch {
+	case v.Cmp(maxValue) == 0:
+		return "nan", nil
+	case v.Cmp(inf) == 0:
+		return "inf", nil
+	case v.Cmp(inf.Neg(inf)) == 0:
+		return "-inf", nil
+	}
+
+	sign := ""
+	if v.Sign() < 0 {
+		sign = "-"
+		v.Neg(v)
+	}
+
+	digits := v.String()
+	if t.Scale <= 0 {
+		return sign + digits, nil
+	}
+	if len(digits) <= t.Scale {
+		digits = strings.Repeat("0", t.Scale-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-t.Scale] + "." + digits[len(digits)-t.Scale:], nil
+}
+
+// decimalByteSize returns the size of binary decimal with given precision.
+func decimalByteSize(precision int) int {
+	switch {
+	case precision <= 9:
+		return 4
+	case precision <= 18:
+		return 8
+	case precision <= 38:
+		return 16
+	default:
+		return 32
+	}
+}
+
+// formatUUID formats 16 byte uuid as text, e.g. 01234567-89ab-cdef-0123-456789abcdef.
+//
+// Values of other sizes are returned as is.
+func formatUUID(data string) string {
+	if len(data) != 16 {
+		return data
+	}
+
+	s := hex.EncodeToString([]byte(data))
+	return s[:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+// toInt64 returns value of an integer that fits in int64.
+func toInt64(v any) (int64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if rv.Uint() > 1<<63-1 {
+			return 0, false
+		}
+		return int64(rv.Uint()), true
+	default:
+		return 0, false
+	}
+}
+
+// plainName matches struct member names that are written without quotes in type descriptions.
+var plainName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// describeColumnType returns text description of the column type that is written to the header.
+//
+// Top level optional is omitted as nullability is not shown for columns without type_v3 either,
+// e.g. optional<list<int64>> is described as list<int64>.
+func describeColumnType(col schema.Column) string {
+	if col.ComplexType == nil {
+		return string(col.Type)
+	}
+
+	t := col.ComplexType
+	if o, ok := t.(schema.Optional); ok {
+		t = o.Item
+	}
+	return describeType(t)
+}
+
+// describeType returns text description of type_v3 type, e.g. struct<a:int64,b:list<utf8>>.
+func describeType(t schema.ComplexType) string {
+	var b strings.Builder
+	writeTypeDescription(&b, t)
+	return b.String()
+}
+
+func writeTypeDescription(b *strings.Builder, t schema.ComplexType) {
+	writeMembers := func(members []schema.StructMember) {
+		for i, m := range members {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if plainName.MatchString(m.Name) {
+				b.WriteString(m.Name)
+			} else {
+				b.WriteString(strconv.Quote(m.Name))
+			}
+			b.WriteByte(':')
+			writeTypeDescription(b, m.Type)
+		}
+	}
+
+	writeElements := func(elements []schema.TupleElement) {
+		for i, e := range elements {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeTypeDescription(b, e.Type)
+		}
+	}
+
+	switch t := t.(type) {
+	case schema.Type:
+		b.WriteString(string(t))
+	case schema.Decimal:
+		b.WriteString("decimal(" + strconv.Itoa(t.Precision) + "," + strconv.Itoa(t.Scale) + ")")
+	case schema.Optional:
+		b.WriteString("optional<")
+		writeTypeDescription(b, t.Item)
+		b.WriteByte('>')
+	case schema.List:
+		b.WriteString("list<")
+		writeTypeDescription(b, t.Item)
+		b.WriteByte('>')
+	case schema.Struct:
+		b.WriteString("struct<")
+		writeMembers(t.Members)
+		b.WriteByte('>')
+	case schema.Tuple:
+		b.WriteString("tuple<")
+		writeElements(t.Elements)
+		b.WriteByte('>')
+	case schema.Variant:
+		b.WriteString("variant<")
+		if len(t.Members) > 0 {
+			writeMembers(t.Members)
+		} else {
+			writeElements(t.Elements)
+		}
+		b.WriteByte('>')
+	case schema.Dict:
+		b.WriteString("dict<")
+		writeTypeDescription(b, t.Key)
+		b.WriteByte(',')
+		writeTypeDescription(b, t.Value)
+		b.WriteByte('>')
+	case schema.Tagged:
+		b.WriteString("tagged<" + strconv.Quote(t.Tag) + ",")
+		writeTypeDescription(b, t.Item)
+		b.WriteByte('>')
+	default:
+		b.WriteString("unknown")
+	}
+}
@@ -0,0 +1,239 @@
+package exporter
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// binaryDecimal64 returns YT binary representation of decimal with precision from 10 to 18.
+func binaryDecimal64(v int64) string {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v)^1<<63)
+	return string(b)
+}
+
+func TestDecodeDecimal(t *testing.T) {
+	const maxInt64 = 1<<63 - 1
+
+	for _, tc := range []struct {
+		name     string
+		in       string
+		typ      schema.Decimal
+		expected string
+	}{
+		{name: "positive", in: binaryDecimal64(1234), typ: schema.Decimal{Precision: 10, Scale: 3}, expected: "1.234"},
+		{name: "negative", in: binaryDecimal64(-1500), typ: schema.Decimal{Precision: 10, Scale: 3}, expected: "-1.500"},
+		{name: "fraction", in: binaryDecimal64(-5), typ: schema.Decimal{Precision: 10, Scale: 3}, expected: "-0.005"},
+		{name: "zero-scale", in: binaryDecimal64(42), typ: schema.Decimal{Precision: 18}, expected: "42"},
+		{name: "decimal32", in: "\x80\x00\x00\x07", typ: schema.Decimal{Precision: 3, Scale: 1}, expected: "0.7"},
+		{name: "nan", in: binaryDecimal64(maxInt64), typ: schema.Decimal{Precision: 10, Scale: 3}, expected: "nan"},
+		{name: "inf", in: binaryDecimal64(maxInt64 - 1), typ: schema.Decimal{Precision: 10, Scale: 3}, expected: "inf"},
+		{name: "-inf", in: binaryDecimal64(-maxInt64 + 1), typ: schema.Decimal{Precision: 10, Scale: 3}, expected: "-inf"},
+		{
+			name:     "decimal128",
+			in:       "\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0b",
+			typ:      schema.Decimal{Precision: 35, Scale: 1},
+			expected: "1.1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			text, err := decodeDecimal([]byte(tc.in), tc.typ)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, text)
+		})
+	}
+
+	_, err := decodeDecimal([]byte("\x80\x00"), schema.Decimal{Precision: 10, Scale: 3})
+	require.Error(t, err)
+}
+
+func TestConvertComplex(t *testing.T) {
+	styles := &CellStyles{Number: 1, Date: 2, Datetime: 3, Timestamp: 4}
+	decimal := schema.Decimal{Precision: 18, Scale: 2}
+
+	for _, tc := range []struct {
+		name   string
+		typ    schema.ComplexType
+		in     any
+		format ComplexTypeFormat
+		mode   NumberPrecisionMode
+		cell   excelize.Cell
+	}{
+		{
+			name: "optional",
+			typ:  schema.Optional{Item: schema.TypeInt64},
+			in:   int64(-64),
+			cell: excelize.Cell{StyleID: styles.Number, Value: int64(-64)},
+		},
+		{
+			name: "tagged",
+			typ:  schema.Tagged{Tag: "image/png", Item: schema.TypeString},
+			in:   "png",
+			cell: excelize.Cell{Value: "png"},
+		},
+		{
+			name: "decimal",
+			typ:  decimal,
+			in:   binaryDecimal64(12345),
+			cell: excelize.Cell{Value: 123.45},
+		},
+		{
+			name: "large-decimal",
+			typ:  decimal,
+			in:   binaryDecimal64(1234567890123456789),
+			mode: NumberPrecisionModeString,
+			cell: excelize.Cell{Value: "12345678901234567.89"},
+		},
+		{
+			name: "large-decimal-lose",
+			typ:  decimal,
+			in:   binaryDecimal64(1234567890123456789),
+			mode: NumberPrecisionModeLose,
+			cell: excelize.Cell{Value: 12345678901234567.89},
+		},
+		{
+			name: "decimal-nan",
+			typ:  decimal,
+			in:   binaryDecimal64(1<<63 - 1),
+			cell: excelize.Cell{Value: "nan"},
+		},
+		{
+			name: "uuid",
+			typ:  typeUUID,
+			in:   "\x01\x23\x45\x67\x89\xab\xcd\xef\x01\x23\x45\x67\x89\xab\xcd\xef",
+			cell: excelize.Cell{Value: "01234567-89ab-cdef-0123-456789abcdef"},
+		},
+		{
+			name: "date32",
+			typ:  typeDate32,
+			in:   int64(1),
+			cell: excelize.Cell{StyleID: styles.Date, Value: int64(25570)},
+		},
+		{
+			name: "date32-before-excel-epoch",
+			typ:  typeDate32,
+			in:   int64(-25567),
+			cell: excelize.Cell{Value: "1900-01-01"},
+		},
+		{
+			name: "datetime64-negative",
+			typ:  typeDatetime64,
+			in:   int64(-86400 * 365 * 100),
+			cell: excelize.Cell{Value: "1870-01-25T00:00:00Z"},
+		},
+		{
+			name: "timestamp64",
+			typ:  typeTimestamp64,
+			in:   time.Date(1969, time.December, 31, 12, 0, 0, 0, time.UTC).UnixMicro(),
+			cell: excelize.Cell{StyleID: styles.Timestamp, Value: 25568.5},
+		},
+		{
+			name: "timestamp64-micro",
+			typ:  typeTimestamp64,
+			in:   int64(-1),
+			cell: excelize.Cell{Value: "1969-12-31T23:59:59.999999Z"},
+		},
+		{
+			name: "list",
+			typ:  schema.List{Item: decimal},
+			in:   []any{binaryDecimal64(150), nil},
+			cell: excelize.Cell{Value: []byte(`["1.50";#;]`)},
+		},
+		{
+			name: "struct",
+			typ: schema.Optional{Item: schema.Struct{Members: []schema.StructMember{
+				{Name: "id", Type: typeUUID},
+				{Name: "tags", Type: schema.List{Item: schema.TypeString}},
+			}}},
+			in: map[string]any{
+				"id":   "\x01\x23\x45\x67\x89\xab\xcd\xef\x01\x23\x45\x67\x89\xab\xcd\xef",
+				"tags": []any{"a", "b"},
+			},
+			format: ComplexTypeFormatJSON,
+			cell:   excelize.Cell{Value: []byte(`{"id":"01234567-89ab-cdef-0123-456789abcdef","tags":["a","b"]}`)},
+		},
+		{
+			name:   "dict",
+			typ:    schema.Dict{Key: schema.TypeString, Value: decimal},
+			in:     []any{[]any{"a", binaryDecimal64(1)}},
+			format: ComplexTypeFormatJSON,
+			cell:   excelize.Cell{Value: []byte(`[["a","0.01"]]`)},
+		},
+		{
+			name: "variant",
+			typ: schema.Variant{Members: []schema.StructMember{
+				{Name: "n", Type: schema.TypeInt64},
+				{Name: "d", Type: decimal},
+			}},
+			in:   []any{"d", binaryDecimal64(-1)},
+			cell: excelize.Cell{Value: []byte(`[d;"-0.01";]`)},
+		},
+		{
+			name: "nested-optional",
+			typ:  schema.Optional{Item: schema.Optional{Item: schema.TypeInt64}},
+			in:   []any{nil},
+			cell: excelize.Cell{Value: []byte(`[#;]`)},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.mode == "" {
+				tc.mode = NumberPrecisionModeError
+			}
+			c := converter{styles: styles, numberPrecisionMode: tc.mode, complexTypeFormat: tc.format}
+
+			cell, err := c.convertComplex(tc.typ, tc.in)
+			require.NoError(t, err)
+			require.Equal(t, tc.cell, cell)
+		})
+	}
+}
+
+func TestConvertComplexError(t *testing.T) {
+	c := converter{numberPrecisionMode: NumberPrecisionModeError}
+
+	_, err := c.convertComplex(schema.Decimal{Precision: 18, Scale: 2}, binaryDecimal64(1234567890123456789))
+	require.Error(t, err)
+}
+
+func TestDescribeColumnType(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		col      schema.Column
+		expected string
+	}{
+		{name: "v1", col: schema.Column{Type: schema.TypeInt64}, expected: "int64"},
+		{
+			name:     "optional",
+			col:      schema.Column{Type: schema.TypeInt64, ComplexType: schema.Optional{Item: schema.TypeInt64}},
+			expected: "int64",
+		},
+		{name: "decimal", col: schema.Column{ComplexType: schema.Decimal{Precision: 10, Scale: 2}}, expected: "decimal(10,2)"},
+		{
+			name: "struct",
+			col: schema.Column{ComplexType: schema.Struct{Members: []schema.StructMember{
+				{Name: "a", Type: schema.Optional{Item: schema.TypeString}},
+				{Name: "b c", Type: schema.List{Item: schema.TypeBoolean}},
+			}}},
+			expected: `struct<a:optional<utf8>,"b c":list<boolean>>`,
+		},
+		{
+			name: "containers",
+			col: schema.Column{ComplexType: schema.Tuple{Elements: []schema.TupleElement{
+				{Type: schema.Dict{Key: schema.TypeString, Value: schema.TypeInt64}},
+				{Type: schema.Variant{Elements: []schema.TupleElement{{Type: typeUUID}, {Type: typeDate32}}}},
+				{Type: schema.Tagged{Tag: "image/png", Item: schema.TypeBytes}},
+			}}},
+			expected: `tuple<dict<utf8,int64>,variant<uuid,date32>,tagged<"image/png",string>>`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, describeColumnType(tc.col))
+		})
+	}
+}
@@ -2,11 +2,11 @@ package exporter
 
 import (
 	"fmt"
+	"io"
 	"slices"
 	"strings"
 	"time"
 
-	"github.com/c2h5oh/datasize"
 	"github.com/xuri/excelize/v2"
 
 	"go.ytsaurus.tech/library/go/core/xerrors"
@@ -16,12 +16,17 @@ import (
 )
 
 const (
-	// SheetName stores the name of the resulting excel sheet.
+	// SheetName stores the name of the first sheet of the resulting excel file.
 	SheetName          = "Sheet1"
 	strTimestampFormat = "2006-01-02T15:04:05.999999Z"
 	maxExcelStrLen     = 32767
 
 	day = 24 * time.Hour
+
+	numFmtNumber    = "0"
+	numFmtDate      = "yyyy-mm-dd"
+	numFmtDatetime  = "yyyy-mm-ddThh:mm:ssZ"
+	numFmtTimestamp = "yyyy-mm-ddThh:mm:ss.000Z"
 )
 
 var (
@@ -32,12 +37,15 @@ var (
 type converter struct {
 	styles              *CellStyles
 	numberPrecisionMode NumberPrecisionMode
+	complexTypeFormat   ComplexTypeFormat
+	// lossless makes conversion fail on values that can not be written without loss instead of truncating them.
+	lossless bool
 }
 
 func (c *converter) convertBytes(v any) (excelize.Cell, error) {
-	data := v.(string)
-	if len(data) > maxExcelStrLen {
-		data = data[:maxExcelStrLen]
+	data, err := fitCellText(v.(string), c.lossless)
+	if err != nil {
+		return excelize.Cell{}, err
 	}
 	return excelize.Cell{Value: data}, nil
 }
@@ -92,10 +100,9 @@ func (c *converter) convertAny(v any) (excelize.Cell, error) {
 		return excelize.Cell{}, xerrors.Errorf("error converting %s to yson: %w", v, err)
 	}
 
-	if len(data) > maxExcelStrLen {
-		data = data[:maxExcelStrLen]
+	if data, err = fitCellText(data, c.lossless); err != nil {
+		return excelize.Cell{}, err
 	}
-
 	return excelize.Cell{Value: data}, nil
 }
 
@@ -156,6 +163,20 @@ func (c *converter) convert(t schema.Type, v any) (excelize.Cell, error) {
 		return c.convertInterval(v)
 	case schema.TypeAny:
 		return c.convertAny(v)
+	case typeUUID:
+		return c.convertUUID(v)
+	case typeJSON:
+		return c.convertString(v)
+	case typeDate32:
+		return c.convertDate32(v)
+	case typeDatetime64:
+		return c.convertDatetime64(v)
+	case typeTimestamp64:
+		return c.convertTimestamp64(v)
+	case typeInterval64:
+		return c.convertLargeIntegers(v)
+	case typeNull, typeVoid:
+		return excelize.Cell{}, nil
 	default:
 		return excelize.Cell{Value: "UNSUPPORTED"}, nil
 	}
@@ -195,24 +216,194 @@ type ConvertOptions struct {
 	Schema              *schema.Schema
 	ExportOptions       *ExportOptions
 	NumberPrecisionMode NumberPrecisionMode
+	// MultiSheet allows spilling rows that do not fit into a single sheet over the next sheets.
+	//
+	// Header is repeated on each sheet.
+	MultiSheet bool
+
+	// Format is the format of the resulting file; xlsx by default.
+	Format Format
+	// Delimiter is the field delimiter of csv format; comma by default.
+	Delimiter rune
+	// ComplexTypeFormat is the text format of container values; yson by default.
+	ComplexTypeFormat ComplexTypeFormat
+	// Header configures the header rows and the presentation of the sheets.
+	Header HeaderOptions
+	// ColumnSpecs are the columns of the sheet in the order they are written;
+	// Columns are written in the order of Schema if empty. Requires Schema.
+	ColumnSpecs []ColumnSpec
+	// Profile is ProfileRoundtrip if the values must be written without loss.
+	Profile Profile
+	// RoundtripSchema is the schema stored in the hidden sheet of roundtrip profile.
+	RoundtripSchema *schema.Schema
+	// Metadata is written to the hidden sheet MetadataSheetName if set.
+	Metadata *Metadata
+
+	// sheetRowLimit overrides the max number of rows in a sheet; excel limit is used by default.
+	sheetRowLimit int
+	// workbookRowLimit overrides the max number of rows in all the sheets written by ConvertWorkbook.
+	workbookRowLimit int64
+}
+
+// Convert reads rows from r and writes them to w as excel file or a file of another Format.
+//
+// The file is streamed as rows arrive, so memory consumption does not depend on the table size.
+// Conversion fails as soon as the output exceeds ExportOptions.MaxExcelFileSize bytes.
+func Convert(w io.Writer, r yt.TableReader, opts *ConvertOptions) error {
+	b := newWorkbook(w, opts)
+	defer b.out.cleanup()
+
+	if err := b.writeTable(r, ""); err != nil {
+		return err
+	}
+	return b.Close()
+}
+
+// OpenTableFunc opens a reader of table rows.
+type OpenTableFunc func() (yt.TableReader, error)
+
+// ConvertTables is like Convert but writes each table starting from a new sheet.
+//
+// Tables are opened one at a time and closed as soon as they are converted.
+func ConvertTables(w io.Writer, tables []OpenTableFunc, opts *ConvertOptions) error {
+	b := newWorkbook(w, opts)
+	defer b.out.cleanup()
+
+	for _, open := range tables {
+		r, err := open()
+		if err != nil {
+			return err
+		}
+
+		err = b.writeTable(r, "")
+		_ = r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return b.Close()
+}
+
+// WorkbookSheet is a table written to a separate sheet by ConvertWorkbook.
+type WorkbookSheet struct {
+	// Name is the name of the sheet; generated from its position if empty.
+	Name string
+	// Open opens the reader of the rows of the sheet.
+	Open OpenTableFunc
+	// Columns, Schema and ColumnSpecs replace the ones of ConvertOptions for the sheet.
+	Columns     []string
+	Schema      *schema.Schema
+	ColumnSpecs []ColumnSpec
+}
+
+// ConvertWorkbook is like ConvertTables but each of the tables has its own columns and sheet name.
+//
+// Limits of ExportOptions and MaxRowCount apply to the whole file.
+func ConvertWorkbook(w io.Writer, sheets []WorkbookSheet, opts *ConvertOptions) error {
+	b := newWorkbook(w, opts)
+	defer b.out.cleanup()
+	b.rowLimit = MaxRowCount
+	if opts.workbookRowLimit != 0 {
+		b.rowLimit = opts.workbookRowLimit
+	}
+
+	for i, sheet := range sheets {
+		sheetOpts := *opts
+		sheetOpts.Columns = sheet.Columns
+		sheetOpts.Schema = sheet.Schema
+		sheetOpts.ColumnSpecs = sheet.ColumnSpecs
+		b.opts = &sheetOpts
+
+		name := sheet.Name
+		if name == "" {
+			name = makeSheetName(i + 1)
+		}
+
+		r, err := sheet.Open()
+		if err != nil {
+			return xerrors.Errorf("error opening sheet %q: %w", name, err)
+		}
+
+		err = b.writeTable(r, name)
+		_ = r.Close()
+		if err != nil {
+			return xerrors.Errorf("error writing sheet %q: %w", name, err)
+		}
+	}
+
+	b.opts = opts
+	return b.Close()
+}
+
+// workbook converts tables to the sheets of a single file.
+type workbook struct {
+	out        sheetWriter
+	opts       *ConvertOptions
+	c          *converter
+	sheetCount int
+	// rowCount is the number of rows written to all the sheets, rowLimit limits it unless zero.
+	rowCount int64
+	rowLimit int64
+	// numFmtStyles are styles of the custom number formats of the columns by format.
+	numFmtStyles map[string]int
 }
 
-func Convert(r yt.TableReader, opts *ConvertOptions) (*excelize.File, error) {
-	out := excelize.NewFile()
+func newWorkbook(w io.Writer, opts *ConvertOptions) *workbook {
+	if p := opts.ExportOptions.Progress; p != nil {
+		w = &progressWriter{w: w, p: p}
+	}
+	out := newSheetWriter(&limitedWriter{w: w, limit: opts.ExportOptions.MaxExcelFileSize}, opts)
+	styles := registerCellStyles(out)
+
+	return &workbook{
+		out:  out,
+		opts: opts,
+		c: &converter{
+			styles:              styles,
+			numberPrecisionMode: opts.NumberPrecisionMode,
+			complexTypeFormat:   opts.ComplexTypeFormat,
+			lossless:            opts.Profile == ProfileRoundtrip,
+		},
+	}
+}
+
+// newSheet starts a new sheet; its name is generated from the sheet count if empty.
+func (b *workbook) newSheet(name string, deferHeader bool) error {
+	if b.sheetCount == MaxSheetCount {
+		return ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of sheets %d", MaxSheetCount))
+	}
 
+	b.sheetCount++
+	if name == "" {
+		name = makeSheetName(b.sheetCount)
+	}
+	return b.out.NewSheet(name, deferHeader)
+}
+
+// writeTable writes rows of r starting from a new sheet.
+//
+// name is the name of the first sheet of the table; the names of the sheets the rows spill over are generated.
+func (b *workbook) writeTable(r yt.TableReader, name string) error {
+	opts := b.opts
+	out := b.out
 	hasSchema := opts.Schema != nil && len(opts.Schema.Columns) > 0
 
+	// Columns of a table with schema are known in advance, columns of a schemaless table
+	// are collected from the rows.
+	var columns []sheetColumn
 	var nameToCol map[string]*Column
 	var nextColIndex int
+	var names []excelize.Cell
 
 	if hasSchema {
-		nameToCol = makeHeader(opts.Columns, opts.Schema)
-		if err := writeHeader(nameToCol, out); err != nil {
-			return nil, err
+		var err error
+		columns, err = makeSheetColumns(opts.Columns, opts.ColumnSpecs, opts.Schema)
+		if err != nil {
+			return ErrBadRequest.Wrap(err)
 		}
-		for _, col := range nameToCol {
-			if col.Index >= nextColIndex {
-				nextColIndex = col.Index + 1
+		for i := range columns {
+			if columns[i].numberFormat != "" {
+				columns[i].style = b.numberFormatStyle(columns[i].numberFormat)
 			}
 		}
 	} else {
@@ -220,7 +411,38 @@ func Convert(r yt.TableReader, opts *ConvertOptions) (*excelize.File, error) {
 		nextColIndex = 1
 	}
 
-	updateHeaders := func(row map[string]any) error {
+	headerRows := opts.Header.rowCount(hasSchema)
+	out.SetLayout(sheetLayout{headerRows: headerRows, HeaderOptions: opts.Header})
+	deferHeader := !hasSchema && headerRows > 0
+
+	sheetRowLimit := excelMaxRowCount
+	if opts.sheetRowLimit != 0 {
+		sheetRowLimit = opts.sheetRowLimit
+	}
+	sheetRowLimit -= headerRows
+
+	sheetRowCount := 0
+
+	startSheet := func() error {
+		sheetRowCount = 0
+
+		// Header of a schemaless table is only known after all rows of the sheet are read.
+		if err := b.newSheet(name, deferHeader); err != nil {
+			return err
+		}
+		name = ""
+
+		if hasSchema && headerRows > 0 {
+			return writeHeader(columns, headerRows, out)
+		}
+		return nil
+	}
+
+	if err := startSheet(); err != nil {
+		return err
+	}
+
+	updateHeaders := func(row map[string]any) {
 		var newKeys []string
 
 		for k := range row {
@@ -234,101 +456,131 @@ func Convert(r yt.TableReader, opts *ConvertOptions) (*excelize.File, error) {
 		for _, k := range newKeys {
 			col := &Column{Index: nextColIndex}
 			nameToCol[k] = col
-
-			axis, _ := excelize.CoordinatesToCellName(col.Index, 1)
-			if err := out.SetCellValue(SheetName, axis, k); err != nil {
-				return err
-			}
+			names = append(names, excelize.Cell{Value: k})
 			nextColIndex++
 		}
-		return nil
 	}
 
-	styles, err := registerCellStyles(out)
-	if err != nil {
-		return nil, err
-	}
-
-	c := &converter{styles: styles, numberPrecisionMode: opts.NumberPrecisionMode}
-
-	convert := func(col *Column, v any) (excelize.Cell, error) {
+	convertRow := func(row map[string]any, excelRow []excelize.Cell, rowIndex int) error {
 		if hasSchema {
-			return c.convert(col.Type, v)
-		}
-		return c.convertAuto(v)
-	}
-
-	totalRowWeight := 0
-	excelRowNumber := 2
-	if hasSchema {
-		excelRowNumber++
-	}
-
-	for r.Next() {
-		var row map[string]any
-		err = r.Scan(&row)
-		if err != nil {
-			return nil, xerrors.Errorf("error reading table row: %w", err)
-		}
-
-		if !hasSchema {
-			if err := updateHeaders(row); err != nil {
-				return nil, err
+			for i, col := range columns {
+				cell, err := col.value(b.c, row)
+				if err != nil {
+					return fmt.Errorf("error converting value from column %s and row %d: %w", col.title, rowIndex, err)
+				}
+				if col.style != 0 && cell.Value != nil {
+					cell.StyleID = col.style
+				}
+				excelRow[i] = cell
 			}
+			return nil
 		}
 
-		excelRow := make(map[int]excelize.Cell)
 		for k, v := range row {
 			if v == nil {
 				continue
 			}
 
-			col, ok := nameToCol[k]
-			if !ok {
-				return nil, xerrors.Errorf("unable to find column %s in schema %+v", k, nameToCol)
-			}
-
-			cell, err := convert(col, v)
+			col := nameToCol[k]
+			cell, err := b.c.convertAuto(v)
 			if err != nil {
-				errRowIndex := excelRowNumber - 1
-				if hasSchema {
-					errRowIndex = excelRowNumber - 3
-				}
-				return nil, fmt.Errorf("error converting value from column %s and row %d: %w", k, errRowIndex, err)
+				return fmt.Errorf("error converting value from column %s and row %d: %w", k, rowIndex, err)
 			}
+			excelRow[col.Index-1] = cell
+		}
+		return nil
+	}
+
+	rowIndex := 0
+	var excelRow []excelize.Cell
 
-			excelRow[col.Index] = cell
+	for r.Next() {
+		var row map[string]any
+		if err := r.Scan(&row); err != nil {
+			return xerrors.Errorf("error reading table row: %w", err)
 		}
 
-		for i, cell := range excelRow {
-			axis, _ := excelize.CoordinatesToCellName(i, excelRowNumber)
-			if err := out.SetCellStyle(SheetName, axis, axis, cell.StyleID); err != nil {
-				return nil, err
+		if sheetRowCount == sheetRowLimit {
+			if !opts.MultiSheet {
+				return ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export; max is %d", sheetRowLimit))
 			}
-			if err := out.SetCellValue(SheetName, axis, cell.Value); err != nil {
-				return nil, err
+			if deferHeader {
+				out.SetHeader(names)
 			}
+			if err := startSheet(); err != nil {
+				return err
+			}
+		}
+
+		rowLen := len(columns)
+		if !hasSchema {
+			updateHeaders(row)
+			rowLen = nextColIndex - 1
 		}
 
-		// Even if stream writer is used excelize will materialize the whole excel file in memory on write.
-		// That's why a special heuristic is used to control the output file size.
-		// todo remove when https://github.com/360EntSecGroup-Skylar/excelize/issues/650 is resolved.
-		totalRowWeight += rowWeight(excelRow)
-		if totalRowWeight >= opts.ExportOptions.MaxExcelFileSize {
-			return nil, xerrors.Errorf("max total row weight exceeded: %v >= %v; "+
-				"try specifying a smaller range of rows or exclude unneeded columns",
-				datasize.ByteSize(totalRowWeight).HumanReadable(),
-				datasize.ByteSize(opts.ExportOptions.MaxExcelFileSize).HumanReadable())
+		excelRow = slices.Grow(excelRow[:0], rowLen)[:rowLen]
+		clear(excelRow)
+
+		if err := convertRow(row, excelRow, rowIndex); err != nil {
+			return err
 		}
 
-		excelRowNumber++
+		if b.rowLimit != 0 && b.rowCount == b.rowLimit {
+			return ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export in the workbook; max is %d", b.rowLimit))
+		}
+		if err := out.WriteRow(excelRow); err != nil {
+			return xerrors.Errorf("error writing row %d: %w", rowIndex, err)
+		}
+		b.rowCount++
+		if p := b.opts.ExportOptions.Progress; p != nil {
+			p.rows.Add(1)
+		}
+
+		rowIndex++
+		sheetRowCount++
 	}
 
 	if r.Err() != nil {
-		return nil, xerrors.Errorf("error reading data: %w", r.Err())
+		return xerrors.Errorf("error reading data: %w", r.Err())
 	}
 
-	return out, nil
+	if deferHeader {
+		out.SetHeader(names)
+	}
+	return nil
+}
+
+// numberFormatStyle returns the id of the cell style with the number format registering it once per file.
+func (b *workbook) numberFormatStyle(numFmt string) int {
+	if id, ok := b.numFmtStyles[numFmt]; ok {
+		return id
+	}
+	if b.numFmtStyles == nil {
+		b.numFmtStyles = make(map[string]int)
+	}
+	id := b.out.NewStyle(numFmt)
+	b.numFmtStyles[numFmt] = id
+	return id
+}
+
+// Close finishes the file.
+func (b *workbook) Close() error {
+	if b.opts.RoundtripSchema != nil {
+		if err := b.writeSchemaSheet(b.opts.RoundtripSchema); err != nil {
+			return err
+		}
+	}
+	if b.opts.Metadata != nil {
+		if err := b.writeMetadataSheet(b.opts.Metadata); err != nil {
+			return err
+		}
+	}
+	return b.out.Close()
+}
+
+// makeSheetName returns the name of i-th sheet starting from 1, e.g. Sheet1, Sheet2...
+func makeSheetName(i int) string {
+	return fmt.Sprintf("Sheet%d", i)
 }
 
 // makeHeader creates mapping from column name to indexed excel column.
@@ -358,61 +610,38 @@ func makeHeader(columns []string, s *schema.Schema) map[string]*Column {
 	return header
 }
 
-// writeHeader writes column names on the first row of the sheet and
-// their types on the second.
-func writeHeader(header map[string]*Column, w *excelize.File) error {
-	for name, col := range header {
-		axis, _ := excelize.CoordinatesToCellName(col.Index, 1)
-		if err := w.SetCellValue(SheetName, axis, name); err != nil {
-			return err
-		}
-
-		axis, _ = excelize.CoordinatesToCellName(col.Index, 2)
-		if err := w.SetCellValue(SheetName, axis, col.Column.Type); err != nil {
-			return err
-		}
+// writeHeader writes column titles on the first row of the sheet and
+// their types on the second unless the header consists of a single row.
+//
+// Types of columns with type_v3 are described in full, e.g. list<int64>.
+func writeHeader(columns []sheetColumn, rows int, w sheetWriter) error {
+	names := make([]excelize.Cell, len(columns))
+	types := make([]excelize.Cell, len(columns))
+	for i, col := range columns {
+		names[i] = excelize.Cell{Value: col.title}
+		types[i] = excelize.Cell{Value: col.typ}
 	}
 
-	return nil
+	if err := w.WriteRow(names); err != nil {
+		return err
+	}
+	if rows == 1 {
+		return nil
+	}
+	return w.WriteRow(types)
 }
 
 type CellStyles struct {
 	Number, Date, Datetime, Timestamp int
 }
 
-func registerCellStyles(f *excelize.File) (*CellStyles, error) {
-	numberNumFmt := "0"
-	numberFormat, err := f.NewStyle(&excelize.Style{CustomNumFmt: &numberNumFmt})
-	if err != nil {
-		return nil, err
-	}
-
-	dateNumFmt := "yyyy-mm-dd"
-	dateFormat, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateNumFmt})
-	if err != nil {
-		return nil, err
-	}
-
-	datetimeNumFmt := "yyyy-mm-ddThh:mm:ssZ"
-	datetimeFormat, err := f.NewStyle(&excelize.Style{CustomNumFmt: &datetimeNumFmt})
-	if err != nil {
-		return nil, err
+func registerCellStyles(w sheetWriter) *CellStyles {
+	return &CellStyles{
+		Number:    w.NewStyle(numFmtNumber),
+		Date:      w.NewStyle(numFmtDate),
+		Datetime:  w.NewStyle(numFmtDatetime),
+		Timestamp: w.NewStyle(numFmtTimestamp),
 	}
-
-	timestampNumFmt := "yyyy-mm-ddThh:mm:ss.000Z"
-	timestampFormat, err := f.NewStyle(&excelize.Style{CustomNumFmt: &timestampNumFmt})
-	if err != nil {
-		return nil, err
-	}
-
-	s := &CellStyles{
-		Number:    numberFormat,
-		Date:      dateFormat,
-		Datetime:  datetimeFormat,
-		Timestamp: timestampFormat,
-	}
-
-	return s, nil
 }
 
 // fitsInNumber checks whether numeric type can be converted to excel number type,
@@ -1,6 +1,7 @@
 package exporter
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 	"testing"
@@ -11,6 +12,7 @@ import (
 
 	"go.ytsaurus.tech/yt/go/schema"
 	"go.ytsaurus.tech/yt/go/yson"
+	"go.ytsaurus.tech/yt/go/yt"
 )
 
 func TestMakeHeader(t *testing.T) {
@@ -45,9 +47,20 @@ func TestMakeHeader(t *testing.T) {
 }
 
 func TestRegisterCellStyles(t *testing.T) {
-	f := excelize.NewFile()
-	_, err := registerCellStyles(f)
+	var buf bytes.Buffer
+	w := newXLSXWriter(&buf)
+
+	styles := registerCellStyles(w)
+	require.Equal(t, &CellStyles{Number: 1, Date: 2, Datetime: 3, Timestamp: 4}, styles)
+
+	require.NoError(t, w.Close())
+
+	f, err := excelize.OpenReader(&buf)
 	require.NoError(t, err)
+
+	numFmt, err := f.GetStyle(styles.Date)
+	require.NoError(t, err)
+	require.Equal(t, "yyyy-mm-dd", *numFmt.CustomNumFmt)
 }
 
 func TestFitsInNumber(t *testing.T) {
@@ -360,3 +373,229 @@ func TestConverterError(t *testing.T) {
 		})
 	}
 }
+
+// rowsReader is a yt.TableReader over in-memory rows.
+type rowsReader struct {
+	rows []any
+	next int
+}
+
+func (r *rowsReader) Scan(value any) error {
+	data, err := yson.MarshalFormat(r.rows[r.next-1], yson.FormatBinary)
+	if err != nil {
+		return err
+	}
+	return yson.Unmarshal(data, value)
+}
+
+func (r *rowsReader) Next() bool {
+	if r.next >= len(r.rows) {
+		return false
+	}
+	r.next++
+	return true
+}
+
+func (r *rowsReader) Err() error { return nil }
+
+func (r *rowsReader) Close() error { return nil }
+
+func TestConvert(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		rows     []any
+		opts     *ConvertOptions
+		expected [][]string
+		error    bool
+	}{
+		{
+			name: "schema",
+			rows: []any{
+				map[string]any{"id": 1, "name": "a"},
+				map[string]any{"id": 2},
+			},
+			opts: &ConvertOptions{
+				Columns: []string{"name", "id"},
+				Schema: &schema.Schema{Columns: []schema.Column{
+					{Name: "id", Type: schema.TypeInt64},
+					{Name: "name", Type: schema.TypeString},
+				}},
+			},
+			expected: [][]string{
+				{"id", "name"},
+				{"int64", "utf8"},
+				{"1", "a"},
+				{"2"},
+			},
+		},
+		{
+			name: "schemaless",
+			rows: []any{
+				map[string]any{"c": 1, "a": 2},
+				map[string]any{"d": 3, "b": 4},
+			},
+			opts: &ConvertOptions{},
+			expected: [][]string{
+				{"a", "c", "b", "d"},
+				{"2", "1"},
+				{"", "", "4", "3"},
+			},
+		},
+		{
+			name: "max-file-size-exceeded",
+			rows: []any{
+				map[string]any{"a": strings.Repeat("a", 1024*1024)},
+			},
+			opts:  &ConvertOptions{ExportOptions: &ExportOptions{MaxExcelFileSize: 1024}},
+			error: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.NumberPrecisionMode = NumberPrecisionModeString
+			if tc.opts.ExportOptions == nil {
+				tc.opts.ExportOptions = &ExportOptions{MaxExcelFileSize: 1024 * 1024}
+			}
+
+			var buf bytes.Buffer
+			err := Convert(&buf, &rowsReader{rows: tc.rows}, tc.opts)
+			if tc.error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			f, err := excelize.OpenReader(&buf)
+			require.NoError(t, err)
+
+			rows, err := f.GetRows(SheetName)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rows)
+		})
+	}
+}
+
+func TestConvert_multiSheet(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		rows     []any
+		opts     *ConvertOptions
+		expected map[string][][]string
+		error    bool
+	}{
+		{
+			name: "schema",
+			rows: []any{
+				map[string]any{"id": 1},
+				map[string]any{"id": 2},
+				map[string]any{"id": 3},
+				map[string]any{"id": 4},
+				map[string]any{"id": 5},
+			},
+			opts: &ConvertOptions{
+				Columns: []string{"id"},
+				Schema: &schema.Schema{Columns: []schema.Column{
+					{Name: "id", Type: schema.TypeInt64},
+				}},
+				MultiSheet:    true,
+				sheetRowLimit: 4,
+			},
+			expected: map[string][][]string{
+				"Sheet1": {{"id"}, {"int64"}, {"1"}, {"2"}},
+				"Sheet2": {{"id"}, {"int64"}, {"3"}, {"4"}},
+				"Sheet3": {{"id"}, {"int64"}, {"5"}},
+			},
+		},
+		{
+			name: "schemaless",
+			rows: []any{
+				map[string]any{"a": 1},
+				map[string]any{"b": 2},
+				map[string]any{"c": 3},
+			},
+			opts: &ConvertOptions{
+				MultiSheet:    true,
+				sheetRowLimit: 3,
+			},
+			expected: map[string][][]string{
+				"Sheet1": {{"a", "b"}, {"1"}, {"", "2"}},
+				"Sheet2": {{"a", "b", "c"}, {"", "", "3"}},
+			},
+		},
+		{
+			name: "disabled",
+			rows: []any{
+				map[string]any{"a": 1},
+				map[string]any{"a": 2},
+				map[string]any{"a": 3},
+			},
+			opts: &ConvertOptions{
+				sheetRowLimit: 3,
+			},
+			error: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.NumberPrecisionMode = NumberPrecisionModeString
+			tc.opts.ExportOptions = &ExportOptions{MaxExcelFileSize: 1024 * 1024}
+
+			var buf bytes.Buffer
+			err := Convert(&buf, &rowsReader{rows: tc.rows}, tc.opts)
+			if tc.error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			f, err := excelize.OpenReader(&buf)
+			require.NoError(t, err)
+			require.Len(t, f.GetSheetList(), len(tc.expected))
+
+			for sheet, expected := range tc.expected {
+				rows, err := f.GetRows(sheet)
+				require.NoError(t, err)
+				require.Equal(t, expected, rows, sheet)
+			}
+		})
+	}
+}
+
+func TestConvertTables(t *testing.T) {
+	tables := [][]any{
+		{map[string]any{"id": 1}, map[string]any{"id": 2}},
+		{},
+		{map[string]any{"id": 3}},
+	}
+
+	var opened []OpenTableFunc
+	for _, rows := range tables {
+		opened = append(opened, func() (yt.TableReader, error) {
+			return &rowsReader{rows: rows}, nil
+		})
+	}
+
+	opts := &ConvertOptions{
+		Columns: []string{"id"},
+		Schema: &schema.Schema{Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeInt64},
+		}},
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+		NumberPrecisionMode: NumberPrecisionModeString,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, ConvertTables(&buf, opened, opts))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Sheet1", "Sheet2", "Sheet3"}, f.GetSheetList())
+
+	for sheet, expected := range map[string][][]string{
+		"Sheet1": {{"id"}, {"int64"}, {"1"}, {"2"}},
+		"Sheet2": {{"id"}, {"int64"}},
+		"Sheet3": {{"id"}, {"int64"}, {"3"}},
+	} {
+		rows, err := f.GetRows(sheet)
+		require.NoError(t, err)
+		require.Equal(t, expected, rows, sheet)
+	}
+}
@@ -0,0 +1,177 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+)
+
+// csvWriter is a streaming writer of delimiter-separated text files as specified by RFC 4180.
+//
+// Cell values are written as they are displayed by excel, e.g. dates are formatted according to the cell style.
+// The file consists of a single sheet.
+type csvWriter struct {
+	out       io.Writer
+	format    Format
+	delimiter rune
+
+	numFmts []string
+	sheets  int
+
+	// rows writes to out or to spool when header of the sheet is deferred.
+	rows   *csv.Writer
+	spool  *spool
+	header []excelize.Cell
+	record []string
+	// minWidth is the least number of fields of the spooled records.
+	minWidth int
+}
+
+func newCSVWriter(w io.Writer, format Format, delimiter rune) *csvWriter {
+	return &csvWriter{out: w, format: format, delimiter: delimiter}
+}
+
+func (w *csvWriter) newCSV(out io.Writer) *csv.Writer {
+	cw := csv.NewWriter(out)
+	cw.Comma = w.delimiter
+	cw.UseCRLF = true
+	return cw
+}
+
+func (w *csvWriter) NewStyle(numFmt string) int {
+	w.numFmts = append(w.numFmts, numFmt)
+	return len(w.numFmts)
+}
+
+func (w *csvWriter) SetLayout(sheetLayout) {}
+
+func (w *csvWriter) NewSheet(name string, deferHeader bool) error {
+	if w.sheets > 0 {
+		return errSingleSheet(w.format)
+	}
+	w.sheets++
+
+	if deferHeader {
+		spool, err := newSpool()
+		if err != nil {
+			return err
+		}
+		w.spool = spool
+		w.rows = w.newCSV(spool)
+		w.minWidth = -1
+		return nil
+	}
+
+	w.rows = w.newCSV(w.out)
+	return nil
+}
+
+func (w *csvWriter) SetHeader(cells []excelize.Cell) {
+	w.header = cells
+}
+
+func (w *csvWriter) WriteRow(cells []excelize.Cell) error {
+	if w.rows == nil {
+		return xerrors.New("no sheet to write the row to")
+	}
+	if w.spool != nil && (w.minWidth < 0 || len(cells) < w.minWidth) {
+		w.minWidth = len(cells)
+	}
+	return w.writeRow(w.rows, cells)
+}
+
+func (w *csvWriter) writeRow(cw *csv.Writer, cells []excelize.Cell) error {
+	w.record = w.record[:0]
+	for _, cell := range cells {
+		var numFmt string
+		if cell.StyleID > 0 {
+			numFmt = w.numFmts[cell.StyleID-1]
+		}
+		w.record = append(w.record, formatCellText(cell.Value, numFmt))
+	}
+
+	return cw.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	if w.rows == nil {
+		return nil
+	}
+
+	w.rows.Flush()
+	if err := w.rows.Error(); err != nil {
+		return err
+	}
+
+	if w.spool == nil {
+		return nil
+	}
+	defer w.cleanup()
+
+	header := w.newCSV(w.out)
+	if err := w.writeRow(header, w.header); err != nil {
+		return err
+	}
+	header.Flush()
+	if err := header.Error(); err != nil {
+		return err
+	}
+
+	// Columns of schemaless tables are added as they are met, so early records may be shorter than the header.
+	if w.minWidth < 0 || w.minWidth >= len(w.header) {
+		return w.spool.CopyTo(w.out)
+	}
+	r, err := w.spool.Rewind()
+	if err != nil {
+		return err
+	}
+	return w.copyPadded(r, len(w.header))
+}
+
+// copyPadded copies the records written by csv.Writer from r to the output
+// appending empty fields to the records having less than width fields.
+//
+// Fields containing quotes, delimiters or line breaks are quoted by csv.Writer,
+// so records end at line breaks outside of quotes.
+func (w *csvWriter) copyPadded(r io.Reader, width int) error {
+	in := bufio.NewReader(r)
+	out := bufio.NewWriter(w.out)
+
+	fields, quoted := 1, false
+	for {
+		c, _, err := in.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return xerrors.Errorf("error reading spool file: %w", err)
+		}
+
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == w.delimiter:
+			fields++
+		case c == '\r':
+			if fields < width {
+				_, _ = out.WriteString(strings.Repeat(string(w.delimiter), width-fields))
+			}
+			fields = 1
+		}
+		_, _ = out.WriteRune(c)
+	}
+	return out.Flush()
+}
+
+func (w *csvWriter) cleanup() {
+	if w.spool != nil {
+		w.spool.Remove()
+		w.spool = nil
+	}
+}
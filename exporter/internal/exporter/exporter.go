@@ -3,10 +3,10 @@ package exporter
 import (
 	"context"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 
-	"github.com/xuri/excelize/v2"
-
 	"go.ytsaurus.tech/library/go/core/xerrors"
 	"go.ytsaurus.tech/yt/go/schema"
 	"go.ytsaurus.tech/yt/go/ypath"
@@ -20,6 +20,10 @@ const (
 	// MaxRowCount stores the maximum number of static table rows that service handles.
 	// -2 is for headers and types.
 	MaxRowCount = excelMaxRowCount - 2
+	// MaxSheetCount stores the maximum number of sheets in multi-sheet export.
+	MaxSheetCount = 10
+	// MaxMultiSheetRowCount stores the maximum number of static table rows that service handles in multi-sheet export.
+	MaxMultiSheetRowCount = MaxSheetCount * MaxRowCount
 	// excelMaxFilepathLength stores the maximum length of excel filepath that excel can open.
 	excelMaxFilepathLength = 218
 	// maxFilenameLength stores max length of generated filename.
@@ -44,6 +48,32 @@ type ExportRequest struct {
 	StartRow            int64 `json:"start_row"`
 	RowCount            int64 `json:"row_count"`
 	NumberPrecisionMode NumberPrecisionMode
+	// MultiSheet enables spilling rows over several sheets when they do not fit into a single one.
+	MultiSheet bool `json:"multi_sheet"`
+	// Ranges holds read ranges of the path unless it is a single row index interval
+	// that is stored in StartRow and RowCount.
+	Ranges []ypath.Range `json:"ranges"`
+	// SheetPerRange exports each of Ranges to a separate sheet instead of concatenating them.
+	SheetPerRange bool `json:"sheet_per_range"`
+	// Format is the format of the resulting file; xlsx by default.
+	Format Format `json:"format"`
+	// Delimiter is the field delimiter of csv format; comma by default.
+	Delimiter rune `json:"delimiter"`
+	// ComplexTypeFormat is the text format of values of container types; yson by default.
+	ComplexTypeFormat ComplexTypeFormat `json:"complex_type_format"`
+	// Header configures the header rows and the presentation of the sheets.
+	Header HeaderOptions `json:"header"`
+	// Query filters, sorts and limits the rows read from the table if set.
+	Query *RowQuery `json:"query,omitempty"`
+	// ColumnSpecs are titles, formats and order of the exported columns including computed ones.
+	// Columns of the path must not be set along with them.
+	ColumnSpecs []ColumnSpec `json:"column_spec,omitempty"`
+	// Profile is a preset of the settings above, e.g. ProfileRoundtrip; none by default.
+	Profile Profile `json:"profile,omitempty"`
+	// Metadata adds the hidden sheet MetadataSheetName describing the exported table.
+	Metadata bool `json:"metadata,omitempty"`
+	// ExpectedRevision fails the export with ErrTableChanged if the table has another @revision.
+	ExpectedRevision *uint64 `json:"expected_revision,omitempty"`
 }
 
 func (r *ExportRequest) String() string {
@@ -51,17 +81,91 @@ func (r *ExportRequest) String() string {
 	if !r.allColumns && r.Columns != nil {
 		s += fmt.Sprintf("{%s}", strings.Join(r.Columns, ","))
 	}
-	if !r.allRows {
-		s += fmt.Sprintf("[#%d:#%d]", r.StartRow, r.StartRow+r.RowCount)
+	s += r.formatRows()
+	if r.Query != nil {
+		s += " " + r.Query.String()
 	}
 	return s
 }
 
+// formatRows returns the read ranges of the request in ypath syntax, e.g. [#10:#20]; empty if all rows are read.
+func (r *ExportRequest) formatRows() string {
+	switch {
+	case r.allRows:
+		return ""
+	case len(r.Ranges) > 0:
+		return "[" + formatRanges(r.Ranges) + "]"
+	default:
+		return fmt.Sprintf("[#%d:#%d]", r.StartRow, r.StartRow+r.RowCount)
+	}
+}
+
+// RowLimit returns the maximum number of rows the request can export.
+func (r *ExportRequest) RowLimit() int64 {
+	if r.MultiSheet || r.SheetPerRange {
+		return MaxMultiSheetRowCount
+	}
+	return MaxRowCount
+}
+
+// RangeRowCount returns the total number of rows selected by Ranges.
+//
+// The second return value is false if the count can not be determined without reading the table,
+// e.g. when some range is bounded by keys or has no upper bound.
+func (r *ExportRequest) RangeRowCount() (int64, bool) {
+	counts, ok := r.RangeRowCounts()
+	if !ok {
+		return 0, false
+	}
+
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	return total, true
+}
+
+// RangeRowCounts returns the number of rows selected by each of Ranges.
+//
+// The second return value is false if some of the counts can not be determined without reading the table.
+func (r *ExportRequest) RangeRowCounts() ([]int64, bool) {
+	counts := make([]int64, len(r.Ranges))
+	for i, rng := range r.Ranges {
+		switch {
+		case rng.Exact != nil && rng.Exact.RowIndex != nil && rng.Exact.Key == nil:
+			counts[i] = 1
+		case isRowIndexInterval(rng):
+			counts[i] = max(0, *rng.Upper.RowIndex-*rng.Lower.RowIndex)
+		default:
+			return nil, false
+		}
+	}
+	return counts, true
+}
+
+// CheckRangeRowCounts checks that the ranges of sheet_per_range export fit into a single sheet each
+// unless the rows are allowed to spill over several sheets.
+func (r *ExportRequest) CheckRangeRowCounts(counts []int64) error {
+	if !r.SheetPerRange || r.MultiSheet {
+		return nil
+	}
+
+	for i, n := range counts {
+		if n > MaxRowCount {
+			return xerrors.Errorf("too many rows in range %s to export into a single sheet; max is %d",
+				formatRanges(r.Ranges[i:i+1]), MaxRowCount)
+		}
+	}
+	return nil
+}
+
 // MakeExportRequest creates request object from ypath string and mode of handling numbers with high precision.
 //
 // Example inputs:
 //
 //	//home/example{"col1","col2"}[#10:#999]
+//	//home/example{"col1","col2"}[#10:#20,#50:#60]
+//	//home/example[("a"):("b")]
 //	//home/example{"col1","col2"}
 //	<file_name=data.xlsx>//home/example
 //	//home/example
@@ -82,44 +186,97 @@ func MakeExportRequest(s string, numberPrecisionMode NumberPrecisionMode) (*Expo
 		NumberPrecisionMode: numberPrecisionMode,
 	}
 
-	if len(p.Ranges) > 1 {
-		return nil, xerrors.Errorf("multiple ranges are not supported")
-	}
-
-	if len(p.Ranges) == 1 {
+	switch {
+	case len(p.Ranges) == 1 && isRowIndexInterval(p.Ranges[0]):
 		r.allRows = false
 		r.StartRow = *p.Ranges[0].Lower.RowIndex
 		r.RowCount = *p.Ranges[0].Upper.RowIndex - r.StartRow
+	case len(p.Ranges) > 0:
+		r.allRows = false
+		r.Ranges = p.Ranges
 	}
 
 	return r, nil
 }
 
+// isRowIndexInterval checks that range is of the form [#lower:#upper].
+func isRowIndexInterval(rng ypath.Range) bool {
+	return rng.Exact == nil &&
+		rng.Lower != nil && rng.Lower.RowIndex != nil && rng.Lower.Key == nil &&
+		rng.Upper != nil && rng.Upper.RowIndex != nil && rng.Upper.Key == nil
+}
+
 // MakePath creates ypath for the read request.
 //
+// The whole table is read when all rows are requested and their count is not limited,
+// e.g. when rows are filtered by Query.
+//
 // Example: //home/example{col1,col2}[#10:#999].
 func (r *ExportRequest) MakePath() *ypath.Rich {
+	p := ypath.NewRich(string(r.Path)).SetColumns(r.readColumns())
+	if len(r.Ranges) > 0 {
+		for _, rng := range r.Ranges {
+			p.AddRange(rng)
+		}
+		return p
+	}
+
+	if r.allRows && r.RowCount == 0 {
+		return p
+	}
+
 	endRow := r.StartRow + r.RowCount
-	return ypath.NewRich(string(r.Path)).
-		AddRange(ypath.Range{
-			Lower: &ypath.ReadLimit{RowIndex: &r.StartRow},
-			Upper: &ypath.ReadLimit{RowIndex: &endRow},
-		}).
-		SetColumns(r.Columns)
+	return p.AddRange(ypath.Range{
+		Lower: &ypath.ReadLimit{RowIndex: &r.StartRow},
+		Upper: &ypath.ReadLimit{RowIndex: &endRow},
+	})
 }
 
-func (r *ExportRequest) EnsureFileName(ctx context.Context, yc yt.Client) {
-	defer func() {
-		if !strings.HasSuffix(r.Filename, ".xlsx") {
-			r.Filename += ".xlsx"
+// MakeRangePaths creates a separate ypath for each of the request ranges.
+func (r *ExportRequest) MakeRangePaths() []*ypath.Rich {
+	paths := make([]*ypath.Rich, len(r.Ranges))
+	for i, rng := range r.Ranges {
+		paths[i] = ypath.NewRich(string(r.Path)).AddRange(rng).SetColumns(r.readColumns())
+	}
+	return paths
+}
+
+// readColumns returns the exported columns along with the columns used by Query.
+func (r *ExportRequest) readColumns() []string {
+	if len(r.Columns) == 0 || r.Query == nil {
+		return r.Columns
+	}
+	return append(slices.Clip(r.Columns), r.hiddenColumns()...)
+}
+
+// hiddenColumns returns the columns used by Query that are not exported.
+func (r *ExportRequest) hiddenColumns() []string {
+	if len(r.Columns) == 0 || r.Query == nil {
+		return nil
+	}
+
+	var hidden []string
+	for _, c := range r.Query.Columns() {
+		if !slices.Contains(r.Columns, c) {
+			hidden = append(hidden, c)
 		}
+	}
+	return hidden
+}
+
+// EnsureFileName sets the file name from @file_name attribute of the table at path or generates one.
+//
+// Path is r.Path unless the table is locked and read by node id.
+func (r *ExportRequest) EnsureFileName(ctx context.Context, yc yt.CypressClient, path ypath.Path) {
+	defer func() {
+		r.Filename = ensureExtension(r.Filename, r.Format)
 	}()
 
 	if r.Filename != "" {
 		return
 	}
 
-	filename, err := ReadFileName(ctx, yc, r.Path)
+	filename, err := ReadFileName(ctx, yc, path)
 	if err == nil && filename != "" {
 		r.Filename = filename
 		return
@@ -138,7 +295,11 @@ func (r *ExportRequest) MakeFileName(suffix string) string {
 	}
 
 	if !r.allRows {
-		name += fmt.Sprintf("__%d_%d__", r.StartRow, r.StartRow+r.RowCount)
+		if len(r.Ranges) > 0 {
+			name += "__" + replaceNonAlphanumeric(formatRanges(r.Ranges)) + "__"
+		} else {
+			name += fmt.Sprintf("__%d_%d__", r.StartRow, r.StartRow+r.RowCount)
+		}
 	}
 
 	name += suffix
@@ -147,26 +308,131 @@ func (r *ExportRequest) MakeFileName(suffix string) string {
 		name = name[:maxFilenameLength]
 	}
 
-	name += ".xlsx"
+	name += r.Format.Extension()
 	return name
 }
 
 type ExportOptions struct {
+	// MaxExcelFileSize is the max size of the resulting file in bytes.
 	MaxExcelFileSize int
+	// Cluster is the proxy of the cluster the tables are exported from.
+	Cluster string
+	// Progress is updated with the number of converted rows and written bytes if set.
+	Progress *Progress
 }
 
+// ExportResponse is a prepared export that is ready to be written.
+//
+// User must call Close.
 type ExportResponse struct {
 	// Filename is name of a converted file.
 	Filename string
-	File     *excelize.File
+	// ContentType is mime type of a converted file.
+	ContentType string
+
+	source string
+	// tx holds snapshot lock of the exported table until the response is closed.
+	tx yt.Tx
+	in yt.TableReader
+	// tables are opened one by one during Write, each into a separate sheet. Used instead of in.
+	tables []OpenTableFunc
+	// sheets are the sources of the workbook export, each with its own columns. Used instead of in.
+	sheets      []WorkbookSheet
+	convertOpts *ConvertOptions
+	// rowCount is the number of exported rows if it is known before conversion; zero otherwise.
+	rowCount int64
+}
+
+// Write converts the exported rows and streams resulting excel file to w.
+func (r *ExportResponse) Write(w io.Writer) error {
+	var err error
+	switch {
+	case r.sheets != nil:
+		err = ConvertWorkbook(w, r.sheets, r.convertOpts)
+	case r.tables != nil:
+		err = ConvertTables(w, r.tables, r.convertOpts)
+	default:
+		err = Convert(w, r.in, r.convertOpts)
+	}
+	if err != nil {
+		return xerrors.Errorf("error converting %s: %w", r.source, err)
+	}
+	return nil
+}
+
+// Close frees underlying table reader and aborts the transaction of the export.
+func (r *ExportResponse) Close() error {
+	var err error
+	if r.in != nil {
+		err = r.in.Close()
+	}
+	if r.tx != nil {
+		if abortErr := r.tx.Abort(); err == nil {
+			err = abortErr
+		}
+	}
+	return err
 }
 
 // ErrBadRequest is an error that signals that conversion is failed due to bad request.
 var ErrBadRequest = xerrors.NewSentinel("bad request")
 
-// Export executes given conversion request.
+// Export prepares given conversion request.
+//
+// The table is read under snapshot lock taken in a transaction that lasts until ExportResponse.Close,
+// so the schema, the attributes and the rows belong to the same version of the table.
+// Conversion itself happens on ExportResponse.Write.
 func Export(ctx context.Context, yc yt.Client, req *ExportRequest, opts *ExportOptions) (*ExportResponse, error) {
-	s, err := ReadSchema(ctx, yc, req.Path)
+	table, err := lockSnapshot(ctx, yc, req.Path)
+	if err != nil {
+		if yterrors.ContainsResolveError(err) {
+			return nil, ErrBadRequest.Wrap(err)
+		}
+		return nil, err
+	}
+
+	rsp, err := export(ctx, yc, table, req, opts)
+	if err != nil {
+		_ = table.tx.Abort()
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func export(ctx context.Context, yc yt.Client, table *tableSnapshot, req *ExportRequest, opts *ExportOptions) (*ExportResponse, error) {
+	rsp, err := prepareExport(ctx, yc, table, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	if len(rsp.tables) > 1 {
+		return rsp, nil
+	}
+
+	// A single table is opened right away, so that errors of reading it are reported before the file is written.
+	in, err := rsp.tables[0]()
+	if err != nil {
+		return nil, err
+	}
+	rsp.in, rsp.tables = in, nil
+	return rsp, nil
+}
+
+// prepareExport checks the request against the locked table and prepares the response
+// with the readers of the table that are opened on Write.
+func prepareExport(
+	ctx context.Context,
+	yc yt.Client,
+	table *tableSnapshot,
+	req *ExportRequest,
+	opts *ExportOptions,
+) (*ExportResponse, error) {
+	if req.ExpectedRevision != nil {
+		if err := table.checkRevision(ctx, req.Path, *req.ExpectedRevision); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := ReadSchema(ctx, table.tx, table.node)
 	if err != nil {
 		if yterrors.ContainsResolveError(err) {
 			return nil, ErrBadRequest.Wrap(xerrors.Errorf("error reading schema for %q: %w", req.Path, err))
@@ -174,38 +440,142 @@ func Export(ctx context.Context, yc yt.Client, req *ExportRequest, opts *ExportO
 		return nil, xerrors.Errorf("error reading schema for %q: %w", req.Path, err)
 	}
 
-	req.EnsureFileName(ctx, yc)
+	req.EnsureFileName(ctx, table.tx, table.node)
+
+	if err := req.checkProfile(s); err != nil {
+		return nil, ErrBadRequest.Wrap(err)
+	}
+	if req.Metadata && !req.Format.SupportsSheets() {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("metadata sheet is not supported by %q format", req.Format))
+	}
+
+	if len(req.ColumnSpecs) > 0 {
+		if len(req.Columns) > 0 {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("columns of the path can not be set along with column spec"))
+		}
+		if len(s.Columns) == 0 {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("column spec requires table schema"))
+		}
+		if _, err := makeSheetColumns(nil, req.ColumnSpecs, s); err != nil {
+			return nil, ErrBadRequest.Wrap(err)
+		}
+		req.Columns = columnSpecColumns(req.ColumnSpecs)
+	}
 
 	if len(req.Columns) > excelMaxColCount || len(req.Columns) == 0 && len(s.Columns) > excelMaxColCount {
 		return nil, ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d", excelMaxColCount))
 	}
 
-	in, err := yc.ReadTable(ctx, req.MakePath(), nil)
-	if err != nil {
-		return nil, xerrors.Errorf("error creating reader: %w", err)
+	if err := normalizeKeys(req.Ranges, s); err != nil {
+		return nil, ErrBadRequest.Wrap(err)
+	}
+
+	var query *compiledQuery
+	if req.Query != nil {
+		if query, err = req.Query.compile(s); err != nil {
+			return nil, ErrBadRequest.Wrap(err)
+		}
+		// Skipped rows are read and sorted along with the exported ones, so they count against the row limit.
+		if rowLimit := req.RowLimit(); query.offset > rowLimit {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("offset %d is too large; max is %d", query.offset, rowLimit))
+		}
+	}
+
+	// Rows selected by the query are counted while they are converted.
+	var rowCount int64
+	if req.allRows && req.Query == nil {
+		if rowCount, err = readRowCount(ctx, table.tx, table.node); err != nil {
+			return nil, err
+		}
+		if rowLimit := req.RowLimit(); rowCount > rowLimit {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export; max is %d", rowLimit))
+		}
+	}
+
+	if _, ok := req.RangeRowCounts(); !ok && req.Query == nil {
+		rowLimit := req.RowLimit()
+		counts, err := countRangeRows(ctx, table.tx, table.node, req.Ranges)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, n := range counts {
+			rowCount += n
+		}
+		if rowCount > rowLimit {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export; max is %d", rowLimit))
+		}
+		if err := req.CheckRangeRowCounts(counts); err != nil {
+			return nil, ErrBadRequest.Wrap(err)
+		}
+	}
+
+	rsp := &ExportResponse{
+		Filename:    req.Filename,
+		ContentType: req.Format.ContentType(),
+		source:      req.String(),
+		tx:          table.tx,
+		rowCount:    rowCount,
+	}
+
+	hidden := req.hiddenColumns()
+	readTable := func(path *ypath.Rich) (yt.TableReader, error) {
+		in, err := table.tx.ReadTable(ctx, path, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("error creating reader: %w", err)
+		}
+		if query != nil {
+			return newQueryReader(in, query, hidden, req.RowLimit()), nil
+		}
+		return in, nil
+	}
+
+	paths := []*ypath.Rich{req.MakePath()}
+	if req.SheetPerRange && len(req.Ranges) > 1 {
+		paths = req.MakeRangePaths()
+	}
+	for _, path := range paths {
+		path.Path = table.node
+		rsp.tables = append(rsp.tables, func() (yt.TableReader, error) {
+			return readTable(path)
+		})
 	}
-	defer func() { _ = in.Close() }()
 
 	if len(req.Columns) == 0 {
 		req.Columns = getColumnNames(s.Columns)
 	}
 
-	convertOpts := &ConvertOptions{
+	var metadata *Metadata
+	if req.Metadata {
+		if metadata, err = makeMetadata(ctx, yc, table, req, s, opts.Cluster); err != nil {
+			return nil, err
+		}
+	}
+
+	rsp.convertOpts = &ConvertOptions{
 		Columns:             req.Columns,
 		Schema:              s,
 		ExportOptions:       opts,
 		NumberPrecisionMode: req.NumberPrecisionMode,
+		MultiSheet:          req.MultiSheet,
+		Format:              req.Format,
+		Delimiter:           req.Delimiter,
+		ComplexTypeFormat:   req.ComplexTypeFormat,
+		Header:              req.Header,
+		ColumnSpecs:         req.ColumnSpecs,
+		Profile:             req.Profile,
+		Metadata:            metadata,
 	}
-	out, err := Convert(in, convertOpts)
-	if err != nil {
-		return nil, xerrors.Errorf("error converting %s: %w", req, err)
+	if req.Profile == ProfileRoundtrip {
+		reordered := req.Query != nil && len(req.Query.orderBy) > 0
+		rsp.convertOpts.RoundtripSchema = makeRoundtripSchema(s, req.Columns, reordered)
 	}
 
-	return &ExportResponse{Filename: req.Filename, File: out}, nil
+	return rsp, nil
 }
 
 // ReadSchema returns the value of @schema table attribute.
-func ReadSchema(ctx context.Context, yc yt.Client, path ypath.Path) (*schema.Schema, error) {
+func ReadSchema(ctx context.Context, yc yt.CypressClient, path ypath.Path) (*schema.Schema, error) {
 	var s *schema.Schema
 	if err := yc.GetNode(ctx, path.Attr("schema"), &s, nil); err != nil {
 		return nil, err
@@ -214,7 +584,7 @@ func ReadSchema(ctx context.Context, yc yt.Client, path ypath.Path) (*schema.Sch
 }
 
 // ReadFileName returns the value of @file_name table attribute.
-func ReadFileName(ctx context.Context, yc yt.Client, path ypath.Path) (string, error) {
+func ReadFileName(ctx context.Context, yc yt.CypressClient, path ypath.Path) (string, error) {
 	var filename string
 	if err := yc.GetNode(ctx, path.Attr("file_name"), &filename, nil); err != nil {
 		return "", err
@@ -239,13 +609,15 @@ type ExportQueryResultRequest struct {
 	UpperRowIndex       *int64
 	Columns             []string
 	NumberPrecisionMode NumberPrecisionMode
+	Format              Format
+	Delimiter           rune
+	ComplexTypeFormat   ComplexTypeFormat
+	Header              HeaderOptions
 }
 
 func (r *ExportQueryResultRequest) EnsureFileName() {
 	defer func() {
-		if !strings.HasSuffix(r.Filename, ".xlsx") {
-			r.Filename += ".xlsx"
-		}
+		r.Filename = ensureExtension(r.Filename, r.Format)
 	}()
 
 	if r.Filename != "" {
@@ -256,15 +628,51 @@ func (r *ExportQueryResultRequest) EnsureFileName() {
 }
 
 func (r *ExportQueryResultRequest) MakeFileName() string {
-	return fmt.Sprintf("yt_query_result__%s__%d.xlsx", replaceNonAlphanumeric(string(r.ID.String())), r.Index)
+	return fmt.Sprintf("yt_query_result__%s__%d%s", replaceNonAlphanumeric(string(r.ID.String())), r.Index,
+		r.Format.Extension())
+}
+
+// rowCount returns the number of rows of the query result selected by the row indices of the request.
+func (r *ExportQueryResultRequest) rowCount(qr *yt.QueryResult) int64 {
+	lower, upper := int64(0), qr.DataStatistics.RowCount
+	if r.LowerRowIndex != nil {
+		lower = *r.LowerRowIndex
+	}
+	if r.UpperRowIndex != nil {
+		upper = min(upper, *r.UpperRowIndex)
+	}
+	return max(0, upper-lower)
 }
 
-// Export executes given conversion request.
+// ExportQueryResult prepares given query result conversion request.
+//
+// Conversion itself happens on ExportResponse.Write.
 func ExportQueryResult(
 	ctx context.Context,
 	yc yt.Client,
 	req *ExportQueryResultRequest,
 	opts *ExportOptions,
+) (*ExportResponse, error) {
+	rsp, err := prepareQueryResult(ctx, yc, req, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := rsp.tables[0]()
+	if err != nil {
+		return nil, err
+	}
+	rsp.in, rsp.tables = in, nil
+	return rsp, nil
+}
+
+// prepareQueryResult checks the request against the query result and prepares the response
+// with the reader of the result that is opened on Write.
+func prepareQueryResult(
+	ctx context.Context,
+	yc yt.Client,
+	req *ExportQueryResultRequest,
+	opts *ExportOptions,
 ) (*ExportResponse, error) {
 	qr, err := yc.GetQueryResult(ctx, req.ID, req.Index, nil)
 	if err != nil {
@@ -279,15 +687,23 @@ func ExportQueryResult(
 		return nil, ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d", excelMaxColCount))
 	}
 
-	in, err := yc.ReadQueryResult(ctx, req.ID, req.Index, &yt.ReadQueryResultOptions{
+	rowCount := req.rowCount(qr)
+	if rowCount > MaxRowCount {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export; max is %d", MaxRowCount))
+	}
+
+	readOpts := &yt.ReadQueryResultOptions{
 		Columns:       req.Columns,
 		LowerRowIndex: req.LowerRowIndex,
 		UpperRowIndex: req.UpperRowIndex,
-	})
-	if err != nil {
-		return nil, ErrBadRequest.Wrap(err)
 	}
-	defer func() { _ = in.Close() }()
+	open := func() (yt.TableReader, error) {
+		in, err := yc.ReadQueryResult(ctx, req.ID, req.Index, readOpts)
+		if err != nil {
+			return nil, ErrBadRequest.Wrap(err)
+		}
+		return in, nil
+	}
 
 	if len(req.Columns) == 0 {
 		req.Columns = getColumnNames(s.Columns)
@@ -298,11 +714,18 @@ func ExportQueryResult(
 		Schema:              s,
 		ExportOptions:       opts,
 		NumberPrecisionMode: req.NumberPrecisionMode,
-	}
-	out, err := Convert(in, convertOpts)
-	if err != nil {
-		return nil, xerrors.Errorf("error converting %q: %w", req.ID, err)
+		Format:              req.Format,
+		Delimiter:           req.Delimiter,
+		ComplexTypeFormat:   req.ComplexTypeFormat,
+		Header:              req.Header,
 	}
 
-	return &ExportResponse{Filename: req.Filename, File: out}, nil
+	return &ExportResponse{
+		Filename:    req.Filename,
+		ContentType: req.Format.ContentType(),
+		source:      fmt.Sprintf("%q", req.ID),
+		tables:      []OpenTableFunc{open},
+		convertOpts: convertOpts,
+		rowCount:    rowCount,
+	}, nil
 }
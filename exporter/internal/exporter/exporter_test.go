@@ -4,12 +4,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
 	"go.uber.org/zap/zaptest"
 
 	"go.ytsaurus.tech/library/go/core/log/zap"
@@ -65,6 +67,36 @@ func TestMakeExportRequest(t *testing.T) {
 				RowCount: 100,
 			},
 		},
+		{
+			reqStr: `//home/abc[#10:#20,#50:#60]`,
+			expected: &ExportRequest{
+				Path:       "//home/abc",
+				allColumns: true,
+				Ranges: []ypath.Range{
+					ypath.Interval(ypath.RowIndex(10), ypath.RowIndex(20)),
+					ypath.Interval(ypath.RowIndex(50), ypath.RowIndex(60)),
+				},
+			},
+		},
+		{
+			reqStr: `//home/abc[#10:]`,
+			expected: &ExportRequest{
+				Path:       "//home/abc",
+				allColumns: true,
+				Ranges:     []ypath.Range{ypath.StartingFrom(ypath.RowIndex(10))},
+			},
+		},
+		{
+			reqStr: `//home/abc{"id"}[("a"):("b"),#5]`,
+			expected: &ExportRequest{
+				Path:    "//home/abc",
+				Columns: []string{"id"},
+				Ranges: []ypath.Range{
+					ypath.Interval(ypath.Key([]byte("a")), ypath.Key([]byte("b"))),
+					ypath.Exact(ypath.RowIndex(5)),
+				},
+			},
+		},
 	} {
 		t.Run(tc.reqStr, func(t *testing.T) {
 			req, err := MakeExportRequest(tc.reqStr, "")
@@ -118,6 +150,26 @@ func TestExportRequest_MakeFileName(t *testing.T) {
 			},
 			filename: "yt__home_verytable_tbl__id_name__10_110__.xlsx",
 		},
+		{
+			req: &ExportRequest{
+				Path:       "//home/verytable/tbl",
+				allColumns: true,
+				Ranges: []ypath.Range{
+					ypath.Interval(ypath.RowIndex(10), ypath.RowIndex(20)),
+					ypath.Exact(ypath.Key([]byte("a"), 1)),
+				},
+			},
+			filename: "yt__home_verytable_tbl___10__20___a__1___.xlsx",
+		},
+		{
+			req: &ExportRequest{
+				Path:       "//home/verytable/tbl",
+				allColumns: true,
+				allRows:    true,
+				Format:     FormatCSV,
+			},
+			filename: "yt__home_verytable_tbl.csv",
+		},
 	} {
 		t.Run(tc.req.String(), func(t *testing.T) {
 			require.Equal(t, tc.filename, tc.req.MakeFileName(""))
@@ -125,6 +177,60 @@ func TestExportRequest_MakeFileName(t *testing.T) {
 	}
 }
 
+func TestExportRequest_RangeRowCount(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ranges   []ypath.Range
+		rowCount int64
+		ok       bool
+	}{
+		{
+			name: "row_indices",
+			ranges: []ypath.Range{
+				ypath.Interval(ypath.RowIndex(10), ypath.RowIndex(20)),
+				ypath.Interval(ypath.RowIndex(20), ypath.RowIndex(10)),
+				ypath.Exact(ypath.RowIndex(5)),
+			},
+			rowCount: 11,
+			ok:       true,
+		},
+		{
+			name:   "open_range",
+			ranges: []ypath.Range{ypath.StartingFrom(ypath.RowIndex(10))},
+		},
+		{
+			name:   "key_range",
+			ranges: []ypath.Range{ypath.Interval(ypath.Key("a"), ypath.Key("b"))},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &ExportRequest{Ranges: tc.ranges}
+			rowCount, ok := req.RangeRowCount()
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.rowCount, rowCount)
+		})
+	}
+}
+
+func TestExportRequest_CheckRangeRowCounts(t *testing.T) {
+	ranges := []ypath.Range{
+		ypath.Interval(ypath.RowIndex(0), ypath.RowIndex(10)),
+		ypath.Interval(ypath.RowIndex(0), ypath.RowIndex(MaxRowCount+1)),
+	}
+
+	req := &ExportRequest{Ranges: ranges, SheetPerRange: true}
+	counts, ok := req.RangeRowCounts()
+	require.True(t, ok)
+	require.Equal(t, []int64{10, MaxRowCount + 1}, counts)
+	require.Error(t, req.CheckRangeRowCounts(counts))
+
+	req.MultiSheet = true
+	require.NoError(t, req.CheckRangeRowCounts(counts))
+
+	req = &ExportRequest{Ranges: ranges}
+	require.NoError(t, req.CheckRangeRowCounts(counts))
+}
+
 func TestExportRequest_MakeFileName_LongFilename(t *testing.T) {
 	req := &ExportRequest{
 		Path:       "//home/verytable/tbl",
@@ -163,6 +269,11 @@ type S2 struct {
 	Bytes   []byte `yson:"bytes"`
 }
 
+type S3 struct {
+	Key   uint64 `yson:"key"`
+	Value string `yson:"value"`
+}
+
 func TestExportFile(t *testing.T) {
 	env, cancel := yttest.NewEnv(t)
 	defer cancel()
@@ -320,6 +431,41 @@ func TestExportFile(t *testing.T) {
 				{"", "", "4", "3"},
 			},
 		},
+		{
+			name:   "key-ranges",
+			schema: schema.MustInfer(&S3{}).SortedBy("key"),
+			rows: []any{
+				&S3{Key: 1, Value: "a"},
+				&S3{Key: 2, Value: "b"},
+				&S3{Key: 3, Value: "c"},
+				&S3{Key: 4, Value: "d"},
+				&S3{Key: 5, Value: "e"},
+			},
+			req: &ExportRequest{
+				Path: ypath.Path("//tmp/key-ranges"),
+				Ranges: []ypath.Range{
+					ypath.Interval(ypath.Key(int64(2)), ypath.Key(int64(4))),
+					ypath.Exact(ypath.Key(int64(5))),
+				},
+			},
+			expected: [][]string{
+				{"key", "value"},
+				{"uint64", "utf8"},
+				{"2", "b"},
+				{"3", "c"},
+				{"5", "e"},
+			},
+		},
+		{
+			name:   "key-ranges-unsorted",
+			schema: schema.MustInfer(&S3{}),
+			rows:   []any{&S3{Key: 1, Value: "a"}},
+			req: &ExportRequest{
+				Path:   ypath.Path("//tmp/key-ranges-unsorted"),
+				Ranges: []ypath.Range{ypath.Exact(ypath.Key(int64(1)))},
+			},
+			error: true,
+		},
 	} {
 		t.Run(tc.req.String(), func(t *testing.T) {
 			tc.req.NumberPrecisionMode = NumberPrecisionModeString
@@ -346,18 +492,12 @@ func TestExportFile(t *testing.T) {
 			if tc.opts == nil {
 				tc.opts = &ExportOptions{MaxExcelFileSize: 1024 * 1024 * 10}
 			}
-			f, err := Export(env.Ctx, env.YT, tc.req, tc.opts)
+			f, err := exportFile(t, env.Ctx, env.YT, tc.req, tc.opts, tc.name+".xlsx")
 
 			if !tc.error {
 				require.NoError(t, err)
 
-				outFilename := OutputPath(tc.name + ".xlsx")
-				outFile, err := os.Create(outFilename)
-				require.NoError(t, err)
-				t.Logf("Saving excel file to %q", outFilename)
-				require.NoError(t, f.File.Write(outFile))
-
-				rows, err := f.File.GetRows(SheetName)
+				rows, err := f.GetRows(SheetName)
 				require.NoError(t, err)
 				require.Equal(t, tc.expected, rows)
 			} else {
@@ -367,6 +507,42 @@ func TestExportFile(t *testing.T) {
 	}
 }
 
+// exportFile executes export request, saves the result to the output directory and opens it.
+func exportFile(
+	t *testing.T,
+	ctx context.Context,
+	yc yt.Client,
+	req *ExportRequest,
+	opts *ExportOptions,
+	filename string,
+) (*excelize.File, error) {
+	t.Helper()
+
+	rsp, err := Export(ctx, yc, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rsp.Close() }()
+
+	return writeFile(t, rsp, filename)
+}
+
+// writeFile writes export response to the output directory and opens it.
+func writeFile(t *testing.T, rsp *ExportResponse, filename string) (*excelize.File, error) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := rsp.Write(&buf); err != nil {
+		return nil, err
+	}
+
+	outFilename := OutputPath(filename)
+	t.Logf("Saving excel file to %q", outFilename)
+	require.NoError(t, os.WriteFile(outFilename, buf.Bytes(), 0o644))
+
+	return excelize.OpenReader(&buf)
+}
+
 type UIntAndDouble struct {
 	UI64   uint64  `yson:"ui_64"`
 	Double float64 `yson:"double"`
@@ -399,12 +575,12 @@ func TestLosePrecision(t *testing.T) {
 	err = writer.Commit()
 	require.NoError(t, err)
 
-	f, err := Export(env.Ctx, env.YT, req, &ExportOptions{MaxExcelFileSize: 1024 * 1024 * 10})
+	f, err := exportFile(t, env.Ctx, env.YT, req, &ExportOptions{MaxExcelFileSize: 1024 * 1024 * 10}, "lose_precision.xlsx")
 	require.NoError(t, err)
 
-	uintVal, err := f.File.GetCellValue("Sheet1", "A3")
+	uintVal, err := f.GetCellValue("Sheet1", "A3")
 	require.NoError(t, err)
-	doubleVal, err := f.File.GetCellValue("Sheet1", "B3")
+	doubleVal, err := f.GetCellValue("Sheet1", "B3")
 	require.NoError(t, err)
 
 	require.Equal(t, "4291747200000000", uintVal)
@@ -439,13 +615,12 @@ func TestExportQueryResult(t *testing.T) {
 		NumberPrecisionMode: NumberPrecisionModeString,
 	}
 
-	f, err := ExportQueryResult(ctx, yc, req, opts)
+	rsp, err := ExportQueryResult(ctx, yc, req, opts)
 	require.NoError(t, err)
-	outFilename := OutputPath("export_query_result.xlsx")
-	outFile, err := os.Create(outFilename)
+	defer func() { _ = rsp.Close() }()
+
+	_, err = writeFile(t, rsp, "export_query_result.xlsx")
 	require.NoError(t, err)
-	t.Logf("Saving excel file to %q", outFilename)
-	require.NoError(t, f.File.Write(outFile))
 }
 
 func BenchmarkExport(b *testing.B) {
@@ -535,8 +710,11 @@ func BenchmarkExport(b *testing.B) {
 			b.ResetTimer()
 
 			runBenchmark := func() {
-				_, err := Export(env.Ctx, env.YT, req, opts)
+				rsp, err := Export(env.Ctx, env.YT, req, opts)
 				require.NoError(b, err)
+				defer func() { _ = rsp.Close() }()
+
+				require.NoError(b, rsp.Write(io.Discard))
 			}
 
 			for i := 0; i < b.N; i++ {
@@ -0,0 +1,192 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+)
+
+// Format is a format of the exported file.
+type Format string
+
+const (
+	FormatXLSX Format = "xlsx"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+	FormatODS  Format = "ods"
+)
+
+// Formats lists all supported formats.
+var Formats = []Format{FormatXLSX, FormatCSV, FormatTSV, FormatODS}
+
+// orDefault returns xlsx for an empty format.
+func (f Format) orDefault() Format {
+	if f == "" {
+		return FormatXLSX
+	}
+	return f
+}
+
+// Extension returns file extension of the format including the dot.
+func (f Format) Extension() string {
+	return "." + string(f.orDefault())
+}
+
+// ContentType returns mime type of the format.
+func (f Format) ContentType() string {
+	switch f.orDefault() {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatTSV:
+		return "text/tab-separated-values; charset=utf-8"
+	case FormatODS:
+		return "application/vnd.oasis.opendocument.spreadsheet"
+	default:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+}
+
+// SupportsSheets checks whether a file of the format can contain several sheets.
+func (f Format) SupportsSheets() bool {
+	return f.orDefault() == FormatXLSX || f == FormatODS
+}
+
+// ensureExtension makes sure that filename has the extension of the format.
+//
+// Extension of another supported format is replaced, e.g. data.xlsx becomes data.csv.
+func ensureExtension(filename string, f Format) string {
+	ext := f.Extension()
+	if strings.HasSuffix(filename, ext) {
+		return filename
+	}
+
+	for _, other := range Formats {
+		if base, ok := strings.CutSuffix(filename, other.Extension()); ok && base != "" {
+			return base + ext
+		}
+	}
+	return filename + ext
+}
+
+// sheetWriter is a streaming writer of a spreadsheet file.
+type sheetWriter interface {
+	// NewStyle registers cell style with given excel number format and returns its id.
+	NewStyle(numFmt string) int
+	// SetLayout sets presentation of the sheets started after the call, e.g. header styling and column widths.
+	// Formats without such presentation ignore it.
+	SetLayout(l sheetLayout)
+	// NewSheet finishes the current sheet and starts a new one.
+	//
+	// If deferHeader is set, the first row of the sheet is expected to be set via SetHeader
+	// at any moment before the sheet is finished.
+	NewSheet(name string, deferHeader bool) error
+	// SetHeader sets the first row of the sheet started with deferred header.
+	SetHeader(cells []excelize.Cell)
+	// WriteRow appends a row to the current sheet. Cells without value are left empty.
+	WriteRow(cells []excelize.Cell) error
+	// Close finishes the file.
+	Close() error
+	// cleanup removes temporary files. It is safe to call cleanup multiple times.
+	cleanup()
+}
+
+func newSheetWriter(w io.Writer, opts *ConvertOptions) sheetWriter {
+	switch opts.Format.orDefault() {
+	case FormatCSV:
+		delimiter := opts.Delimiter
+		if delimiter == 0 {
+			delimiter = ','
+		}
+		return newCSVWriter(w, FormatCSV, delimiter)
+	case FormatTSV:
+		return newCSVWriter(w, FormatTSV, '\t')
+	case FormatODS:
+		return newODSWriter(w)
+	default:
+		return newXLSXWriter(w)
+	}
+}
+
+// errSingleSheet is returned by writers of formats that can not hold several sheets.
+func errSingleSheet(f Format) error {
+	return ErrBadRequest.Wrap(xerrors.Errorf("%s file can not contain several sheets", f))
+}
+
+// excelSerialToTime converts excel date serial number to time rounding it to milliseconds.
+//
+// It is the inverse of date conversions made by converter.
+func excelSerialToTime(serial float64) time.Time {
+	ms := math.Round(serial * float64(day/time.Millisecond))
+	return unixEpoch.Add(time.Duration(ms)*time.Millisecond - unixEpoch.Add(day).Sub(excelEpoch))
+}
+
+// timeLayouts maps number formats used for dates to go time layouts.
+var timeLayouts = map[string]string{
+	numFmtDate:      "2006-01-02",
+	numFmtDatetime:  "2006-01-02T15:04:05Z",
+	numFmtTimestamp: "2006-01-02T15:04:05.000Z",
+}
+
+// toFloat returns numeric value of v.
+func toFloat(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+// formatCellText returns text representation of the cell value as it would be displayed by excel
+// given the number format of the cell style.
+func formatCellText(v any, numFmt string) string {
+	if layout, ok := timeLayouts[numFmt]; ok {
+		if f, ok := toFloat(v); ok {
+			return excelSerialToTime(f).Format(layout)
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64)
+	case reflect.String:
+		return rv.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,234 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func TestEnsureExtension(t *testing.T) {
+	for _, tc := range []struct {
+		filename string
+		format   Format
+		expected string
+	}{
+		{filename: "data", format: "", expected: "data.xlsx"},
+		{filename: "data.xlsx", format: FormatXLSX, expected: "data.xlsx"},
+		{filename: "data.xlsx", format: FormatCSV, expected: "data.csv"},
+		{filename: "data.tsv", format: FormatODS, expected: "data.ods"},
+		{filename: "data.txt", format: FormatTSV, expected: "data.txt.tsv"},
+		{filename: ".xlsx", format: FormatCSV, expected: ".xlsx.csv"},
+	} {
+		t.Run(tc.filename+"/"+string(tc.format), func(t *testing.T) {
+			require.Equal(t, tc.expected, ensureExtension(tc.filename, tc.format))
+		})
+	}
+}
+
+func TestFormatCellText(t *testing.T) {
+	c := &converter{
+		styles:              &CellStyles{Number: 1, Date: 2, Datetime: 3, Timestamp: 4},
+		numberPrecisionMode: NumberPrecisionModeString,
+	}
+	numFmts := []string{numFmtNumber, numFmtDate, numFmtDatetime, numFmtTimestamp}
+
+	ts := time.Date(2000, time.December, 12, 10, 22, 17, 302000000, time.UTC)
+
+	for _, tc := range []struct {
+		name     string
+		typ      schema.Type
+		in       any
+		expected string
+	}{
+		{name: "int", typ: schema.TypeInt32, in: int64(-42), expected: "-42"},
+		{name: "large-int", typ: schema.TypeUint64, in: uint64(4291747200000000), expected: "4291747200000000"},
+		{name: "double", typ: schema.TypeFloat64, in: 0.25, expected: "0.25"},
+		{name: "bool", typ: schema.TypeBoolean, in: true, expected: "true"},
+		{name: "string", typ: schema.TypeString, in: "a,b", expected: "a,b"},
+		{name: "date", typ: schema.TypeDate, in: uint64(NewDate(ts)), expected: "2000-12-12"},
+		{name: "datetime", typ: schema.TypeDatetime, in: uint64(NewDatetime(ts)), expected: "2000-12-12T10:22:17Z"},
+		{
+			name:     "timestamp",
+			typ:      schema.TypeTimestamp,
+			in:       uint64(NewTimestamp(ts)),
+			expected: "2000-12-12T10:22:17.302Z",
+		},
+		{
+			name:     "timestamp-micro",
+			typ:      schema.TypeTimestamp,
+			in:       uint64(NewTimestamp(ts.Add(time.Microsecond))),
+			expected: "2000-12-12T10:22:17.302001Z",
+		},
+		{name: "date-epoch", typ: schema.TypeDate, in: uint64(0), expected: "1970-01-01"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cell, err := c.convert(tc.typ, tc.in)
+			require.NoError(t, err)
+
+			var numFmt string
+			if cell.StyleID != 0 {
+				numFmt = numFmts[cell.StyleID-1]
+			}
+			require.Equal(t, tc.expected, formatCellText(cell.Value, numFmt))
+		})
+	}
+}
+
+func TestConvert_csv(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64},
+		{Name: "name", Type: schema.TypeString},
+		{Name: "date", Type: schema.TypeDate},
+	}}
+
+	for _, tc := range []struct {
+		name     string
+		rows     []any
+		opts     *ConvertOptions
+		expected string
+	}{
+		{
+			name: "csv",
+			rows: []any{
+				map[string]any{"id": 1, "name": `a "quoted", value`, "date": uint64(1)},
+				map[string]any{"id": 2},
+			},
+			opts: &ConvertOptions{Columns: []string{"id", "name", "date"}, Schema: s, Format: FormatCSV},
+			expected: "id,name,date\r\nint64,utf8,date\r\n" +
+				"1,\"a \"\"quoted\"\", value\",1970-01-02\r\n2,,\r\n",
+		},
+		{
+			name:     "delimiter",
+			rows:     []any{map[string]any{"id": 1, "name": "a;b"}},
+			opts:     &ConvertOptions{Columns: []string{"id", "name"}, Schema: s, Format: FormatCSV, Delimiter: ';'},
+			expected: "id;name\r\nint64;utf8\r\n1;\"a;b\"\r\n",
+		},
+		{
+			name:     "tsv",
+			rows:     []any{map[string]any{"id": 1, "name": "a\tb"}},
+			opts:     &ConvertOptions{Columns: []string{"id", "name"}, Schema: s, Format: FormatTSV},
+			expected: "id\tname\r\nint64\tutf8\r\n1\t\"a\tb\"\r\n",
+		},
+		{
+			name: "schemaless",
+			rows: []any{
+				map[string]any{"b": 1},
+				map[string]any{"a": "x"},
+			},
+			opts:     &ConvertOptions{Format: FormatCSV},
+			expected: "b,a\r\n1,\r\n,x\r\n",
+		},
+		{
+			name: "schemaless padding",
+			rows: []any{
+				map[string]any{},
+				map[string]any{"a": "x\r\ny"},
+				map[string]any{"b": "p;q", "c": `"`},
+			},
+			opts:     &ConvertOptions{Format: FormatCSV, Delimiter: ';'},
+			expected: "a;b;c\r\n;;\r\n\"x\r\ny\";;\r\n;\"p;q\";\"\"\"\"\r\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.NumberPrecisionMode = NumberPrecisionModeString
+			tc.opts.ExportOptions = &ExportOptions{MaxExcelFileSize: 1024 * 1024}
+
+			var buf bytes.Buffer
+			require.NoError(t, Convert(&buf, &rowsReader{rows: tc.rows}, tc.opts))
+			require.Equal(t, tc.expected, buf.String())
+		})
+	}
+}
+
+func TestConvert_csvSingleSheet(t *testing.T) {
+	opts := &ConvertOptions{
+		Format:              FormatCSV,
+		NumberPrecisionMode: NumberPrecisionModeString,
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+		MultiSheet:          true,
+		sheetRowLimit:       2,
+	}
+
+	rows := []any{map[string]any{"a": 1}, map[string]any{"a": 2}}
+	err := Convert(io.Discard, &rowsReader{rows: rows}, opts)
+	require.ErrorIs(t, err, ErrBadRequest)
+}
+
+func TestConvert_ods(t *testing.T) {
+	opts := &ConvertOptions{
+		Columns: []string{"id", "name", "date"},
+		Schema: &schema.Schema{Columns: []schema.Column{
+			{Name: "id", Type: schema.TypeInt64},
+			{Name: "name", Type: schema.TypeString},
+			{Name: "date", Type: schema.TypeDate},
+		}},
+		Format:              FormatODS,
+		NumberPrecisionMode: NumberPrecisionModeString,
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+	}
+
+	rows := []any{
+		map[string]any{"id": 1, "name": "  a <b>", "date": uint64(1)},
+		map[string]any{"name": "c"},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, opts))
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Equal(t, "mimetype", zr.File[0].Name)
+	require.Equal(t, zip.Store, zr.File[0].Method)
+
+	var content []byte
+	for _, f := range zr.File {
+		r, err := f.Open()
+		require.NoError(t, err)
+		data, err := io.ReadAll(r)
+		require.NoError(t, err)
+
+		switch f.Name {
+		case "mimetype":
+			require.Equal(t, odsMimeType, string(data))
+		case "content.xml":
+			content = data
+		}
+	}
+
+	type cell struct {
+		ValueType string `xml:"value-type,attr"`
+		Value     string `xml:"value,attr"`
+		DateValue string `xml:"date-value,attr"`
+		Repeated  int    `xml:"number-columns-repeated,attr"`
+		Text      string `xml:",innerxml"`
+	}
+	var doc struct {
+		Tables []struct {
+			Name string `xml:"name,attr"`
+			Rows []struct {
+				Cells []cell `xml:"table-cell"`
+			} `xml:"table-row"`
+		} `xml:"body>spreadsheet>table"`
+	}
+	require.NoError(t, xml.Unmarshal(content, &doc))
+
+	require.Len(t, doc.Tables, 1)
+	require.Equal(t, SheetName, doc.Tables[0].Name)
+
+	tableRows := doc.Tables[0].Rows
+	require.Len(t, tableRows, 4)
+	require.Equal(t, "string", tableRows[0].Cells[0].ValueType)
+	require.Equal(t, cell{ValueType: "float", Value: "1", Text: "<text:p>1</text:p>"}, tableRows[2].Cells[0])
+	require.Equal(t, "<text:p><text:s text:c=\"2\"/>a &lt;b&gt;</text:p>", tableRows[2].Cells[1].Text)
+	require.Equal(t, "date", tableRows[2].Cells[2].ValueType)
+	require.Equal(t, "1970-01-02", tableRows[2].Cells[2].DateValue)
+	require.Equal(t, cell{}, tableRows[3].Cells[0])
+	require.Equal(t, "<text:p>c</text:p>", tableRows[3].Cells[1].Text)
+}
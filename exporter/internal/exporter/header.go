@@ -0,0 +1,98 @@
+package exporter
+
+import (
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// HeaderMode selects the header rows written at the top of each sheet.
+type HeaderMode string
+
+const (
+	// HeaderNamesAndTypes writes column names on the first row and their types on the second.
+	HeaderNamesAndTypes HeaderMode = "names_and_types"
+	// HeaderNames writes column names only.
+	HeaderNames HeaderMode = "names"
+	// HeaderNone writes no header, so the data starts from the first row.
+	HeaderNone HeaderMode = "none"
+)
+
+// HeaderModes lists all supported header modes.
+var HeaderModes = []HeaderMode{HeaderNamesAndTypes, HeaderNames, HeaderNone}
+
+// HeaderOptions configures the header rows and the presentation of the sheets.
+type HeaderOptions struct {
+	// Mode is names_and_types by default.
+	Mode HeaderMode `json:"mode,omitempty"`
+	// Style makes the header rows bold and freezes them, so they stay visible on scroll.
+	Style bool `json:"style,omitempty"`
+	// AutoFilter adds filter buttons to the column names.
+	AutoFilter bool `json:"autofilter,omitempty"`
+	// AutoWidth sizes the columns to fit the header and the first rows of the sheet.
+	AutoWidth bool `json:"auto_width,omitempty"`
+}
+
+// rowCount returns the number of header rows of a sheet.
+//
+// Schemaless tables have no types, so their header consists of names only.
+func (o HeaderOptions) rowCount(hasSchema bool) int {
+	switch o.Mode {
+	case HeaderNone:
+		return 0
+	case HeaderNames:
+		return 1
+	}
+	if hasSchema {
+		return 2
+	}
+	return 1
+}
+
+// sheetLayout describes presentation of the sheets that is set up by the sheet writer.
+type sheetLayout struct {
+	// headerRows is the number of header rows at the top of each sheet.
+	headerRows int
+	// hidden hides the sheets from the user, e.g. the ones with service data.
+	hidden bool
+	HeaderOptions
+}
+
+const (
+	// widthSampleRows is the number of the first rows of a sheet that the column widths are fitted to.
+	widthSampleRows = 100
+	// maxColumnWidth is the max width of an auto-sized column in characters.
+	maxColumnWidth = 60
+	// maxNumberWidth is the width of numbers displayed in excel general format.
+	maxNumberWidth = 11
+)
+
+// columnWidths tracks the widths of the columns that fit the text of their cells.
+type columnWidths []int
+
+// update widens the columns to fit the cells of the row.
+func (w *columnWidths) update(cells []excelize.Cell, numFmts []string) {
+	for len(*w) < len(cells) {
+		*w = append(*w, 0)
+	}
+
+	for i, cell := range cells {
+		if cell.Value == nil {
+			continue
+		}
+
+		var numFmt string
+		if cell.StyleID > 0 && cell.StyleID <= len(numFmts) {
+			numFmt = numFmts[cell.StyleID-1]
+		}
+
+		width := utf8.RuneCountInString(formatCellText(cell.Value, numFmt))
+		if _, ok := timeLayouts[numFmt]; !ok {
+			switch cell.Value.(type) {
+			case float32, float64:
+				width = min(width, maxNumberWidth)
+			}
+		}
+		(*w)[i] = max((*w)[i], min(width+2, maxColumnWidth))
+	}
+}
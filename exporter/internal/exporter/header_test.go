@@ -0,0 +1,175 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func TestConvert_headerModes(t *testing.T) {
+	rows := []any{
+		map[string]any{"id": 1, "name": "a"},
+		map[string]any{"id": 2, "name": "b"},
+	}
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64},
+		{Name: "name", Type: schema.TypeString},
+	}}
+
+	for _, tc := range []struct {
+		name     string
+		schema   *schema.Schema
+		mode     HeaderMode
+		expected [][]string
+	}{
+		{
+			name:     "default",
+			schema:   s,
+			expected: [][]string{{"id", "name"}, {"int64", "utf8"}, {"1", "a"}, {"2", "b"}},
+		},
+		{
+			name:     "names",
+			schema:   s,
+			mode:     HeaderNames,
+			expected: [][]string{{"id", "name"}, {"1", "a"}, {"2", "b"}},
+		},
+		{
+			name:     "none",
+			schema:   s,
+			mode:     HeaderNone,
+			expected: [][]string{{"1", "a"}, {"2", "b"}},
+		},
+		{
+			name:     "schemaless_names_and_types",
+			mode:     HeaderNamesAndTypes,
+			expected: [][]string{{"id", "name"}, {"1", "a"}, {"2", "b"}},
+		},
+		{
+			name:     "schemaless_none",
+			mode:     HeaderNone,
+			expected: [][]string{{"1", "a"}, {"2", "b"}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := &ConvertOptions{
+				Columns:             []string{"id", "name"},
+				Schema:              tc.schema,
+				ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+				NumberPrecisionMode: NumberPrecisionModeString,
+				Header:              HeaderOptions{Mode: tc.mode},
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, opts))
+
+			f, err := excelize.OpenReader(&buf)
+			require.NoError(t, err)
+			actual, err := f.GetRows(SheetName)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestConvert_headerRowLimit(t *testing.T) {
+	rows := []any{
+		map[string]any{"id": 1},
+		map[string]any{"id": 2},
+		map[string]any{"id": 3},
+	}
+
+	opts := &ConvertOptions{
+		Columns:             []string{"id"},
+		Schema:              &schema.Schema{Columns: []schema.Column{{Name: "id", Type: schema.TypeInt64}}},
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+		NumberPrecisionMode: NumberPrecisionModeString,
+		sheetRowLimit:       3,
+	}
+
+	var buf bytes.Buffer
+	require.ErrorIs(t, Convert(&buf, &rowsReader{rows: rows}, opts), ErrBadRequest)
+
+	opts.Header.Mode = HeaderNone
+	buf.Reset()
+	require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, opts))
+}
+
+func TestConvert_headerLayout(t *testing.T) {
+	var rows []any
+	for i := range widthSampleRows + 10 {
+		rows = append(rows, map[string]any{"id": i, "name": fmt.Sprintf("name %d", i)})
+	}
+	// Rows after the sample do not affect the widths.
+	rows = append(rows, map[string]any{"id": 1, "name": "a very long name that is not sampled"})
+
+	for _, tc := range []struct {
+		name   string
+		schema *schema.Schema
+		// nameWidth is the expected width of the name column.
+		nameWidth float64
+		lastRow   int
+	}{
+		{
+			name: "schema",
+			schema: &schema.Schema{Columns: []schema.Column{
+				{Name: "id", Type: schema.TypeInt64},
+				{Name: "name", Type: schema.TypeString},
+			}},
+			nameWidth: float64(len("name 99") + 2),
+			lastRow:   len(rows) + 1,
+		},
+		{
+			name:      "schemaless",
+			nameWidth: float64(len("name 99") + 2),
+			lastRow:   len(rows) + 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := &ConvertOptions{
+				Columns:             []string{"id", "name"},
+				Schema:              tc.schema,
+				ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+				NumberPrecisionMode: NumberPrecisionModeString,
+				Header:              HeaderOptions{Mode: HeaderNames, Style: true, AutoFilter: true, AutoWidth: true},
+				MultiSheet:          true,
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, opts))
+
+			f, err := excelize.OpenReader(&buf)
+			require.NoError(t, err)
+
+			panes, err := f.GetPanes(SheetName)
+			require.NoError(t, err)
+			require.True(t, panes.Freeze)
+			require.Equal(t, 1, panes.YSplit)
+			require.Equal(t, "A2", panes.TopLeftCell)
+
+			styleID, err := f.GetCellStyle(SheetName, "B1")
+			require.NoError(t, err)
+			style, err := f.GetStyle(styleID)
+			require.NoError(t, err)
+			require.NotNil(t, style.Font)
+			require.True(t, style.Font.Bold)
+
+			styleID, err = f.GetCellStyle(SheetName, "B2")
+			require.NoError(t, err)
+			require.Zero(t, styleID)
+
+			width, err := f.GetColWidth(SheetName, "B")
+			require.NoError(t, err)
+			require.Equal(t, tc.nameWidth, width)
+
+			names := f.GetDefinedName()
+			require.Len(t, names, 1)
+			require.Equal(t, "_xlnm._FilterDatabase", names[0].Name)
+			require.Equal(t, fmt.Sprintf("'Sheet1'!$A$1:$B$%d", tc.lastRow), names[0].RefersTo)
+		})
+	}
+}
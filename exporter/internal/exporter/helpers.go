@@ -1,35 +1,85 @@
 package exporter
 
 import (
+	"bufio"
 	"crypto/rand"
 	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
 	"regexp"
+	"strings"
 
-	"github.com/xuri/excelize/v2"
+	"github.com/c2h5oh/datasize"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/ypath"
 )
 
-// rowWeight returns approximate row weight in bytes.
-//
-// Value lengths are calculated as follows:
-// integers, double — 8 bytes;
-// boolean — 1 byte;
-// string — it's length;
-// nil — 0 bytes.
-func rowWeight(row map[int]excelize.Cell) int {
-	weight := 0
-	for _, cell := range row {
-		switch t := cell.Value.(type) {
-		case int8, uint8, int16, uint16, int, uint, int32, uint32, int64, uint64, float64:
-			weight += 8
-		case bool:
-			weight += 1
-		case string:
-			weight += len(t)
-		case []byte:
-			weight += len(t)
-		}
+// limitedWriter is an io.Writer that fails once the total number of written bytes exceeds the limit.
+type limitedWriter struct {
+	w       io.Writer
+	limit   int
+	written int
+}
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.written+len(p) > w.limit {
+		return 0, xerrors.Errorf("max file size exceeded: %v > %v; "+
+			"try specifying a smaller range of rows or exclude unneeded columns",
+			datasize.ByteSize(w.written+len(p)).HumanReadable(),
+			datasize.ByteSize(w.limit).HumanReadable())
+	}
+
+	n, err := w.w.Write(p)
+	w.written += n
+	return n, err
+}
+
+// spool stores encoded rows in a temporary file until they can be written to the output,
+// e.g. until the header of a sheet is known.
+type spool struct {
+	f   *os.File
+	buf *bufio.Writer
+}
+
+func newSpool() (*spool, error) {
+	f, err := os.CreateTemp("", "excel-exporter-*.spool")
+	if err != nil {
+		return nil, xerrors.Errorf("error creating spool file: %w", err)
+	}
+	return &spool{f: f, buf: bufio.NewWriter(f)}, nil
+}
+
+func (s *spool) Write(p []byte) (int, error) {
+	return s.buf.Write(p)
+}
+
+// Rewind flushes spooled data and returns a reader of it from the start.
+func (s *spool) Rewind() (io.Reader, error) {
+	if err := s.buf.Flush(); err != nil {
+		return nil, xerrors.Errorf("error writing spool file: %w", err)
+	}
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return nil, xerrors.Errorf("error reading spool file: %w", err)
 	}
-	return weight
+	return s.f, nil
+}
+
+// CopyTo writes spooled data to w.
+func (s *spool) CopyTo(w io.Writer) error {
+	r, err := s.Rewind()
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// Remove deletes the temporary file.
+func (s *spool) Remove() {
+	_ = s.f.Close()
+	_ = os.Remove(s.f.Name())
 }
 
 // randomName returns 8 random bytes in hex.
@@ -48,3 +98,45 @@ func init() {
 func replaceNonAlphanumeric(in string) string {
 	return alphanumRegex.ReplaceAllString(in, "_")
 }
+
+// formatRanges formats ranges using ypath range syntax, e.g. #1:#10,("a"):("b").
+func formatRanges(ranges []ypath.Range) string {
+	parts := make([]string, len(ranges))
+	for i, rng := range ranges {
+		switch {
+		case rng.Exact != nil:
+			parts[i] = formatReadLimit(rng.Exact)
+		default:
+			parts[i] = formatReadLimit(rng.Lower) + ":" + formatReadLimit(rng.Upper)
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func formatReadLimit(l *ypath.ReadLimit) string {
+	switch {
+	case l == nil:
+		return ""
+	case l.RowIndex != nil:
+		return fmt.Sprintf("#%d", *l.RowIndex)
+	case len(l.Key) == 1:
+		return formatKeyValue(l.Key[0])
+	default:
+		values := make([]string, len(l.Key))
+		for i, v := range l.Key {
+			values[i] = formatKeyValue(v)
+		}
+		return "(" + strings.Join(values, ",") + ")"
+	}
+}
+
+func formatKeyValue(v any) string {
+	switch v := v.(type) {
+	case []byte:
+		return fmt.Sprintf("%q", v)
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -1,60 +1,63 @@
 package exporter
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/require"
-	"github.com/xuri/excelize/v2"
 )
 
 func TestLimitedWriter(t *testing.T) {
 	for _, tc := range []struct {
-		name   string
-		values []any
-		weight int
+		name    string
+		limit   int
+		writes  []string
+		written string
+		error   bool
 	}{
 		{
-			name: "numbers",
-			values: []any{
-				int8(0), uint8(0), int16(0), uint16(0), int32(0), uint32(0), int64(0), uint64(0),
-				0, uint(0), 0.0,
-			},
-			weight: 8 * 11,
+			name:    "empty",
+			limit:   0,
+			writes:  []string{""},
+			written: "",
 		},
 		{
-			name:   "bool",
-			values: []any{true},
-			weight: 1,
+			name:    "below-limit",
+			limit:   10,
+			writes:  []string{"hello", "test"},
+			written: "hellotest",
 		},
 		{
-			name:   "string",
-			values: []any{"hello"},
-			weight: 5,
+			name:    "exact-limit",
+			limit:   9,
+			writes:  []string{"hello", "test"},
+			written: "hellotest",
 		},
 		{
-			name:   "bytes",
-			values: []any{[]byte("hello")},
-			weight: 5,
-		},
-		{
-			name:   "nil",
-			values: []any{nil, nil, nil},
-			weight: 0,
-		},
-		{
-			name:   "simple",
-			values: []any{false, 42, 0.5, "hello", []byte("test"), nil},
-			weight: 1 + 8 + 8 + 5 + 4 + 0,
+			name:    "exceeded",
+			limit:   8,
+			writes:  []string{"hello", "test"},
+			written: "hello",
+			error:   true,
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
-			row := make(map[int]excelize.Cell)
-			for i, v := range tc.values {
-				if v != nil {
-					row[i] = excelize.Cell{Value: v}
+			var buf bytes.Buffer
+			w := &limitedWriter{w: &buf, limit: tc.limit}
+
+			var err error
+			for _, s := range tc.writes {
+				if _, err = w.Write([]byte(s)); err != nil {
+					break
 				}
 			}
-			require.Equal(t, tc.weight, rowWeight(row))
+
+			if tc.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.written, buf.String())
 		})
 	}
 }
@@ -0,0 +1,425 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/guid"
+)
+
+// Progress counts rows and bytes written by Convert. It is safe for concurrent use.
+type Progress struct {
+	rows  atomic.Int64
+	bytes atomic.Int64
+}
+
+// Rows returns the number of converted rows.
+func (p *Progress) Rows() int64 {
+	return p.rows.Load()
+}
+
+// Bytes returns the number of bytes of the resulting file written so far.
+func (p *Progress) Bytes() int64 {
+	return p.bytes.Load()
+}
+
+// progressWriter is an io.Writer that counts written bytes in Progress.
+type progressWriter struct {
+	w io.Writer
+	p *Progress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.p.bytes.Add(int64(n))
+	return n, err
+}
+
+// JobState is a state of an asynchronous export job.
+type JobState string
+
+const (
+	JobStateQueued    JobState = "queued"
+	JobStateRunning   JobState = "running"
+	JobStateCompleted JobState = "completed"
+	JobStateFailed    JobState = "failed"
+)
+
+// ErrJobQueueFull is returned on job submission when the max number of queued jobs is reached.
+var ErrJobQueueFull = xerrors.NewSentinel("too many export jobs in queue")
+
+// ErrJobNotCompleted is returned on reading the result of the job that is not completed yet.
+var ErrJobNotCompleted = xerrors.NewSentinel("export job is not completed")
+
+// JobOptions configures JobQueue.
+type JobOptions struct {
+	// MaxQueued is the max number of jobs waiting for execution.
+	MaxQueued int
+	// Concurrency is the number of jobs executed simultaneously.
+	Concurrency int
+	// Retention is the time a finished job and its result are kept for.
+	Retention time.Duration
+	// Timeout limits execution time of a single job.
+	Timeout time.Duration
+	// SpoolDir is a local directory to store results in. Results are kept in memory if empty.
+	SpoolDir string
+	// MaxFinished is the max number of finished jobs kept; the oldest ones are removed first.
+	MaxFinished int
+	// MaxMemoryResultBytes is the max total size of results kept in memory;
+	// finished jobs with the oldest in-memory results are removed first.
+	MaxMemoryResultBytes int64
+}
+
+// JobFunc prepares an export of the job. It is called by the worker that executes the job.
+//
+// progress must be passed to Export via ExportOptions.
+type JobFunc func(ctx context.Context, progress *Progress) (*ExportResponse, error)
+
+// Job is an asynchronous export.
+type Job struct {
+	// ID is a unique unguessable identifier of the job.
+	ID string
+	// Owner is the login of the user who submitted the job.
+	Owner string
+	// Cluster is the proxy of the cluster the job reads data from.
+	Cluster string
+
+	ctx      context.Context
+	run      JobFunc
+	progress Progress
+
+	mu          sync.Mutex
+	state       JobState
+	err         error
+	filename    string
+	contentType string
+	createdAt   time.Time
+	startedAt   time.Time
+	finishedAt  time.Time
+	// Result is stored either in data or in a file of the spool directory.
+	data     []byte
+	dataPath string
+}
+
+// JobStatus describes the state of the job.
+type JobStatus struct {
+	ID         string     `json:"id"`
+	State      JobState   `json:"state"`
+	Filename   string     `json:"filename,omitempty"`
+	Rows       int64      `json:"rows"`
+	Bytes      int64      `json:"bytes"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Status returns current status of the job.
+func (j *Job) Status() *JobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	s := &JobStatus{
+		ID:        j.ID,
+		State:     j.state,
+		Filename:  j.filename,
+		Rows:      j.progress.Rows(),
+		Bytes:     j.progress.Bytes(),
+		CreatedAt: j.createdAt,
+	}
+	if j.err != nil {
+		s.Error = j.err.Error()
+	}
+	if !j.startedAt.IsZero() {
+		startedAt := j.startedAt
+		s.StartedAt = &startedAt
+	}
+	if !j.finishedAt.IsZero() {
+		finishedAt := j.finishedAt
+		s.FinishedAt = &finishedAt
+	}
+	return s
+}
+
+// Err returns the error of the failed job.
+func (j *Job) Err() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.err
+}
+
+// Result returns the resulting file of the completed job.
+//
+// ErrJobNotCompleted is returned if the job is queued, running or failed.
+func (j *Job) Result() (filename, contentType string, r io.ReadCloser, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.state != JobStateCompleted {
+		return "", "", nil, ErrJobNotCompleted.Wrap(xerrors.Errorf("job %s is %s", j.ID, j.state))
+	}
+
+	if j.dataPath == "" {
+		return j.filename, j.contentType, io.NopCloser(bytes.NewReader(j.data)), nil
+	}
+
+	f, err := os.Open(j.dataPath)
+	if err != nil {
+		return "", "", nil, xerrors.Errorf("error opening result of job %s: %w", j.ID, err)
+	}
+	return j.filename, j.contentType, f, nil
+}
+
+func (j *Job) finish(err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.finishedAt = time.Now()
+	if err != nil {
+		j.state = JobStateFailed
+		j.err = err
+		return
+	}
+	j.state = JobStateCompleted
+}
+
+// removeResult deletes the result of the job.
+func (j *Job) removeResult() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.data = nil
+	if j.dataPath != "" {
+		_ = os.Remove(j.dataPath)
+		j.dataPath = ""
+	}
+}
+
+// JobQueue is a bounded in-process queue of export jobs.
+//
+// Jobs are executed by a fixed number of workers started by Run.
+// Finished jobs are kept for JobOptions.Retention and then removed along with their results.
+// Jobs finished earlier are removed before that if the number of finished jobs
+// or the size of results kept in memory exceeds the limits of JobOptions.
+type JobQueue struct {
+	opts  *JobOptions
+	queue chan *Job
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	// finished lists finished jobs in order of completion.
+	finished    []*Job
+	memoryBytes int64
+}
+
+// NewJobQueue creates new queue.
+func NewJobQueue(opts *JobOptions) *JobQueue {
+	return &JobQueue{
+		opts:  opts,
+		queue: make(chan *Job, opts.MaxQueued),
+		jobs:  make(map[string]*Job),
+	}
+}
+
+// Submit adds a job to the queue.
+//
+// The job is executed with the values of ctx, e.g. user credentials, but is not canceled with ctx.
+func (q *JobQueue) Submit(ctx context.Context, owner, cluster string, run JobFunc) (*Job, error) {
+	j := &Job{
+		ID:        guid.New().String(),
+		Owner:     owner,
+		Cluster:   cluster,
+		ctx:       context.WithoutCancel(ctx),
+		run:       run,
+		state:     JobStateQueued,
+		createdAt: time.Now(),
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	select {
+	case q.queue <- j:
+	default:
+		return nil, ErrJobQueueFull.Wrap(xerrors.Errorf("max number of queued jobs is %d", q.opts.MaxQueued))
+	}
+
+	q.jobs[j.ID] = j
+	return j, nil
+}
+
+// Get returns the job by id.
+func (q *JobQueue) Get(id string) (*Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	j, ok := q.jobs[id]
+	return j, ok
+}
+
+// Run executes jobs until ctx is canceled.
+//
+// Running jobs are canceled and all results are removed on return.
+func (q *JobQueue) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < q.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-q.queue:
+					q.execute(ctx, j)
+				}
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(min(q.opts.Retention, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			q.removeJobs(time.Time{})
+			return ctx.Err()
+		case now := <-ticker.C:
+			q.removeJobs(now.Add(-q.opts.Retention))
+		}
+	}
+}
+
+// removeJobs removes jobs finished before the deadline or all jobs if deadline is zero.
+func (q *JobQueue) removeJobs(deadline time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if deadline.IsZero() {
+		for _, j := range q.jobs {
+			j.removeResult()
+		}
+		clear(q.jobs)
+		q.finished = nil
+		q.memoryBytes = 0
+		return
+	}
+
+	for len(q.finished) > 0 && q.finished[0].finishedAt.Before(deadline) {
+		q.removeOldest()
+	}
+}
+
+// addFinished registers finished job and removes the oldest jobs exceeding the limits.
+func (q *JobQueue) addFinished(j *Job) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.jobs[j.ID]; !ok {
+		// The queue is stopped and the job is already forgotten.
+		j.removeResult()
+		return
+	}
+
+	q.finished = append(q.finished, j)
+	q.memoryBytes += int64(len(j.data))
+
+	// The job that has just finished is kept even if its result alone exceeds the limit.
+	for len(q.finished) > 1 {
+		tooMany := q.opts.MaxFinished > 0 && len(q.finished) > q.opts.MaxFinished
+		tooLarge := q.opts.MaxMemoryResultBytes > 0 && q.memoryBytes > q.opts.MaxMemoryResultBytes
+		if !tooMany && !tooLarge {
+			break
+		}
+		q.removeOldest()
+	}
+}
+
+// removeOldest removes the job that has finished first. q.mu must be held.
+func (q *JobQueue) removeOldest() {
+	j := q.finished[0]
+	q.finished[0] = nil
+	q.finished = q.finished[1:]
+
+	q.memoryBytes -= int64(len(j.data))
+	j.removeResult()
+	delete(q.jobs, j.ID)
+}
+
+func (q *JobQueue) execute(ctx context.Context, j *Job) {
+	j.mu.Lock()
+	j.state = JobStateRunning
+	j.startedAt = time.Now()
+	j.mu.Unlock()
+
+	jobCtx, cancel := context.WithTimeout(j.ctx, q.opts.Timeout)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	j.finish(q.safeWrite(jobCtx, j))
+	q.addFinished(j)
+}
+
+// safeWrite is like write but converts a panic to the job error, so that it does not crash the service.
+func (q *JobQueue) safeWrite(ctx context.Context, j *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = xerrors.Errorf("export job panicked: %v", r)
+		}
+	}()
+	return q.write(ctx, j)
+}
+
+// write converts the job data and stores the result.
+func (q *JobQueue) write(ctx context.Context, j *Job) error {
+	rsp, err := j.run(ctx, &j.progress)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = rsp.Close() }()
+
+	j.mu.Lock()
+	j.filename = rsp.Filename
+	j.contentType = rsp.ContentType
+	j.mu.Unlock()
+
+	if q.opts.SpoolDir == "" {
+		var buf bytes.Buffer
+		if err := rsp.Write(&buf); err != nil {
+			return err
+		}
+
+		j.mu.Lock()
+		j.data = buf.Bytes()
+		j.mu.Unlock()
+		return nil
+	}
+
+	f, err := os.CreateTemp(q.opts.SpoolDir, "export-job-*")
+	if err != nil {
+		return xerrors.Errorf("error creating result file: %w", err)
+	}
+
+	err = rsp.Write(f)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = xerrors.Errorf("error writing result file: %w", closeErr)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return err
+	}
+
+	j.mu.Lock()
+	j.dataPath = f.Name()
+	j.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,216 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// testJob returns a job exporting given rows of a single int64 column.
+func testJob(rows []any) JobFunc {
+	return func(ctx context.Context, progress *Progress) (*ExportResponse, error) {
+		return &ExportResponse{
+			Filename:    "table.xlsx",
+			ContentType: FormatXLSX.ContentType(),
+			source:      "test",
+			in:          &rowsReader{rows: rows},
+			convertOpts: &ConvertOptions{
+				Columns: []string{"id"},
+				Schema: &schema.Schema{Columns: []schema.Column{
+					{Name: "id", Type: schema.TypeInt64},
+				}},
+				ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024, Progress: progress},
+				NumberPrecisionMode: NumberPrecisionModeString,
+			},
+		}, nil
+	}
+}
+
+func waitJob(t *testing.T, j *Job) *JobStatus {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		s := j.Status()
+		if s.State == JobStateCompleted || s.State == JobStateFailed {
+			return s
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s is not finished", j.ID)
+	return nil
+}
+
+func TestJobQueue(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		spoolDir bool
+	}{
+		{name: "memory"},
+		{name: "spool", spoolDir: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := &JobOptions{MaxQueued: 10, Concurrency: 2, Retention: time.Hour, Timeout: time.Minute}
+			if tc.spoolDir {
+				opts.SpoolDir = t.TempDir()
+			}
+			q := NewJobQueue(opts)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() { done <- q.Run(ctx) }()
+
+			j, err := q.Submit(context.Background(), "user", "cluster", testJob([]any{
+				map[string]any{"id": 1},
+				map[string]any{"id": 2},
+			}))
+			require.NoError(t, err)
+
+			got, ok := q.Get(j.ID)
+			require.True(t, ok)
+			require.Equal(t, "user", got.Owner)
+
+			status := waitJob(t, j)
+			require.Equal(t, JobStateCompleted, status.State)
+			require.Equal(t, "table.xlsx", status.Filename)
+			require.Equal(t, int64(2), status.Rows)
+			require.NotEqual(t, int64(0), status.Bytes)
+			require.NotNil(t, status.FinishedAt)
+
+			filename, contentType, r, err := j.Result()
+			require.NoError(t, err)
+			require.Equal(t, "table.xlsx", filename)
+			require.Equal(t, FormatXLSX.ContentType(), contentType)
+
+			data, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.NoError(t, r.Close())
+			require.Equal(t, status.Bytes, int64(len(data)))
+
+			f, err := excelize.OpenReader(bytes.NewReader(data))
+			require.NoError(t, err)
+			rows, err := f.GetRows(SheetName)
+			require.NoError(t, err)
+			require.Equal(t, [][]string{{"id"}, {"int64"}, {"1"}, {"2"}}, rows)
+
+			cancel()
+			require.ErrorIs(t, <-done, context.Canceled)
+
+			_, ok = q.Get(j.ID)
+			require.False(t, ok)
+			if tc.spoolDir {
+				files, err := os.ReadDir(opts.SpoolDir)
+				require.NoError(t, err)
+				require.Empty(t, files)
+			}
+		})
+	}
+}
+
+func TestJobQueue_failed(t *testing.T) {
+	q := NewJobQueue(&JobOptions{MaxQueued: 1, Concurrency: 1, Retention: time.Hour, Timeout: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = q.Run(ctx) }()
+
+	j, err := q.Submit(context.Background(), "user", "cluster", func(ctx context.Context, progress *Progress) (*ExportResponse, error) {
+		return nil, ErrBadRequest.Wrap(errors.New("no such table"))
+	})
+	require.NoError(t, err)
+
+	status := waitJob(t, j)
+	require.Equal(t, JobStateFailed, status.State)
+	require.Contains(t, status.Error, "no such table")
+	require.ErrorIs(t, j.Err(), ErrBadRequest)
+
+	_, _, _, err = j.Result()
+	require.ErrorIs(t, err, ErrJobNotCompleted)
+}
+
+func TestJobQueue_full(t *testing.T) {
+	q := NewJobQueue(&JobOptions{MaxQueued: 1, Concurrency: 1, Retention: time.Hour, Timeout: time.Minute})
+
+	_, err := q.Submit(context.Background(), "user", "cluster", testJob(nil))
+	require.NoError(t, err)
+
+	_, err = q.Submit(context.Background(), "user", "cluster", testJob(nil))
+	require.ErrorIs(t, err, ErrJobQueueFull)
+}
+
+func TestJobQueue_retention(t *testing.T) {
+	q := NewJobQueue(&JobOptions{MaxQueued: 1, Concurrency: 1, Retention: time.Hour, Timeout: time.Minute})
+
+	j, err := q.Submit(context.Background(), "user", "cluster", testJob(nil))
+	require.NoError(t, err)
+
+	q.removeJobs(time.Now())
+	_, ok := q.Get(j.ID)
+	require.True(t, ok, "unfinished job must be kept")
+
+	q.execute(context.Background(), <-q.queue)
+	require.Equal(t, JobStateCompleted, j.Status().State)
+
+	q.removeJobs(time.Now().Add(-time.Minute))
+	_, ok = q.Get(j.ID)
+	require.True(t, ok)
+
+	q.removeJobs(time.Now().Add(time.Minute))
+	_, ok = q.Get(j.ID)
+	require.False(t, ok)
+}
+
+func TestJobQueue_limits(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts *JobOptions
+	}{
+		{name: "max_finished", opts: &JobOptions{MaxFinished: 2}},
+		{name: "max_memory_result_bytes", opts: &JobOptions{MaxMemoryResultBytes: 1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.MaxQueued = 3
+			tc.opts.Concurrency = 1
+			tc.opts.Retention = time.Hour
+			tc.opts.Timeout = time.Minute
+			q := NewJobQueue(tc.opts)
+
+			var jobs []*Job
+			for i := 0; i < 3; i++ {
+				j, err := q.Submit(context.Background(), "user", "cluster", testJob(nil))
+				require.NoError(t, err)
+				q.execute(context.Background(), <-q.queue)
+				jobs = append(jobs, j)
+			}
+
+			_, ok := q.Get(jobs[0].ID)
+			require.False(t, ok, "the oldest job must be removed")
+			_, ok = q.Get(jobs[2].ID)
+			require.True(t, ok, "the latest job must be kept")
+		})
+	}
+}
+
+func TestJobQueue_panic(t *testing.T) {
+	q := NewJobQueue(&JobOptions{MaxQueued: 1, Concurrency: 1, Retention: time.Hour, Timeout: time.Minute})
+
+	j, err := q.Submit(context.Background(), "user", "cluster", func(ctx context.Context, progress *Progress) (*ExportResponse, error) {
+		panic("boom")
+	})
+	require.NoError(t, err)
+
+	q.execute(context.Background(), <-q.queue)
+
+	status := j.Status()
+	require.Equal(t, JobStateFailed, status.State)
+	require.Contains(t, status.Error, "boom")
+}
@@ -0,0 +1,136 @@
+package exporter
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yson"
+	"go.ytsaurus.tech/yt/go/yt"
+)
+
+// MetadataSheetName is the name of the hidden sheet describing the origin of the file.
+//
+// Each row of the sheet holds a key in the first column and its text value in the second one.
+// Values that do not fit into a single cell, e.g. large schemas, continue in the next columns.
+const MetadataSheetName = "_metadata"
+
+// Metadata describes the table the file is exported from.
+type Metadata struct {
+	// Cluster is the proxy of the YT cluster.
+	Cluster string
+	Path    ypath.Path
+	// Rows are the row ranges of the path in ypath syntax, e.g. [#10:#20]; empty if all rows are exported.
+	Rows string
+	// Query is the filter, order and limits of the exported rows; empty if not set.
+	Query   string
+	Columns []string
+	// Schema is the full schema of the table.
+	Schema              *schema.Schema
+	Revision            uint64
+	ModificationTime    string
+	ExportTime          time.Time
+	User                string
+	NumberPrecisionMode NumberPrecisionMode
+}
+
+// makeMetadata reads the attributes of the exported table and the requesting user.
+func makeMetadata(
+	ctx context.Context,
+	yc yt.Client,
+	table *tableSnapshot,
+	req *ExportRequest,
+	s *schema.Schema,
+	cluster string,
+) (*Metadata, error) {
+	m := &Metadata{
+		Cluster:             cluster,
+		Path:                req.Path,
+		Rows:                req.formatRows(),
+		Columns:             req.Columns,
+		Schema:              s,
+		ExportTime:          time.Now().UTC(),
+		NumberPrecisionMode: req.NumberPrecisionMode,
+	}
+	if req.Query != nil {
+		m.Query = req.Query.String()
+	}
+
+	var err error
+	if m.Revision, err = table.readRevision(ctx); err != nil {
+		return nil, err
+	}
+	if err := table.tx.GetNode(ctx, table.node.Attr("modification_time"), &m.ModificationTime, nil); err != nil {
+		return nil, xerrors.Errorf("error reading table modification time: %w", err)
+	}
+
+	user, err := yc.WhoAmI(ctx, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("error authenticating user: %w", err)
+	}
+	m.User = user.Login
+	return m, nil
+}
+
+// rows returns the key-value rows of the metadata sheet.
+func (m *Metadata) rows() ([][]string, error) {
+	columns, err := yson.MarshalFormat(m.Columns, yson.FormatText)
+	if err != nil {
+		return nil, xerrors.Errorf("error marshaling columns: %w", err)
+	}
+	s, err := yson.MarshalFormat(m.Schema, yson.FormatText)
+	if err != nil {
+		return nil, xerrors.Errorf("error marshaling schema: %w", err)
+	}
+
+	return [][]string{
+		{"cluster", m.Cluster},
+		{"path", string(m.Path)},
+		{"rows", m.Rows},
+		{"query", m.Query},
+		{"columns", string(columns)},
+		{"schema", string(s)},
+		{"revision", strconv.FormatUint(m.Revision, 10)},
+		{"modification_time", m.ModificationTime},
+		{"export_time", m.ExportTime.Format(time.RFC3339Nano)},
+		{"user", m.User},
+		{"number_precision_mode", string(m.NumberPrecisionMode)},
+	}, nil
+}
+
+// writeMetadataSheet writes the metadata to the hidden sheet MetadataSheetName.
+func (b *workbook) writeMetadataSheet(m *Metadata) error {
+	rows, err := m.rows()
+	if err != nil {
+		return err
+	}
+
+	cells := make([][]excelize.Cell, len(rows))
+	for i, row := range rows {
+		cells[i] = []excelize.Cell{{Value: row[0]}}
+		for _, chunk := range splitText(row[1], maxExcelStrLen) {
+			cells[i] = append(cells[i], excelize.Cell{Value: chunk})
+		}
+	}
+	return b.writeHiddenSheet(MetadataSheetName, cells)
+}
+
+// writeHiddenSheet writes the rows to a new hidden sheet, e.g. the one with service data.
+func (b *workbook) writeHiddenSheet(name string, rows [][]excelize.Cell) error {
+	b.out.SetLayout(sheetLayout{hidden: true})
+	if err := b.out.NewSheet(name, false); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if err := b.out.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
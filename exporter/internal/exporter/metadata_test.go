@@ -0,0 +1,153 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yson"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+// readMetadataSheet returns the key-value pairs of the metadata sheet.
+func readMetadataSheet(t *testing.T, f *excelize.File) map[string]string {
+	t.Helper()
+
+	visible, err := f.GetSheetVisible(MetadataSheetName)
+	require.NoError(t, err)
+	require.False(t, visible)
+
+	rows, err := f.GetRows(MetadataSheetName, excelize.Options{RawCellValue: true})
+	require.NoError(t, err)
+
+	values := make(map[string]string)
+	for _, row := range rows {
+		require.NotEmpty(t, row)
+		values[row[0]] = strings.Join(row[1:], "")
+	}
+	return values
+}
+
+func TestConvert_metadata(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, SortOrder: schema.SortAscending},
+		{Name: "name", Type: schema.TypeString},
+	}}
+	// A schema that does not fit into a single cell.
+	wide := s.Copy()
+	for i := 0; len(wide.Columns)*20 < 2*maxExcelStrLen; i++ {
+		wide.Columns = append(wide.Columns, schema.Column{Name: "column_" + strconv.Itoa(i), Type: schema.TypeString})
+	}
+
+	m := &Metadata{
+		Cluster:             "hahn",
+		Path:                "//tmp/table",
+		Rows:                "[#10:#20]",
+		Columns:             []string{"name", "id"},
+		Schema:              &wide,
+		Revision:            42,
+		ModificationTime:    "2024-02-29T10:00:00.000000Z",
+		ExportTime:          time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC),
+		User:                "root",
+		NumberPrecisionMode: NumberPrecisionModeString,
+	}
+
+	opts := &ConvertOptions{
+		Columns:             []string{"name", "id"},
+		Schema:              s,
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 10 * 1024 * 1024},
+		NumberPrecisionMode: NumberPrecisionModeString,
+		Metadata:            m,
+	}
+	rows := []any{map[string]any{"id": 1, "name": "a"}}
+
+	t.Run("xlsx", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, opts))
+
+		f, err := excelize.OpenReader(&buf)
+		require.NoError(t, err)
+		require.Equal(t, []string{SheetName, MetadataSheetName}, f.GetSheetList())
+
+		values := readMetadataSheet(t, f)
+		require.Equal(t, map[string]string{
+			"cluster":               "hahn",
+			"path":                  "//tmp/table",
+			"rows":                  "[#10:#20]",
+			"query":                 "",
+			"columns":               `[name;id;]`,
+			"schema":                values["schema"],
+			"revision":              "42",
+			"modification_time":     "2024-02-29T10:00:00.000000Z",
+			"export_time":           "2024-03-01T12:30:00Z",
+			"user":                  "root",
+			"number_precision_mode": "string",
+		}, values)
+
+		var stored schema.Schema
+		require.NoError(t, yson.Unmarshal([]byte(values["schema"]), &stored))
+		require.Equal(t, wide.Columns, stored.Columns)
+	})
+
+	t.Run("ods", func(t *testing.T) {
+		opts := *opts
+		opts.Format = FormatODS
+
+		var buf bytes.Buffer
+		require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, &opts))
+
+		zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		require.NoError(t, err)
+		r, err := zr.Open("content.xml")
+		require.NoError(t, err)
+		content, err := io.ReadAll(r)
+		require.NoError(t, err)
+
+		require.Contains(t, string(content), `<table:table table:name="`+SheetName+`">`)
+		require.Contains(t, string(content), `<table:table table:name="`+MetadataSheetName+`" table:style-name="taHidden">`)
+	})
+}
+
+func TestExport_metadata(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	path := env.TmpPath()
+	_, err := yt.CreateTable(env.Ctx, env.YT, path, yt.WithSchema(schema.MustInfer(&UIntAndDouble{})))
+	require.NoError(t, err)
+	require.NoError(t, env.UploadSlice(path, []UIntAndDouble{{UI64: 1, Double: 2}}))
+
+	var revision uint64
+	require.NoError(t, env.YT.GetNode(env.Ctx, path.Attr("revision"), &revision, nil))
+
+	req, err := MakeExportRequest(path.String()+"{double}[#0:#1]", NumberPrecisionModeError)
+	require.NoError(t, err)
+	req.Metadata = true
+
+	opts := &ExportOptions{MaxExcelFileSize: 1024 * 1024, Cluster: "local"}
+	f, err := exportFile(t, env.Ctx, env.YT, req, opts, "metadata.xlsx")
+	require.NoError(t, err)
+
+	values := readMetadataSheet(t, f)
+	require.Equal(t, "local", values["cluster"])
+	require.Equal(t, path.String(), values["path"])
+	require.Equal(t, "[#0:#1]", values["rows"])
+	require.Equal(t, `[double;]`, values["columns"])
+	require.Equal(t, strconv.FormatUint(revision, 10), values["revision"])
+	require.NotEmpty(t, values["modification_time"])
+	require.NotEmpty(t, values["user"])
+	require.Equal(t, string(NumberPrecisionModeError), values["number_precision_mode"])
+
+	req.Format = FormatCSV
+	_, err = Export(env.Ctx, env.YT, req, opts)
+	require.ErrorIs(t, err, ErrBadRequest)
+}
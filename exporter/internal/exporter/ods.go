@@ -0,0 +1,386 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+)
+
+const (
+	odsMimeType = "application/vnd.oasis.opendocument.spreadsheet"
+
+	odsContentHeader = xmlHeader + `<office:document-content` +
+		` xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"` +
+		` xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0"` +
+		` xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"` +
+		` xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"` +
+		` xmlns:number="urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0"` +
+		` office:version="1.2">`
+)
+
+// odsWriter is a streaming writer of OpenDocument spreadsheets.
+//
+// All sheets are stored in a single content.xml entry that is compressed into the output as rows are written.
+type odsWriter struct {
+	zw      *zip.Writer
+	content io.Writer
+
+	numFmts []string
+	sheets  int
+	// hidden hides the sheets started after it is set.
+	hidden bool
+
+	sheet io.Writer
+	// spool stores rows of the current sheet when its header is deferred.
+	spool  *spool
+	header []excelize.Cell
+	empty  bool
+
+	buf bytes.Buffer
+}
+
+func newODSWriter(w io.Writer) *odsWriter {
+	return &odsWriter{zw: zip.NewWriter(w)}
+}
+
+func (w *odsWriter) NewStyle(numFmt string) int {
+	w.numFmts = append(w.numFmts, numFmt)
+	return len(w.numFmts)
+}
+
+func (w *odsWriter) SetLayout(layout sheetLayout) {
+	w.hidden = layout.hidden
+}
+
+func (w *odsWriter) NewSheet(name string, deferHeader bool) error {
+	if w.content == nil {
+		if err := w.createContent(); err != nil {
+			return err
+		}
+	}
+
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+
+	w.sheets++
+	w.empty = true
+
+	w.buf.Reset()
+	w.buf.WriteString(`<table:table table:name="`)
+	escapeAttr(&w.buf, name)
+	if w.hidden {
+		w.buf.WriteString(`" table:style-name="taHidden">`)
+	} else {
+		w.buf.WriteString(`">`)
+	}
+	if _, err := w.content.Write(w.buf.Bytes()); err != nil {
+		return err
+	}
+
+	if deferHeader {
+		spool, err := newSpool()
+		if err != nil {
+			return err
+		}
+		w.spool = spool
+		w.sheet = spool
+		return nil
+	}
+
+	w.sheet = w.content
+	return nil
+}
+
+func (w *odsWriter) SetHeader(cells []excelize.Cell) {
+	w.header = cells
+}
+
+func (w *odsWriter) WriteRow(cells []excelize.Cell) error {
+	if w.sheet == nil {
+		return xerrors.New("no sheet to write the row to")
+	}
+
+	w.empty = false
+	w.buf.Reset()
+	w.encodeRow(cells)
+
+	_, err := w.sheet.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *odsWriter) Close() error {
+	if w.sheets == 0 {
+		if err := w.NewSheet(SheetName, false); err != nil {
+			return err
+		}
+	}
+
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w.content, `</office:spreadsheet></office:body></office:document-content>`); err != nil {
+		return err
+	}
+
+	manifest, err := w.zw.Create("META-INF/manifest.xml")
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(manifest, xmlHeader+
+		`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">`+
+		`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="`+odsMimeType+`"/>`+
+		`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>`+
+		`</manifest:manifest>`)
+	if err != nil {
+		return err
+	}
+
+	return w.zw.Close()
+}
+
+func (w *odsWriter) cleanup() {
+	if w.spool != nil {
+		w.spool.Remove()
+		w.spool = nil
+	}
+}
+
+// createContent writes mimetype entry and starts content.xml with the cell styles.
+func (w *odsWriter) createContent() error {
+	// Mimetype must be the first entry of the archive and must not be compressed.
+	mimetype, err := w.zw.CreateRaw(&zip.FileHeader{
+		Name:               "mimetype",
+		Method:             zip.Store,
+		CRC32:              crc32.ChecksumIEEE([]byte(odsMimeType)),
+		CompressedSize64:   uint64(len(odsMimeType)),
+		UncompressedSize64: uint64(len(odsMimeType)),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mimetype, odsMimeType); err != nil {
+		return err
+	}
+
+	content, err := w.zw.Create("content.xml")
+	if err != nil {
+		return err
+	}
+	w.content = content
+
+	w.buf.Reset()
+	w.buf.WriteString(odsContentHeader)
+	w.buf.WriteString(`<office:automatic-styles>`)
+	w.buf.WriteString(`<style:style style:name="taHidden" style:family="table">` +
+		`<style:table-properties table:display="false"/></style:style>`)
+	for i, numFmt := range w.numFmts {
+		id := i + 1
+		if dataStyle := odsDataStyle(numFmt); dataStyle != "" {
+			fmt.Fprintf(&w.buf, dataStyle, id)
+			fmt.Fprintf(&w.buf, `<style:style style:name="ce%d" style:family="table-cell" style:data-style-name="N%d"/>`,
+				id, id)
+		} else {
+			fmt.Fprintf(&w.buf, `<style:style style:name="ce%d" style:family="table-cell"/>`, id)
+		}
+	}
+	w.buf.WriteString(`</office:automatic-styles><office:body><office:spreadsheet>`)
+
+	_, err = w.content.Write(w.buf.Bytes())
+	return err
+}
+
+// odsDataStyle returns data style definition for excel number format with a placeholder for the style id.
+func odsDataStyle(numFmt string) string {
+	const (
+		date = `<number:year number:style="long"/><number:text>-</number:text>` +
+			`<number:month number:style="long"/><number:text>-</number:text><number:day number:style="long"/>`
+		time = `<number:text>T</number:text><number:hours number:style="long"/><number:text>:</number:text>` +
+			`<number:minutes number:style="long"/><number:text>:</number:text>`
+	)
+
+	switch numFmt {
+	case numFmtNumber:
+		return `<number:number-style style:name="N%d">` +
+			`<number:number number:decimal-places="0" number:min-integer-digits="1"/></number:number-style>`
+	case numFmtDate:
+		return `<number:date-style style:name="N%d">` + date + `</number:date-style>`
+	case numFmtDatetime:
+		return `<number:date-style style:name="N%d">` + date + time +
+			`<number:seconds number:style="long"/><number:text>Z</number:text></number:date-style>`
+	case numFmtTimestamp:
+		return `<number:date-style style:name="N%d">` + date + time +
+			`<number:seconds number:style="long" number:decimal-places="3"/><number:text>Z</number:text>` +
+			`</number:date-style>`
+	default:
+		return ""
+	}
+}
+
+func (w *odsWriter) finishSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+
+	if w.spool != nil {
+		defer w.cleanup()
+
+		w.buf.Reset()
+		w.encodeRow(w.header)
+		if _, err := w.content.Write(w.buf.Bytes()); err != nil {
+			return err
+		}
+
+		if err := w.spool.CopyTo(w.content); err != nil {
+			return err
+		}
+		w.header = nil
+	} else if w.empty {
+		// Table must contain at least one row.
+		if _, err := io.WriteString(w.content, `<table:table-row><table:table-cell/></table:table-row>`); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w.content, `</table:table>`)
+	w.sheet = nil
+	return err
+}
+
+func (w *odsWriter) encodeRow(cells []excelize.Cell) {
+	b := &w.buf
+	b.WriteString(`<table:table-row>`)
+
+	skipped := 0
+	for _, cell := range cells {
+		if cell.Value == nil {
+			skipped++
+			continue
+		}
+
+		switch skipped {
+		case 0:
+		case 1:
+			b.WriteString(`<table:table-cell/>`)
+		default:
+			fmt.Fprintf(b, `<table:table-cell table:number-columns-repeated="%d"/>`, skipped)
+		}
+		skipped = 0
+
+		var numFmt string
+		b.WriteString(`<table:table-cell`)
+		if cell.StyleID != 0 {
+			numFmt = w.numFmts[cell.StyleID-1]
+			fmt.Fprintf(b, ` table:style-name="ce%d"`, cell.StyleID)
+		}
+		encodeODSCellValue(b, cell.Value, numFmt)
+		b.WriteString(`</table:table-cell>`)
+	}
+
+	if len(cells) == 0 || skipped == len(cells) {
+		b.WriteString(`<table:table-cell/>`)
+	}
+
+	b.WriteString(`</table:table-row>`)
+}
+
+// encodeODSCellValue writes value type attributes and the text of the cell.
+func encodeODSCellValue(b *bytes.Buffer, v any, numFmt string) {
+	if _, ok := timeLayouts[numFmt]; ok {
+		if f, ok := toFloat(v); ok {
+			t := excelSerialToTime(f)
+			layout := "2006-01-02T15:04:05.000"
+			if numFmt == numFmtDate {
+				layout = "2006-01-02"
+			}
+			fmt.Fprintf(b, ` office:value-type="date" office:date-value="%s">`, t.Format(layout))
+			encodeODSText(b, formatCellText(v, numFmt))
+			return
+		}
+	}
+
+	switch v := v.(type) {
+	case string, []byte:
+	case bool:
+		fmt.Fprintf(b, ` office:value-type="boolean" office:boolean-value="%t">`, v)
+		encodeODSText(b, formatCellText(v, numFmt))
+		return
+	default:
+		if f, ok := toFloat(v); ok && !math.IsNaN(f) && !math.IsInf(f, 0) {
+			text := formatCellText(v, numFmt)
+			b.WriteString(` office:value-type="float" office:value="`)
+			b.WriteString(text)
+			b.WriteString(`">`)
+			encodeODSText(b, text)
+			return
+		}
+	}
+
+	b.WriteString(` office:value-type="string">`)
+	encodeODSText(b, formatCellText(v, numFmt))
+}
+
+// encodeODSText writes s as a text paragraph.
+//
+// Consecutive spaces, tabs and line breaks are written as elements since xml whitespace is collapsed in text.
+// Characters that are not allowed in xml 1.0 are replaced with U+FFFD.
+func encodeODSText(b *bytes.Buffer, s string) {
+	b.WriteString(`<text:p>`)
+
+	spaces := 0
+	flushSpaces := func() {
+		switch spaces {
+		case 0:
+		case 1:
+			b.WriteString(`<text:s/>`)
+		default:
+			b.WriteString(`<text:s text:c="` + strconv.Itoa(spaces) + `"/>`)
+		}
+		spaces = 0
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		prevSpace := i > 0 && s[i-1] == ' '
+
+		if r == ' ' && (i == 0 || prevSpace) {
+			spaces++
+			i += size
+			continue
+		}
+		flushSpaces()
+
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '\t':
+			b.WriteString(`<text:tab/>`)
+		case r == '\n':
+			b.WriteString(`<text:line-break/>`)
+		case r < 0x20 || r == 0xFFFE || r == 0xFFFF:
+			b.WriteRune(utf8.RuneError)
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	flushSpaces()
+
+	b.WriteString(`</text:p>`)
+}
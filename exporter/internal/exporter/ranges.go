@@ -0,0 +1,214 @@
+package exporter
+
+import (
+	"context"
+	"math"
+	"slices"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yson"
+	"go.ytsaurus.tech/yt/go/yt"
+)
+
+// hasKeyLimits checks whether any of the ranges is bounded by a key.
+func hasKeyLimits(ranges []ypath.Range) bool {
+	for _, rng := range ranges {
+		for _, l := range []*ypath.ReadLimit{rng.Lower, rng.Upper, rng.Exact} {
+			if l != nil && l.Key != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// normalizeKeys checks that key limits of the ranges match key columns of the sorted table
+// and casts key values to the types of the corresponding key columns.
+//
+// Casting is required since values of different types are never equal in yt,
+// e.g. key (10) parsed as int64 does not select anything in uint64 column.
+func normalizeKeys(ranges []ypath.Range, s *schema.Schema) error {
+	if !hasKeyLimits(ranges) {
+		return nil
+	}
+
+	var keyColumns []schema.Column
+	for _, col := range s.Columns {
+		if col.SortOrder != schema.SortNone {
+			keyColumns = append(keyColumns, col)
+		}
+	}
+
+	if len(keyColumns) == 0 {
+		return xerrors.Errorf("key ranges are supported only for sorted tables")
+	}
+
+	for _, rng := range ranges {
+		for _, l := range []*ypath.ReadLimit{rng.Lower, rng.Upper, rng.Exact} {
+			if l == nil || l.Key == nil {
+				continue
+			}
+
+			if len(l.Key) > len(keyColumns) {
+				return xerrors.Errorf("key %v is longer than table key of %d columns", l.Key, len(keyColumns))
+			}
+
+			for i, v := range l.Key {
+				converted, err := convertKeyValue(v, keyColumns[i].Type)
+				if err != nil {
+					return xerrors.Errorf("invalid key value for column %q: %w", keyColumns[i].Name, err)
+				}
+				l.Key[i] = converted
+			}
+		}
+	}
+
+	return nil
+}
+
+// convertKeyValue casts value parsed from ypath to the type of the key column.
+func convertKeyValue(v any, typ schema.Type) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch typ {
+	case schema.TypeInt8, schema.TypeInt16, schema.TypeInt32, schema.TypeInt64, schema.TypeInterval:
+		switch v := v.(type) {
+		case int64:
+			return v, nil
+		case uint64:
+			if v > math.MaxInt64 {
+				return nil, xerrors.Errorf("value %d overflows %s", v, typ)
+			}
+			return int64(v), nil
+		}
+	case schema.TypeUint8, schema.TypeUint16, schema.TypeUint32, schema.TypeUint64,
+		schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
+		switch v := v.(type) {
+		case uint64:
+			return v, nil
+		case int64:
+			if v < 0 {
+				return nil, xerrors.Errorf("negative value %d for %s", v, typ)
+			}
+			return uint64(v), nil
+		}
+	case schema.TypeFloat64, schema.TypeFloat32:
+		switch v := v.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case uint64:
+			return float64(v), nil
+		}
+	case schema.TypeBytes, schema.TypeString:
+		switch v := v.(type) {
+		case []byte:
+			return v, nil
+		case string:
+			return []byte(v), nil
+		}
+	case schema.TypeBoolean:
+		if v, ok := v.(bool); ok {
+			return v, nil
+		}
+	default:
+		return v, nil
+	}
+
+	return nil, xerrors.Errorf("value %v of type %T does not match %s", v, v, typ)
+}
+
+// countRangeRows returns the number of rows selected by each of the ranges of the request.
+//
+// Row index limits are resolved using table row count. Key limits are resolved to row indexes by yt
+// without reading any rows, see resolveKeyLimit. If yt does not report the index, the rows of the range
+// are checked against the limits while they are converted.
+func countRangeRows(ctx context.Context, tx yt.Tx, path ypath.Path, ranges []ypath.Range) ([]int64, error) {
+	rowCount, err := readRowCount(ctx, tx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make([]int64, len(ranges))
+	for i, rng := range ranges {
+		var lower, upper *ypath.ReadLimit
+		if rng.Exact != nil {
+			if rng.Exact.Key == nil {
+				if rng.Exact.RowIndex != nil && *rng.Exact.RowIndex < rowCount {
+					counts[i] = 1
+				}
+				continue
+			}
+			next := ypath.Key(keySuccessor(rng.Exact.Key)...)
+			lower, upper = rng.Exact, &next
+		} else {
+			lower, upper = rng.Lower, rng.Upper
+		}
+
+		from, ok, err := resolveLimit(ctx, tx, path, lower, 0)
+		if err != nil || !ok {
+			return nil, err
+		}
+		to, ok, err := resolveLimit(ctx, tx, path, upper, rowCount)
+		if err != nil || !ok {
+			return nil, err
+		}
+		counts[i] = max(0, min(to, rowCount)-min(from, rowCount))
+	}
+
+	return counts, nil
+}
+
+// resolveLimit returns the row index of the read limit or def if the limit is not set.
+//
+// The second return value is false if yt does not report the row index of the key limit.
+func resolveLimit(ctx context.Context, tx yt.Tx, path ypath.Path, l *ypath.ReadLimit, def int64) (int64, bool, error) {
+	switch {
+	case l == nil:
+		return def, true, nil
+	case l.Key == nil && l.RowIndex != nil:
+		return *l.RowIndex, true, nil
+	case l.Key == nil:
+		return def, true, nil
+	}
+	return resolveKeyLimit(ctx, tx, path, *l)
+}
+
+// resolveKeyLimit returns the index of the first row of the sorted table whose key is not less than the key limit.
+//
+// The table is read with start_row_index_only, so yt resolves the limit without sending any rows.
+func resolveKeyLimit(ctx context.Context, tx yt.Tx, path ypath.Path, l ypath.ReadLimit) (int64, bool, error) {
+	p := ypath.NewRich(string(path)).AddRange(ypath.StartingFrom(ypath.Key(l.Key...)))
+
+	startRowIndexOnly := true
+	r, err := tx.ReadTable(ctx, p, &yt.ReadTableOptions{StartRowIndexOnly: &startRowIndexOnly})
+	if err != nil {
+		return 0, false, xerrors.Errorf("error resolving key limit %v: %w", l.Key, err)
+	}
+	defer func() { _ = r.Close() }()
+
+	index, ok := yt.StartRowIndex(r)
+	return index, ok, nil
+}
+
+// maxSentinel is a yt key value greater than any other value.
+var maxSentinel = &yson.ValueWithAttrs{Attrs: map[string]any{"type": "max"}}
+
+// keySuccessor returns the least key greater than all keys starting with the given one.
+func keySuccessor(key []any) []any {
+	return append(slices.Clone(key), maxSentinel)
+}
+
+// readRowCount returns the value of @row_count table attribute.
+func readRowCount(ctx context.Context, yc yt.CypressClient, path ypath.Path) (int64, error) {
+	var n int64
+	if err := yc.GetNode(ctx, path.Attr("row_count"), &n, nil); err != nil {
+		return 0, xerrors.Errorf("error reading table row count: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,118 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+func TestNormalizeKeys(t *testing.T) {
+	sorted := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeUint64, SortOrder: schema.SortAscending},
+		{Name: "name", Type: schema.TypeString, SortOrder: schema.SortAscending},
+		{Name: "value", Type: schema.TypeFloat64},
+	}}
+
+	for _, tc := range []struct {
+		name     string
+		ranges   []ypath.Range
+		schema   *schema.Schema
+		expected []ypath.Range
+		isError  bool
+	}{
+		{
+			name:     "row_indices",
+			ranges:   []ypath.Range{ypath.Interval(ypath.RowIndex(1), ypath.RowIndex(2))},
+			schema:   &schema.Schema{},
+			expected: []ypath.Range{ypath.Interval(ypath.RowIndex(1), ypath.RowIndex(2))},
+		},
+		{
+			name:     "cast",
+			ranges:   []ypath.Range{ypath.Interval(ypath.Key(int64(1), "a"), ypath.Key(uint64(10)))},
+			schema:   sorted,
+			expected: []ypath.Range{ypath.Interval(ypath.Key(uint64(1), []byte("a")), ypath.Key(uint64(10)))},
+		},
+		{
+			name:     "exact_null",
+			ranges:   []ypath.Range{ypath.Exact(ypath.Key(any(nil)))},
+			schema:   sorted,
+			expected: []ypath.Range{ypath.Exact(ypath.Key(any(nil)))},
+		},
+		{
+			name:    "unsorted",
+			ranges:  []ypath.Range{ypath.Exact(ypath.Key(int64(1)))},
+			schema:  &schema.Schema{Columns: []schema.Column{{Name: "id", Type: schema.TypeUint64}}},
+			isError: true,
+		},
+		{
+			name:    "too_long",
+			ranges:  []ypath.Range{ypath.Exact(ypath.Key(int64(1), "a", 1.5))},
+			schema:  sorted,
+			isError: true,
+		},
+		{
+			name:    "negative_unsigned",
+			ranges:  []ypath.Range{ypath.StartingFrom(ypath.Key(int64(-1)))},
+			schema:  sorted,
+			isError: true,
+		},
+		{
+			name:    "type_mismatch",
+			ranges:  []ypath.Range{ypath.UpTo(ypath.Key("a"))},
+			schema:  sorted,
+			isError: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := normalizeKeys(tc.ranges, tc.schema)
+			if tc.isError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, tc.ranges)
+			}
+		})
+	}
+}
+
+type keyedRow struct {
+	Key   string `yson:"key,key"`
+	Value int64  `yson:"value"`
+}
+
+func TestCountRangeRows(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	path := env.TmpPath()
+	_, err := yt.CreateTable(env.Ctx, env.YT, path, yt.WithSchema(schema.MustInfer(&keyedRow{})))
+	require.NoError(t, err)
+	require.NoError(t, env.UploadSlice(path, []keyedRow{
+		{Key: "a", Value: 1}, {Key: "b", Value: 2}, {Key: "b", Value: 3}, {Key: "c", Value: 4}, {Key: "e", Value: 5},
+	}))
+
+	tx, err := env.YT.BeginTx(env.Ctx, nil)
+	require.NoError(t, err)
+	defer func() { _ = tx.Abort() }()
+
+	rowIndex := func(i int64) ypath.ReadLimit { return ypath.RowIndex(i) }
+	key := func(k string) ypath.ReadLimit { return ypath.Key(k) }
+
+	counts, err := countRangeRows(env.Ctx, tx, path, []ypath.Range{
+		ypath.Interval(rowIndex(1), rowIndex(3)),
+		ypath.Interval(key("b"), key("d")),
+		ypath.StartingFrom(key("c")),
+		ypath.UpTo(key("b")),
+		ypath.Exact(key("b")),
+		ypath.Exact(key("d")),
+		ypath.Interval(key("a"), rowIndex(2)),
+		ypath.Interval(key("x"), key("z")),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []int64{2, 3, 2, 1, 2, 0, 2, 0}, counts)
+}
@@ -0,0 +1,148 @@
+package exporter
+
+import (
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yson"
+)
+
+// Profile is a preset of export settings for a particular use of the file.
+type Profile string
+
+const (
+	// ProfileRoundtrip makes a file that the uploader converts back into the identical table.
+	//
+	// Values are written without loss or the export fails, and the schema of the exported columns
+	// is stored in the hidden sheet SchemaSheetName.
+	ProfileRoundtrip Profile = "roundtrip"
+)
+
+// Profiles lists all supported export profiles.
+var Profiles = []Profile{ProfileRoundtrip}
+
+// SchemaSheetName is the name of the hidden sheet with the table schema written by roundtrip profile.
+//
+// The schema is stored as yson text split over the cells of the first column.
+const SchemaSheetName = "_schema"
+
+// checkProfile checks that the settings of the request do not contradict its profile and fills in the rest.
+func (r *ExportRequest) checkProfile(s *schema.Schema) error {
+	if r.Profile == "" {
+		return nil
+	}
+	if r.Profile != ProfileRoundtrip {
+		return xerrors.Errorf("unexpected profile: %q; expected one of %q", r.Profile, Profiles)
+	}
+
+	switch {
+	case r.Format.orDefault() != FormatXLSX:
+		return xerrors.Errorf("roundtrip profile supports only %q format", FormatXLSX)
+	case r.MultiSheet || r.SheetPerRange:
+		return xerrors.Errorf("roundtrip profile requires the rows to fit into a single sheet")
+	case len(r.ColumnSpecs) > 0:
+		return xerrors.Errorf("roundtrip profile can not be used along with column spec")
+	case r.Header.Mode != "" && r.Header.Mode != HeaderNamesAndTypes:
+		return xerrors.Errorf("roundtrip profile requires header %q", HeaderNamesAndTypes)
+	case r.NumberPrecisionMode != "" && r.NumberPrecisionMode != NumberPrecisionModeString:
+		return xerrors.Errorf("roundtrip profile requires number precision mode %q", NumberPrecisionModeString)
+	case r.ComplexTypeFormat != "" && r.ComplexTypeFormat != ComplexTypeFormatYSON:
+		return xerrors.Errorf("roundtrip profile requires complex type format %q", ComplexTypeFormatYSON)
+	case len(s.Columns) == 0 || s.Strict != nil && !*s.Strict:
+		return xerrors.Errorf("roundtrip profile requires table with strict schema")
+	}
+
+	r.Header.Mode = HeaderNamesAndTypes
+	r.NumberPrecisionMode = NumberPrecisionModeString
+	r.ComplexTypeFormat = ComplexTypeFormatYSON
+	return nil
+}
+
+// makeRoundtripSchema returns the schema of the exported columns.
+//
+// Sort order is kept for the key columns up to the first one that is not exported,
+// and dropped altogether if the rows are reordered.
+func makeRoundtripSchema(s *schema.Schema, columns []string, reordered bool) *schema.Schema {
+	header := makeHeader(columns, s)
+
+	out := s.Copy()
+	out.Columns = make([]schema.Column, len(header))
+	for _, col := range header {
+		out.Columns[col.Index-1] = col.Column
+	}
+
+	// Key columns are the first columns of the schema, so the exported ones go first too.
+	keys := 0
+	for _, col := range s.Columns {
+		if col.SortOrder == schema.SortNone {
+			break
+		}
+		if _, ok := header[col.Name]; !ok {
+			out.UniqueKeys = false
+			break
+		}
+		keys++
+	}
+	if reordered {
+		keys = 0
+	}
+
+	for i := keys; i < len(out.Columns); i++ {
+		out.Columns[i].SortOrder = schema.SortNone
+	}
+	if keys == 0 {
+		out.UniqueKeys = false
+	}
+	return &out
+}
+
+// writeSchemaSheet writes the schema to the hidden sheet SchemaSheetName.
+func (b *workbook) writeSchemaSheet(s *schema.Schema) error {
+	data, err := yson.MarshalFormat(s, yson.FormatText)
+	if err != nil {
+		return xerrors.Errorf("error marshaling schema: %w", err)
+	}
+
+	var rows [][]excelize.Cell
+	for _, chunk := range splitText(string(data), maxExcelStrLen) {
+		rows = append(rows, []excelize.Cell{{Value: chunk}})
+	}
+	return b.writeHiddenSheet(SchemaSheetName, rows)
+}
+
+// splitText splits text into chunks of at most n bytes without breaking utf-8 characters.
+func splitText(s string, n int) []string {
+	var chunks []string
+	for len(s) > n {
+		i := n
+		for i > 0 && !utf8.RuneStart(s[i]) {
+			i--
+		}
+		chunks = append(chunks, s[:i])
+		s = s[i:]
+	}
+	return append(chunks, s)
+}
+
+// fitCellText cuts text to the max length of an excel cell.
+//
+// Lossless conversion fails instead, as well as on text that is not valid utf-8 and can not be stored in xml as is.
+func fitCellText[T string | []byte](data T, lossless bool) (T, error) {
+	if !lossless {
+		if len(data) > maxExcelStrLen {
+			data = data[:maxExcelStrLen]
+		}
+		return data, nil
+	}
+
+	if len(data) > maxExcelStrLen {
+		return data, xerrors.Errorf("value of %d bytes does not fit into excel cell; max is %d", len(data), maxExcelStrLen)
+	}
+	if !utf8.ValidString(string(data)) {
+		return data, xerrors.Errorf("value is not valid utf-8 text")
+	}
+	return data, nil
+}
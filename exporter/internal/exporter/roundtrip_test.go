@@ -0,0 +1,173 @@
+package exporter
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yson"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+const (
+	// roundtripTable is the table exported by TestConvert_roundtrip.
+	// The uploader tests upload roundtripFile and compare the result with the same table.
+	roundtripTable = "testdata/roundtrip.yson"
+	roundtripFile  = "testdata/roundtrip.xlsx"
+)
+
+func readRoundtripTable(t *testing.T) (*schema.Schema, []any) {
+	t.Helper()
+
+	data, err := os.ReadFile(roundtripTable)
+	require.NoError(t, err)
+
+	var table struct {
+		Schema schema.Schema    `yson:"schema"`
+		Rows   []map[string]any `yson:"rows"`
+	}
+	require.NoError(t, yson.Unmarshal(data, &table))
+
+	rows := make([]any, len(table.Rows))
+	for i, row := range table.Rows {
+		rows[i] = row
+	}
+	return &table.Schema, rows
+}
+
+func TestConvert_roundtrip(t *testing.T) {
+	s, rows := readRoundtripTable(t)
+	columns := getColumnNames(s.Columns)
+
+	opts := &ConvertOptions{
+		Columns:             columns,
+		Schema:              s,
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+		NumberPrecisionMode: NumberPrecisionModeString,
+		ComplexTypeFormat:   ComplexTypeFormatYSON,
+		Header:              HeaderOptions{Mode: HeaderNamesAndTypes},
+		Profile:             ProfileRoundtrip,
+		RoundtripSchema:     makeRoundtripSchema(s, columns, false),
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Convert(&buf, &rowsReader{rows: rows}, opts))
+
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(roundtripFile, buf.Bytes(), 0o644))
+	}
+	golden, err := os.ReadFile(roundtripFile)
+	require.NoError(t, err)
+	require.Equal(t, golden, buf.Bytes(), "run the test with -update to update %s", roundtripFile)
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	require.Equal(t, []string{SheetName, SchemaSheetName}, f.GetSheetList())
+
+	visible, err := f.GetSheetVisible(SchemaSheetName)
+	require.NoError(t, err)
+	require.False(t, visible)
+
+	cells, err := f.GetRows(SchemaSheetName)
+	require.NoError(t, err)
+	require.Len(t, cells, 1)
+
+	var stored schema.Schema
+	require.NoError(t, yson.Unmarshal([]byte(cells[0][0]), &stored))
+	require.Equal(t, s.Columns, stored.Columns)
+	require.True(t, stored.UniqueKeys)
+}
+
+func TestConvert_roundtripLossless(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "s", Type: schema.TypeString},
+		{Name: "a", Type: schema.TypeAny},
+		{Name: "l", ComplexType: schema.List{Item: schema.TypeString}},
+	}}
+
+	for _, row := range []map[string]any{
+		{"s": strings.Repeat("a", maxExcelStrLen+1)},
+		{"s": "\xff"},
+		{"a": strings.Repeat("a ", maxExcelStrLen/2)},
+		{"l": []any{strings.Repeat("a", maxExcelStrLen)}},
+	} {
+		opts := &ConvertOptions{
+			Columns:             []string{"s", "a", "l"},
+			Schema:              s,
+			ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+			NumberPrecisionMode: NumberPrecisionModeString,
+		}
+
+		var buf bytes.Buffer
+		require.NoError(t, Convert(&buf, &rowsReader{rows: []any{row}}, opts), "values are truncated by default")
+
+		opts.Profile = ProfileRoundtrip
+		buf.Reset()
+		require.Error(t, Convert(&buf, &rowsReader{rows: []any{row}}, opts))
+	}
+}
+
+func TestMakeRoundtripSchema(t *testing.T) {
+	s := schema.Schema{UniqueKeys: true, Columns: []schema.Column{
+		{Name: "k1", Type: schema.TypeInt64, SortOrder: schema.SortAscending},
+		{Name: "k2", Type: schema.TypeString, SortOrder: schema.SortDescending},
+		{Name: "v", Type: schema.TypeString},
+	}}
+
+	for _, tc := range []struct {
+		name       string
+		columns    []string
+		reordered  bool
+		sortOrders []schema.SortOrder
+		uniqueKeys bool
+	}{
+		{
+			name:       "all",
+			columns:    []string{"v", "k2", "k1"},
+			sortOrders: []schema.SortOrder{schema.SortAscending, schema.SortDescending, schema.SortNone},
+			uniqueKeys: true,
+		},
+		{
+			name:       "key_prefix",
+			columns:    []string{"k1", "v"},
+			sortOrders: []schema.SortOrder{schema.SortAscending, schema.SortNone},
+		},
+		{
+			name:       "no_first_key",
+			columns:    []string{"k2", "v"},
+			sortOrders: []schema.SortOrder{schema.SortNone, schema.SortNone},
+		},
+		{
+			name:       "reordered",
+			columns:    []string{"k1", "k2", "v"},
+			reordered:  true,
+			sortOrders: []schema.SortOrder{schema.SortNone, schema.SortNone, schema.SortNone},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := makeRoundtripSchema(&s, tc.columns, tc.reordered)
+
+			var sortOrders []schema.SortOrder
+			for _, col := range out.Columns {
+				sortOrders = append(sortOrders, col.SortOrder)
+			}
+			require.Equal(t, tc.sortOrders, sortOrders)
+			require.Equal(t, tc.uniqueKeys, out.UniqueKeys)
+		})
+	}
+
+	require.Equal(t, schema.SortAscending, s.Columns[0].SortOrder, "schema of the table is not changed")
+}
+
+func TestSplitText(t *testing.T) {
+	require.Equal(t, []string{""}, splitText("", 3))
+	require.Equal(t, []string{"abc", "de"}, splitText("abcde", 3))
+	require.Equal(t, []string{"aф", "фф"}, splitText("aффф", 4))
+}
@@ -0,0 +1,892 @@
+package exporter
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// RowQuery selects, orders and limits exported rows.
+//
+// Filter is a boolean expression over the columns of the table, e.g.
+//
+//	status = 'failed' and (ts >= '2024-01-01' or retries > 3) and name is not null
+//
+// Columns are compared with literals of their types: numbers, 'strings', true and false.
+// Values of date, datetime and timestamp columns can also be compared with dates as strings,
+// e.g. '2024-01-01' or '2024-01-01T10:00:00Z'. Supported operators are =, !=, <>, <, <=, >, >=,
+// in (...), not in (...), is null, is not null, and, or, not.
+// Column names that are not identifiers can be written in brackets, e.g. [user name].
+//
+// OrderBy is a comma separated list of columns with optional direction, e.g. ts desc, id.
+// Nulls are less than any other value.
+type RowQuery struct {
+	Filter  string `json:"filter,omitempty"`
+	OrderBy string `json:"order_by,omitempty"`
+	// Offset is the number of rows skipped after filtering and sorting.
+	Offset int64 `json:"offset,omitempty"`
+	// Limit is the max number of exported rows; zero means no limit.
+	Limit int64 `json:"limit,omitempty"`
+
+	filter  filterExpr
+	orderBy []orderKey
+}
+
+// ParseRowQuery parses filter and order by expressions.
+//
+// Expressions are checked against the table schema later, on export.
+func ParseRowQuery(filter, orderBy string, offset, limit int64) (*RowQuery, error) {
+	if offset < 0 {
+		return nil, xerrors.Errorf("offset cannot be negative; got %d", offset)
+	}
+	if limit < 0 {
+		return nil, xerrors.Errorf("limit cannot be negative; got %d", limit)
+	}
+
+	q := &RowQuery{Filter: filter, OrderBy: orderBy, Offset: offset, Limit: limit}
+
+	if strings.TrimSpace(filter) != "" {
+		p, err := newQueryParser(filter)
+		if err != nil {
+			return nil, xerrors.Errorf("error parsing filter: %w", err)
+		}
+		if q.filter, err = p.parseFilter(); err != nil {
+			return nil, xerrors.Errorf("error parsing filter: %w", err)
+		}
+	}
+
+	if strings.TrimSpace(orderBy) != "" {
+		p, err := newQueryParser(orderBy)
+		if err != nil {
+			return nil, xerrors.Errorf("error parsing order by: %w", err)
+		}
+		if q.orderBy, err = p.parseOrderBy(); err != nil {
+			return nil, xerrors.Errorf("error parsing order by: %w", err)
+		}
+	}
+
+	return q, nil
+}
+
+func (q *RowQuery) String() string {
+	var parts []string
+	if q.Filter != "" {
+		parts = append(parts, "where "+q.Filter)
+	}
+	if q.OrderBy != "" {
+		parts = append(parts, "order by "+q.OrderBy)
+	}
+	if q.Offset != 0 {
+		parts = append(parts, fmt.Sprintf("offset %d", q.Offset))
+	}
+	if q.Limit != 0 {
+		parts = append(parts, fmt.Sprintf("limit %d", q.Limit))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Columns returns names of the columns used by the query.
+func (q *RowQuery) Columns() []string {
+	var columns []string
+	add := func(name string) {
+		for _, c := range columns {
+			if c == name {
+				return
+			}
+		}
+		columns = append(columns, name)
+	}
+
+	if q.filter != nil {
+		q.filter.columns(add)
+	}
+	for _, k := range q.orderBy {
+		add(k.column)
+	}
+	return columns
+}
+
+// rowPredicate checks whether the row matches the filter.
+type rowPredicate func(row map[string]any) bool
+
+// compiledQuery is a query checked against the table schema.
+type compiledQuery struct {
+	filter  rowPredicate
+	orderBy []typedOrderKey
+	offset  int64
+	limit   int64
+}
+
+// compile checks types of the columns and literals and prepares the query for evaluation.
+func (q *RowQuery) compile(s *schema.Schema) (*compiledQuery, error) {
+	columns := make(map[string]schema.Column, len(s.Columns))
+	for _, c := range s.Columns {
+		columns[c.Name] = c
+	}
+
+	c := &compiledQuery{offset: q.Offset, limit: q.Limit}
+
+	if q.filter != nil {
+		filter, err := q.filter.compile(columns)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid filter: %w", err)
+		}
+		c.filter = filter
+	}
+
+	for _, k := range q.orderBy {
+		col, kind, err := lookupColumn(columns, k.column)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid order by: %w", err)
+		}
+		c.orderBy = append(c.orderBy, typedOrderKey{column: col.Name, kind: kind, desc: k.desc})
+	}
+
+	return c, nil
+}
+
+// compareRows compares rows by the order keys of the query.
+func (c *compiledQuery) compareRows(a, b map[string]any) int {
+	for _, k := range c.orderBy {
+		r := compareNullable(k.kind, a[k.column], b[k.column])
+		if k.desc {
+			r = -r
+		}
+		if r != 0 {
+			return r
+		}
+	}
+	return 0
+}
+
+// valueKind is a Go type of the decoded values of the column.
+type valueKind int
+
+const (
+	kindInt valueKind = iota
+	kindUint
+	kindFloat
+	kindString
+	kindBool
+)
+
+// columnValueKind returns kind of the values of the column that can be compared.
+//
+// Optional and tagged types of type_v3 schema are compared as their items.
+func columnValueKind(col schema.Column) (schema.Type, valueKind, error) {
+	t := schema.ComplexType(col.Type)
+	if col.ComplexType != nil {
+		t = unwrapTagged(col.ComplexType)
+		if o, ok := t.(schema.Optional); ok {
+			t = unwrapTagged(o.Item)
+		}
+	}
+
+	typ, ok := t.(schema.Type)
+	if !ok {
+		return "", 0, xerrors.Errorf("column %q of type %s can not be compared", col.Name, describeColumnType(col))
+	}
+
+	switch typ {
+	case schema.TypeInt8, schema.TypeInt16, schema.TypeInt32, schema.TypeInt64, schema.TypeInterval,
+		typeDate32, typeDatetime64, typeTimestamp64, typeInterval64:
+		return typ, kindInt, nil
+	case schema.TypeUint8, schema.TypeUint16, schema.TypeUint32, schema.TypeUint64,
+		schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
+		return typ, kindUint, nil
+	case schema.TypeFloat32, schema.TypeFloat64:
+		return typ, kindFloat, nil
+	case schema.TypeBytes, schema.TypeString:
+		return typ, kindString, nil
+	case schema.TypeBoolean:
+		return typ, kindBool, nil
+	default:
+		return "", 0, xerrors.Errorf("column %q of type %s can not be compared", col.Name, typ)
+	}
+}
+
+func lookupColumn(columns map[string]schema.Column, name string) (schema.Column, valueKind, error) {
+	col, ok := columns[name]
+	if !ok {
+		return schema.Column{}, 0, xerrors.Errorf("column %q not found in the table schema", name)
+	}
+	_, kind, err := columnValueKind(col)
+	return col, kind, err
+}
+
+// compareValues compares two non-null values of the same kind.
+//
+// The second return value is false if any of the values has unexpected type.
+func compareValues(kind valueKind, a, b any) (int, bool) {
+	switch kind {
+	case kindInt:
+		x, ok1 := asInt64(a)
+		y, ok2 := asInt64(b)
+		return cmp.Compare(x, y), ok1 && ok2
+	case kindUint:
+		x, ok1 := asUint64(a)
+		y, ok2 := asUint64(b)
+		return cmp.Compare(x, y), ok1 && ok2
+	case kindFloat:
+		x, ok1 := a.(float64)
+		y, ok2 := b.(float64)
+		return cmp.Compare(x, y), ok1 && ok2
+	case kindString:
+		x, ok1 := a.(string)
+		y, ok2 := b.(string)
+		return strings.Compare(x, y), ok1 && ok2
+	case kindBool:
+		x, ok1 := a.(bool)
+		y, ok2 := b.(bool)
+		switch {
+		case x == y:
+			return 0, ok1 && ok2
+		case !x:
+			return -1, ok1 && ok2
+		default:
+			return 1, ok1 && ok2
+		}
+	}
+	return 0, false
+}
+
+// asInt64 returns integer value as int64; unsigned values are accepted as long as they fit.
+func asInt64(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case uint64:
+		return int64(v), v <= math.MaxInt64
+	}
+	return 0, false
+}
+
+// asUint64 returns integer value as uint64; signed values are accepted as long as they are not negative.
+func asUint64(v any) (uint64, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return v, true
+	case int64:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+// compareNullable compares values for sorting; null is less than any other value.
+func compareNullable(kind valueKind, a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	r, _ := compareValues(kind, a, b)
+	return r
+}
+
+type orderKey struct {
+	column string
+	desc   bool
+}
+
+type typedOrderKey struct {
+	column string
+	kind   valueKind
+	desc   bool
+}
+
+// filterExpr is a node of the parsed filter expression.
+type filterExpr interface {
+	compile(columns map[string]schema.Column) (rowPredicate, error)
+	columns(add func(name string))
+}
+
+type logicalExpr struct {
+	and         bool
+	left, right filterExpr
+}
+
+func (e *logicalExpr) compile(columns map[string]schema.Column) (rowPredicate, error) {
+	left, err := e.left.compile(columns)
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.right.compile(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.and {
+		return func(row map[string]any) bool { return left(row) && right(row) }, nil
+	}
+	return func(row map[string]any) bool { return left(row) || right(row) }, nil
+}
+
+func (e *logicalExpr) columns(add func(name string)) {
+	e.left.columns(add)
+	e.right.columns(add)
+}
+
+type notExpr struct {
+	expr filterExpr
+}
+
+func (e *notExpr) compile(columns map[string]schema.Column) (rowPredicate, error) {
+	p, err := e.expr.compile(columns)
+	if err != nil {
+		return nil, err
+	}
+	return func(row map[string]any) bool { return !p(row) }, nil
+}
+
+func (e *notExpr) columns(add func(name string)) {
+	e.expr.columns(add)
+}
+
+type compareExpr struct {
+	column string
+	op     string
+	value  literal
+}
+
+func (e *compareExpr) compile(columns map[string]schema.Column) (rowPredicate, error) {
+	col, kind, err := lookupColumn(columns, e.column)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := e.value.convert(col)
+	if err != nil {
+		return nil, err
+	}
+
+	var check func(r int) bool
+	switch e.op {
+	case "=":
+		check = func(r int) bool { return r == 0 }
+	case "!=":
+		check = func(r int) bool { return r != 0 }
+	case "<":
+		check = func(r int) bool { return r < 0 }
+	case "<=":
+		check = func(r int) bool { return r <= 0 }
+	case ">":
+		check = func(r int) bool { return r > 0 }
+	case ">=":
+		check = func(r int) bool { return r >= 0 }
+	default:
+		return nil, xerrors.Errorf("unknown operator %q", e.op)
+	}
+
+	return func(row map[string]any) bool {
+		v := row[e.column]
+		if v == nil {
+			return false
+		}
+		r, ok := compareValues(kind, v, value)
+		return ok && check(r)
+	}, nil
+}
+
+func (e *compareExpr) columns(add func(name string)) {
+	add(e.column)
+}
+
+type inExpr struct {
+	column string
+	values []literal
+	not    bool
+}
+
+func (e *inExpr) compile(columns map[string]schema.Column) (rowPredicate, error) {
+	col, kind, err := lookupColumn(columns, e.column)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]any, len(e.values))
+	for i, l := range e.values {
+		if values[i], err = l.convert(col); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(row map[string]any) bool {
+		v := row[e.column]
+		if v == nil {
+			return false
+		}
+		for _, value := range values {
+			if r, ok := compareValues(kind, v, value); ok && r == 0 {
+				return !e.not
+			}
+		}
+		return e.not
+	}, nil
+}
+
+func (e *inExpr) columns(add func(name string)) {
+	add(e.column)
+}
+
+type isNullExpr struct {
+	column string
+	not    bool
+}
+
+func (e *isNullExpr) compile(columns map[string]schema.Column) (rowPredicate, error) {
+	if _, ok := columns[e.column]; !ok {
+		return nil, xerrors.Errorf("column %q not found in the table schema", e.column)
+	}
+	return func(row map[string]any) bool {
+		return (row[e.column] == nil) != e.not
+	}, nil
+}
+
+func (e *isNullExpr) columns(add func(name string)) {
+	add(e.column)
+}
+
+type literalKind int
+
+const (
+	literalString literalKind = iota
+	literalNumber
+	literalBool
+)
+
+type literal struct {
+	kind literalKind
+	text string
+}
+
+func (l literal) String() string {
+	if l.kind == literalString {
+		return strconv.Quote(l.text)
+	}
+	return l.text
+}
+
+// convert returns the value of the literal as it is decoded from the column of the table.
+func (l literal) convert(col schema.Column) (any, error) {
+	typ, kind, err := columnValueKind(col)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatch := func(err error) error {
+		if err != nil {
+			return xerrors.Errorf("value %s does not match type %s of column %q: %w", l, typ, col.Name, err)
+		}
+		return xerrors.Errorf("value %s does not match type %s of column %q", l, typ, col.Name)
+	}
+
+	if l.kind == literalString && isDateType(typ) {
+		v, err := parseDateLiteral(l.text, typ)
+		if err != nil {
+			return nil, mismatch(err)
+		}
+		if kind == kindUint {
+			if v < 0 {
+				return nil, mismatch(nil)
+			}
+			return uint64(v), nil
+		}
+		return v, nil
+	}
+
+	switch {
+	case kind == kindString && l.kind == literalString:
+		return l.text, nil
+	case kind == kindBool && l.kind == literalBool:
+		return l.text == "true", nil
+	case kind == kindInt && l.kind == literalNumber:
+		v, err := strconv.ParseInt(l.text, 10, 64)
+		if err != nil {
+			return nil, mismatch(err)
+		}
+		return v, nil
+	case kind == kindUint && l.kind == literalNumber:
+		v, err := strconv.ParseUint(l.text, 10, 64)
+		if err != nil {
+			return nil, mismatch(err)
+		}
+		return v, nil
+	case kind == kindFloat && l.kind == literalNumber:
+		v, err := strconv.ParseFloat(l.text, 64)
+		if err != nil {
+			return nil, mismatch(err)
+		}
+		return v, nil
+	}
+	return nil, mismatch(nil)
+}
+
+func isDateType(t schema.Type) bool {
+	switch t {
+	case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp, typeDate32, typeDatetime64, typeTimestamp64:
+		return true
+	}
+	return false
+}
+
+// parseDateLiteral parses date or time and returns it in units of the type, e.g. days for date.
+func parseDateLiteral(s string, t schema.Type) (int64, error) {
+	var tm time.Time
+	var err error
+	if len(s) == len(time.DateOnly) {
+		tm, err = time.Parse(time.DateOnly, s)
+	} else {
+		tm, err = time.Parse(time.RFC3339Nano, s)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch t {
+	case schema.TypeDate, typeDate32:
+		days := tm.Unix() / 86400
+		if tm.Unix() < 0 && tm.Unix()%86400 != 0 {
+			days--
+		}
+		return days, nil
+	case schema.TypeDatetime, typeDatetime64:
+		return tm.Unix(), nil
+	default:
+		return tm.UnixMicro(), nil
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	// quoted is true for identifiers in brackets that are never keywords.
+	quoted bool
+	pos    int
+}
+
+// isKeyword checks whether the token is a given case-insensitive keyword.
+func (t token) isKeyword(kw string) bool {
+	return t.kind == tokenIdent && !t.quoted && strings.EqualFold(t.text, kw)
+}
+
+func tokenize(s string) ([]token, error) {
+	var tokens []token
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')' || r == ',':
+			tokens = append(tokens, token{kind: tokenPunct, text: string(r), pos: i})
+			i++
+		case r == '=' || r == '!' || r == '<' || r == '>':
+			start := i
+			op := string(r)
+			i++
+			if i < len(runes) && (runes[i] == '=' || r == '<' && runes[i] == '>') {
+				op += string(runes[i])
+				i++
+			}
+			switch op {
+			case "!":
+				return nil, xerrors.Errorf("unexpected %q at position %d", r, start)
+			case "==":
+				op = "="
+			case "<>":
+				op = "!="
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: op, pos: start})
+		case r == '\'' || r == '"':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, xerrors.Errorf("unterminated string at position %d", start)
+				}
+				c := runes[i]
+				if c == '\\' && i+1 < len(runes) {
+					b.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				i++
+				if c == r {
+					break
+				}
+				b.WriteRune(c)
+			}
+			tokens = append(tokens, token{kind: tokenString, text: b.String(), pos: start})
+		case r == '[':
+			start := i
+			end := i + 1
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, xerrors.Errorf("unterminated column name at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[i+1 : end]), quoted: true, pos: start})
+			i = end + 1
+		case r == '-' || r == '+' || r == '.' || unicode.IsDigit(r):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE", runes[i]) ||
+				(runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E')) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), pos: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || runes[i] == '/' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:i]), pos: start})
+		default:
+			return nil, xerrors.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// queryParser is a recursive descent parser of filter and order by expressions.
+type queryParser struct {
+	tokens []token
+	next   int
+}
+
+func newQueryParser(s string) (*queryParser, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	return &queryParser{tokens: tokens}, nil
+}
+
+func (p *queryParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *queryParser) advance() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEOF {
+		p.next++
+	}
+	return t
+}
+
+func (p *queryParser) unexpected(t token) error {
+	if t.kind == tokenEOF {
+		return xerrors.Errorf("unexpected end of expression")
+	}
+	return xerrors.Errorf("unexpected %q at position %d", t.text, t.pos)
+}
+
+func (p *queryParser) expectPunct(s string) error {
+	if t := p.advance(); t.kind != tokenPunct || t.text != s {
+		return p.unexpected(t)
+	}
+	return nil
+}
+
+// parseFilter parses the whole filter expression:
+//
+//	or      = and { "or" and }
+//	and     = not { "and" not }
+//	not     = "not" not | primary
+//	primary = "(" or ")" | column op literal | column ["not"] "in" "(" literal { "," literal } ")"
+//	        | column "is" ["not"] "null"
+func (p *queryParser) parseFilter() (filterExpr, error) {
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t)
+	}
+	return e, nil
+}
+
+func (p *queryParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("or") {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("and") {
+		p.advance()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalExpr{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (filterExpr, error) {
+	if p.peek().isKeyword("not") {
+		p.advance()
+		e, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{expr: e}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (filterExpr, error) {
+	t := p.advance()
+	if t.kind == tokenPunct && t.text == "(" {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(")"); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+
+	if t.kind != tokenIdent {
+		return nil, p.unexpected(t)
+	}
+	column := t.text
+
+	next := p.advance()
+	switch {
+	case next.kind == tokenOperator:
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		return &compareExpr{column: column, op: next.text, value: value}, nil
+	case next.isKeyword("is"):
+		not := false
+		if p.peek().isKeyword("not") {
+			p.advance()
+			not = true
+		}
+		if t := p.advance(); !t.isKeyword("null") {
+			return nil, p.unexpected(t)
+		}
+		return &isNullExpr{column: column, not: not}, nil
+	case next.isKeyword("in"), next.isKeyword("not") && p.peek().isKeyword("in"):
+		not := next.isKeyword("not")
+		if not {
+			p.advance()
+		}
+		values, err := p.parseLiteralList()
+		if err != nil {
+			return nil, err
+		}
+		return &inExpr{column: column, values: values, not: not}, nil
+	default:
+		return nil, p.unexpected(next)
+	}
+}
+
+func (p *queryParser) parseLiteralList() ([]literal, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+
+	var values []literal
+	for {
+		l, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, l)
+
+		t := p.advance()
+		if t.kind == tokenPunct && t.text == ")" {
+			return values, nil
+		}
+		if t.kind != tokenPunct || t.text != "," {
+			return nil, p.unexpected(t)
+		}
+	}
+}
+
+func (p *queryParser) parseLiteral() (literal, error) {
+	t := p.advance()
+	switch {
+	case t.kind == tokenString:
+		return literal{kind: literalString, text: t.text}, nil
+	case t.kind == tokenNumber:
+		return literal{kind: literalNumber, text: t.text}, nil
+	case t.isKeyword("true"), t.isKeyword("false"):
+		return literal{kind: literalBool, text: strings.ToLower(t.text)}, nil
+	}
+	return literal{}, p.unexpected(t)
+}
+
+// parseOrderBy parses comma separated list of columns with optional asc or desc.
+func (p *queryParser) parseOrderBy() ([]orderKey, error) {
+	var keys []orderKey
+	for {
+		t := p.advance()
+		if t.kind != tokenIdent {
+			return nil, p.unexpected(t)
+		}
+		k := orderKey{column: t.text}
+
+		switch {
+		case p.peek().isKeyword("asc"):
+			p.advance()
+		case p.peek().isKeyword("desc"):
+			p.advance()
+			k.desc = true
+		}
+		keys = append(keys, k)
+
+		t = p.advance()
+		if t.kind == tokenEOF {
+			return keys, nil
+		}
+		if t.kind != tokenPunct || t.text != "," {
+			return nil, p.unexpected(t)
+		}
+	}
+}
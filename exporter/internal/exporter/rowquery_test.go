@@ -0,0 +1,246 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+var rowQuerySchema = &schema.Schema{Columns: []schema.Column{
+	{Name: "id", Type: schema.TypeInt64},
+	{Name: "name", Type: schema.TypeString},
+	{Name: "score", Type: schema.TypeFloat64},
+	{Name: "ok", Type: schema.TypeBoolean},
+	{Name: "day", Type: schema.TypeDate},
+	{Name: "user name", ComplexType: schema.Optional{Item: schema.TypeString}},
+	{Name: "tags", ComplexType: schema.List{Item: schema.TypeString}},
+}}
+
+func TestParseRowQuery(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		filter  string
+		orderBy string
+		offset  int64
+		limit   int64
+		columns []string
+		isError bool
+	}{
+		{
+			name:    "comparisons",
+			filter:  `id >= 10 and (name = 'a' or name <> "b") and not ok = false`,
+			columns: []string{"id", "name", "ok"},
+		},
+		{
+			name:    "in_and_null",
+			filter:  `id not in (1, 2, 3) and [user name] is not null or score is null`,
+			orderBy: "score desc, id",
+			columns: []string{"id", "user name", "score"},
+		},
+		{
+			name:    "keywords_case",
+			filter:  `id IN (1) AND name IS NULL`,
+			orderBy: "id DESC",
+			columns: []string{"id", "name"},
+		},
+		{
+			name:    "only_limit",
+			offset:  1,
+			limit:   10,
+			columns: nil,
+		},
+		{name: "unterminated_string", filter: `name = 'a`, isError: true},
+		{name: "missing_value", filter: `id =`, isError: true},
+		{name: "missing_paren", filter: `(id = 1`, isError: true},
+		{name: "trailing", filter: `id = 1 2`, isError: true},
+		{name: "bad_operator", filter: `id ! 1`, isError: true},
+		{name: "column_value", filter: `id = name`, isError: true},
+		{name: "bad_order", orderBy: "id desc desc", isError: true},
+		{name: "negative_offset", offset: -1, isError: true},
+		{name: "negative_limit", limit: -1, isError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := ParseRowQuery(tc.filter, tc.orderBy, tc.offset, tc.limit)
+			if tc.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.columns, q.Columns())
+		})
+	}
+}
+
+func TestRowQuery_compile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		filter  string
+		orderBy string
+		isError bool
+	}{
+		{name: "typed", filter: `id > -1 and score < 1.5e3 and ok = true and name in ('a', 'b')`},
+		{name: "date", filter: `day >= '2024-01-01' and day < '2024-02-01T00:00:00Z'`},
+		{name: "optional", filter: `[user name] = 'a'`, orderBy: "[user name]"},
+		{name: "container_null", filter: `tags is not null`},
+		{name: "unknown_column", filter: `missing = 1`, isError: true},
+		{name: "string_for_int", filter: `id = '1'`, isError: true},
+		{name: "float_for_int", filter: `id = 1.5`, isError: true},
+		{name: "number_for_string", filter: `name = 1`, isError: true},
+		{name: "bad_date", filter: `day = '2024-13-01'`, isError: true},
+		{name: "container", filter: `tags = 'a'`, isError: true},
+		{name: "order_container", orderBy: "tags", isError: true},
+		{name: "order_unknown", orderBy: "missing", isError: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := ParseRowQuery(tc.filter, tc.orderBy, 0, 0)
+			require.NoError(t, err)
+
+			_, err = q.compile(rowQuerySchema)
+			if tc.isError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestQueryReader(t *testing.T) {
+	rows := []any{
+		map[string]any{"id": 1, "name": "b", "score": 2.5, "day": 19723},
+		map[string]any{"id": 2, "name": "a", "day": 19724},
+		map[string]any{"id": 3, "name": "c", "score": 1.0, "day": 19725},
+		map[string]any{"id": 4, "name": "a", "score": 0.5},
+		map[string]any{"id": 5, "name": "b", "score": 2.5, "day": 19726},
+	}
+
+	for _, tc := range []struct {
+		name        string
+		filter      string
+		orderBy     string
+		offset      int64
+		limit       int64
+		hidden      []string
+		rowLimit    int64
+		memoryLimit int
+		expected    []int64
+		isError     bool
+	}{
+		{
+			name:     "filter",
+			filter:   `name = 'a' or score > 2`,
+			expected: []int64{1, 2, 4, 5},
+		},
+		{
+			name:     "nulls_do_not_match",
+			filter:   `score < 10 and day >= '2024-01-02'`,
+			expected: []int64{3, 5},
+		},
+		{
+			name:     "sort",
+			orderBy:  "score desc, name",
+			expected: []int64{1, 5, 3, 4, 2},
+		},
+		{
+			name:     "sort_nulls_first",
+			orderBy:  "score",
+			expected: []int64{2, 4, 3, 1, 5},
+		},
+		{
+			name:     "offset_limit",
+			orderBy:  "id desc",
+			offset:   1,
+			limit:    2,
+			expected: []int64{4, 3},
+		},
+		{
+			name:     "top_stable",
+			orderBy:  "name",
+			limit:    3,
+			expected: []int64{2, 4, 1},
+		},
+		{
+			name:     "top_offset",
+			orderBy:  "score desc",
+			offset:   1,
+			limit:    2,
+			expected: []int64{5, 3},
+		},
+		{
+			name:     "top_over_row_limit",
+			orderBy:  "id desc",
+			limit:    10,
+			rowLimit: 2,
+			expected: []int64{5, 4, 3},
+		},
+		{
+			name:     "filter_limit",
+			filter:   `id != 1`,
+			limit:    2,
+			expected: []int64{2, 3},
+		},
+		{
+			name:        "spill",
+			filter:      `id > 1`,
+			orderBy:     "name desc, score",
+			memoryLimit: 1,
+			expected:    []int64{3, 5, 2, 4},
+		},
+		{
+			name:     "hidden",
+			orderBy:  "name",
+			hidden:   []string{"name"},
+			expected: []int64{2, 4, 1, 5, 3},
+		},
+		{
+			name:     "too_many_rows",
+			orderBy:  "id",
+			rowLimit: 3,
+			isError:  true,
+		},
+		{
+			name:     "too_many_rows_after_offset",
+			orderBy:  "id",
+			offset:   2,
+			rowLimit: 3,
+			expected: []int64{3, 4, 5},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q, err := ParseRowQuery(tc.filter, tc.orderBy, tc.offset, tc.limit)
+			require.NoError(t, err)
+			compiled, err := q.compile(rowQuerySchema)
+			require.NoError(t, err)
+
+			rowLimit := int64(MaxRowCount)
+			if tc.rowLimit != 0 {
+				rowLimit = tc.rowLimit
+			}
+			r := newQueryReader(&rowsReader{rows: rows}, compiled, tc.hidden, rowLimit)
+			if tc.memoryLimit != 0 {
+				r.memoryLimit = tc.memoryLimit
+			}
+			defer func() { _ = r.Close() }()
+
+			var ids []int64
+			for r.Next() {
+				var row map[string]any
+				require.NoError(t, r.Scan(&row))
+				for _, c := range tc.hidden {
+					require.NotContains(t, row, c)
+				}
+				ids = append(ids, row["id"].(int64))
+			}
+
+			if tc.isError {
+				require.Error(t, r.Err())
+				require.ErrorIs(t, r.Err(), ErrBadRequest)
+				return
+			}
+			require.NoError(t, r.Err())
+			require.Equal(t, tc.expected, ids)
+		})
+	}
+}
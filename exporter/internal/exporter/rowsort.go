@@ -0,0 +1,433 @@
+package exporter
+
+import (
+	"bufio"
+	"cmp"
+	"container/heap"
+	"encoding/binary"
+	"io"
+	"slices"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/yson"
+	"go.ytsaurus.tech/yt/go/yt"
+)
+
+// defaultSortMemoryLimit is the max size of rows sorted in memory before they are spilled to disk.
+const defaultSortMemoryLimit = 64 << 20
+
+// queryReader is a yt.TableReader that filters, sorts and limits rows of the underlying reader.
+type queryReader struct {
+	in yt.TableReader
+	q  *compiledQuery
+	// hidden are columns read only to evaluate the query that are removed from the scanned rows.
+	hidden []string
+	// rowLimit is the max number of rows the export can write, checked before sorting all the rows.
+	// It also bounds the number of rows kept to sort with a limit.
+	rowLimit    int64
+	memoryLimit int
+
+	sorted   *sortedRows
+	row      yson.RawValue
+	skipped  int64
+	returned int64
+	err      error
+}
+
+func newQueryReader(in yt.TableReader, q *compiledQuery, hidden []string, rowLimit int64) *queryReader {
+	return &queryReader{
+		in:          in,
+		q:           q,
+		hidden:      hidden,
+		rowLimit:    rowLimit,
+		memoryLimit: defaultSortMemoryLimit,
+	}
+}
+
+func (r *queryReader) Next() bool {
+	if r.err != nil || r.q.limit != 0 && r.returned == r.q.limit {
+		return false
+	}
+
+	for {
+		row, ok := r.nextRow()
+		if !ok {
+			return false
+		}
+		if r.skipped < r.q.offset {
+			r.skipped++
+			continue
+		}
+
+		r.row = row
+		r.returned++
+		return true
+	}
+}
+
+func (r *queryReader) Scan(value any) error {
+	if err := yson.Unmarshal(r.row, value); err != nil {
+		return err
+	}
+	if m, ok := value.(*map[string]any); ok {
+		for _, c := range r.hidden {
+			delete(*m, c)
+		}
+	}
+	return nil
+}
+
+func (r *queryReader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.in.Err()
+}
+
+func (r *queryReader) Close() error {
+	if r.sorted != nil {
+		r.sorted.close()
+	}
+	return r.in.Close()
+}
+
+// nextRow returns the next row that matches the filter in the requested order.
+func (r *queryReader) nextRow() (yson.RawValue, bool) {
+	if len(r.q.orderBy) == 0 {
+		raw, _, ok := r.nextMatch()
+		return raw, ok
+	}
+
+	if r.sorted == nil {
+		r.sorted, r.err = r.sort()
+		if r.err != nil {
+			return nil, false
+		}
+	}
+
+	raw, err := r.sorted.next()
+	if err != nil {
+		r.err = err
+		return nil, false
+	}
+	return raw, raw != nil
+}
+
+// nextMatch reads rows of the underlying reader until one that matches the filter.
+func (r *queryReader) nextMatch() (yson.RawValue, map[string]any, bool) {
+	for r.in.Next() {
+		var raw yson.RawValue
+		if err := r.in.Scan(&raw); err != nil {
+			r.err = xerrors.Errorf("error reading table row: %w", err)
+			return nil, nil, false
+		}
+
+		if r.q.filter == nil && len(r.q.orderBy) == 0 {
+			return raw, nil, true
+		}
+
+		var row map[string]any
+		if err := yson.Unmarshal(raw, &row); err != nil {
+			r.err = xerrors.Errorf("error reading table row: %w", err)
+			return nil, nil, false
+		}
+		if r.q.filter == nil || r.q.filter(row) {
+			return raw, row, true
+		}
+	}
+	return nil, nil, false
+}
+
+// sortEntry is a row being sorted along with its decoded values.
+type sortEntry struct {
+	raw yson.RawValue
+	row map[string]any
+	// seq is the index of the row among the matching ones used to keep the sort stable.
+	seq int64
+}
+
+// orderKeys returns the values of the order keys of the row; only they are needed for sorting.
+func (r *queryReader) orderKeys(row map[string]any) map[string]any {
+	keys := make(map[string]any, len(r.q.orderBy))
+	for _, k := range r.q.orderBy {
+		keys[k.column] = row[k.column]
+	}
+	return keys
+}
+
+// sort reads all the matching rows and sorts them.
+//
+// Rows are sorted in chunks that fit into memoryLimit. Once the rows exceed the limit,
+// each sorted chunk is spilled to a temporary file and the files are merged on read.
+// If the query has a limit, only the rows that can be returned are kept, see sortTop.
+func (r *queryReader) sort() (*sortedRows, error) {
+	if r.q.limit != 0 {
+		return r.sortTop()
+	}
+
+	rows := &sortedRows{q: r.q}
+
+	var chunk []sortEntry
+	chunkSize := 0
+	var count int64
+
+	sortChunk := func() {
+		slices.SortStableFunc(chunk, func(a, b sortEntry) int {
+			return r.q.compareRows(a.row, b.row)
+		})
+	}
+
+	for {
+		raw, row, ok := r.nextMatch()
+		if !ok {
+			break
+		}
+
+		count++
+		if count > r.q.offset+r.rowLimit {
+			rows.close()
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export; max is %d", r.rowLimit))
+		}
+
+		keys := r.orderKeys(row)
+		chunk = append(chunk, sortEntry{raw: raw, row: keys})
+		chunkSize += len(raw) + 64*len(keys)
+
+		if chunkSize >= r.memoryLimit {
+			sortChunk()
+			if err := rows.spill(chunk); err != nil {
+				rows.close()
+				return nil, err
+			}
+			chunk, chunkSize = nil, 0
+		}
+	}
+	if r.err != nil || r.in.Err() != nil {
+		rows.close()
+		return nil, r.Err()
+	}
+
+	sortChunk()
+	if len(rows.runs) == 0 {
+		rows.memory = chunk
+		return rows, nil
+	}
+
+	if len(chunk) > 0 {
+		if err := rows.spill(chunk); err != nil {
+			rows.close()
+			return nil, err
+		}
+	}
+	if err := rows.startMerge(); err != nil {
+		rows.close()
+		return nil, err
+	}
+	return rows, nil
+}
+
+// sortTop reads all the matching rows keeping only the first offset+limit of them in the requested order.
+//
+// Rows are kept in a heap with the last of them on top, so each row is either dropped or replaces the top one
+// and nothing is spilled to disk. Limits exceeding the row limit are not needed to be kept in full,
+// since the export of more than rowLimit rows fails anyway.
+func (r *queryReader) sortTop() (*sortedRows, error) {
+	keep := r.q.offset + min(r.q.limit, r.rowLimit+1)
+	top := &topHeap{q: r.q}
+
+	for seq := int64(0); ; seq++ {
+		raw, row, ok := r.nextMatch()
+		if !ok {
+			break
+		}
+
+		e := sortEntry{raw: raw, row: r.orderKeys(row), seq: seq}
+		switch {
+		case int64(top.Len()) < keep:
+			heap.Push(top, e)
+		case top.compare(e, top.entries[0]) < 0:
+			top.entries[0] = e
+			heap.Fix(top, 0)
+		}
+	}
+	if r.err != nil || r.in.Err() != nil {
+		return nil, r.Err()
+	}
+
+	slices.SortFunc(top.entries, top.compare)
+	return &sortedRows{q: r.q, memory: top.entries}, nil
+}
+
+// topHeap is a heap of rows with the last one in the requested order on top.
+type topHeap struct {
+	q       *compiledQuery
+	entries []sortEntry
+}
+
+// compare orders rows by the order keys of the query and then by their position.
+func (h *topHeap) compare(a, b sortEntry) int {
+	if c := h.q.compareRows(a.row, b.row); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.seq, b.seq)
+}
+
+func (h *topHeap) Len() int { return len(h.entries) }
+
+func (h *topHeap) Less(i, j int) bool { return h.compare(h.entries[i], h.entries[j]) > 0 }
+
+func (h *topHeap) Swap(i, j int) { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+
+func (h *topHeap) Push(x any) { h.entries = append(h.entries, x.(sortEntry)) }
+
+func (h *topHeap) Pop() any {
+	e := h.entries[len(h.entries)-1]
+	h.entries = h.entries[:len(h.entries)-1]
+	return e
+}
+
+// sortedRows returns rows either sorted in memory or merged from sorted runs spilled to disk.
+type sortedRows struct {
+	q      *compiledQuery
+	memory []sortEntry
+	runs   []*sortRun
+	merge  runHeap
+}
+
+// spill writes sorted chunk of rows to a new run.
+func (s *sortedRows) spill(chunk []sortEntry) error {
+	sp, err := newSpool()
+	if err != nil {
+		return err
+	}
+	run := &sortRun{index: len(s.runs), spool: sp}
+	s.runs = append(s.runs, run)
+
+	var size [binary.MaxVarintLen64]byte
+	for _, e := range chunk {
+		n := binary.PutUvarint(size[:], uint64(len(e.raw)))
+		if _, err := sp.Write(size[:n]); err != nil {
+			return xerrors.Errorf("error writing spool file: %w", err)
+		}
+		if _, err := sp.Write(e.raw); err != nil {
+			return xerrors.Errorf("error writing spool file: %w", err)
+		}
+	}
+	return nil
+}
+
+// startMerge rewinds all runs and reads the first row of each of them.
+func (s *sortedRows) startMerge() error {
+	s.merge = runHeap{q: s.q}
+	for _, run := range s.runs {
+		r, err := run.spool.Rewind()
+		if err != nil {
+			return err
+		}
+		run.r = bufio.NewReader(r)
+
+		ok, err := run.advance()
+		if err != nil {
+			return err
+		}
+		if ok {
+			s.merge.runs = append(s.merge.runs, run)
+		}
+	}
+	heap.Init(&s.merge)
+	return nil
+}
+
+// next returns the next row or nil when there are no more rows.
+func (s *sortedRows) next() (yson.RawValue, error) {
+	if s.runs == nil {
+		if len(s.memory) == 0 {
+			return nil, nil
+		}
+		e := s.memory[0]
+		s.memory[0] = sortEntry{}
+		s.memory = s.memory[1:]
+		return e.raw, nil
+	}
+
+	if len(s.merge.runs) == 0 {
+		return nil, nil
+	}
+
+	run := s.merge.runs[0]
+	raw := run.current.raw
+
+	ok, err := run.advance()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		heap.Fix(&s.merge, 0)
+	} else {
+		heap.Pop(&s.merge)
+	}
+	return raw, nil
+}
+
+func (s *sortedRows) close() {
+	for _, run := range s.runs {
+		run.spool.Remove()
+	}
+}
+
+// sortRun is a sorted chunk of rows spilled to disk.
+type sortRun struct {
+	index   int
+	spool   *spool
+	r       *bufio.Reader
+	current sortEntry
+}
+
+// advance reads the next row of the run; it returns false at the end of the run.
+func (r *sortRun) advance() (bool, error) {
+	size, err := binary.ReadUvarint(r.r)
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, xerrors.Errorf("error reading spool file: %w", err)
+	}
+
+	raw := make(yson.RawValue, size)
+	if _, err := io.ReadFull(r.r, raw); err != nil {
+		return false, xerrors.Errorf("error reading spool file: %w", err)
+	}
+
+	var row map[string]any
+	if err := yson.Unmarshal(raw, &row); err != nil {
+		return false, xerrors.Errorf("error reading spool file: %w", err)
+	}
+	r.current = sortEntry{raw: raw, row: row}
+	return true, nil
+}
+
+// runHeap orders runs by their current rows; equal rows are taken from earlier runs first
+// to keep the sort stable.
+type runHeap struct {
+	q    *compiledQuery
+	runs []*sortRun
+}
+
+func (h *runHeap) Len() int { return len(h.runs) }
+
+func (h *runHeap) Less(i, j int) bool {
+	if c := h.q.compareRows(h.runs[i].current.row, h.runs[j].current.row); c != 0 {
+		return c < 0
+	}
+	return h.runs[i].index < h.runs[j].index
+}
+
+func (h *runHeap) Swap(i, j int) { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+
+func (h *runHeap) Push(x any) { h.runs = append(h.runs, x.(*sortRun)) }
+
+func (h *runHeap) Pop() any {
+	run := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return run
+}
@@ -0,0 +1,199 @@
+package exporter
+
+import (
+	"context"
+	"time"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+)
+
+const (
+	// queryPollPeriod is the period of polling the state of a running YT QL query.
+	queryPollPeriod = time.Second
+	// queryAbortTimeout limits aborting of the query that is no longer awaited.
+	queryAbortTimeout = 10 * time.Second
+)
+
+// ExportSelectRequest represents a request to export the result of YT QL query to excel.
+type ExportSelectRequest struct {
+	Filename            string
+	Query               string
+	NumberPrecisionMode NumberPrecisionMode
+	// MultiSheet enables spilling rows over several sheets when they do not fit into a single one.
+	MultiSheet        bool
+	Format            Format
+	Delimiter         rune
+	ComplexTypeFormat ComplexTypeFormat
+	Header            HeaderOptions
+}
+
+// RowLimit returns the maximum number of rows the request can export.
+func (r *ExportSelectRequest) RowLimit() int64 {
+	if r.MultiSheet {
+		return MaxMultiSheetRowCount
+	}
+	return MaxRowCount
+}
+
+func (r *ExportSelectRequest) EnsureFileName() {
+	defer func() {
+		r.Filename = ensureExtension(r.Filename, r.Format)
+	}()
+
+	if r.Filename != "" {
+		return
+	}
+
+	r.Filename = r.MakeFileName(randomName())
+}
+
+func (r *ExportSelectRequest) MakeFileName(suffix string) string {
+	return "yt_select__" + suffix + r.Format.Extension()
+}
+
+// ExportSelect prepares conversion of the YT QL query result.
+//
+// SelectRows of the client does not return the schema of the selected rows over either http or rpc,
+// so the query is run by the query tracker with ql engine that executes select_rows
+// and stores the result along with its schema. The result is then converted
+// the same way as the result of any other query, i.e. typed by its type_v3 schema.
+// The query is aborted if it is not awaited till the end, e.g. when the request is canceled.
+//
+// Conversion itself happens on ExportResponse.Write.
+func ExportSelect(
+	ctx context.Context,
+	yc yt.Client,
+	req *ExportSelectRequest,
+	opts *ExportOptions,
+) (*ExportResponse, error) {
+	req.EnsureFileName()
+
+	id, err := yc.StartQuery(ctx, yt.QueryEngineQL, req.Query, nil)
+	if err != nil {
+		err = xerrors.Errorf("error starting query: %w", err)
+		if yterrors.ContainsResolveError(err) {
+			return nil, ErrBadRequest.Wrap(err)
+		}
+		return nil, err
+	}
+
+	if err := waitQuery(ctx, yc, id); err != nil {
+		return nil, err
+	}
+
+	qr, err := yc.GetQueryResult(ctx, id, 0, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("error getting result of query %v: %w", id, err)
+	}
+	if qr.IsTruncated {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("result of query %v is truncated; add limit to the query", id))
+	}
+
+	rowLimit := req.RowLimit()
+	if qr.DataStatistics.RowCount > rowLimit {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export; max is %d", rowLimit))
+	}
+
+	s := &qr.Schema
+	if len(s.Columns) > excelMaxColCount {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d", excelMaxColCount))
+	}
+
+	in, err := yc.ReadQueryResult(ctx, id, 0, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("error reading result of query %v: %w", id, err)
+	}
+
+	convertOpts := &ConvertOptions{
+		Columns:             getColumnNames(s.Columns),
+		Schema:              s,
+		ExportOptions:       opts,
+		NumberPrecisionMode: req.NumberPrecisionMode,
+		MultiSheet:          req.MultiSheet,
+		Format:              req.Format,
+		Delimiter:           req.Delimiter,
+		ComplexTypeFormat:   req.ComplexTypeFormat,
+		Header:              req.Header,
+	}
+
+	return &ExportResponse{
+		Filename:    req.Filename,
+		ContentType: req.Format.ContentType(),
+		source:      "select result",
+		in:          in,
+		convertOpts: convertOpts,
+	}, nil
+}
+
+// waitQuery polls the state of the query until it is finished.
+//
+// Errors of the failed query are returned as ErrBadRequest unless they are caused by the cluster.
+// The query is aborted if ctx is canceled or its state can not be polled.
+func waitQuery(ctx context.Context, yc yt.QueryTrackerClient, id yt.QueryID) error {
+	t := time.NewTicker(queryPollPeriod)
+	defer t.Stop()
+
+	for {
+		q, err := yc.GetQuery(ctx, id, &yt.GetQueryOptions{Attributes: []string{"state", "error"}})
+		if err != nil {
+			abortQuery(ctx, yc, id)
+			return xerrors.Errorf("error getting state of query %v: %w", id, err)
+		}
+
+		if q.State != nil {
+			switch *q.State {
+			case yt.QueryStateCompleted:
+				return nil
+			case yt.QueryStateFailed, yt.QueryStateAborted:
+				err := xerrors.Errorf("query %v is %s: %w", id, *q.State, q.Err.Unwrap())
+				if isQueryError(err) {
+					return ErrBadRequest.Wrap(err)
+				}
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			abortQuery(ctx, yc, id)
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// abortQuery aborts the query ignoring errors, e.g. if the query is already finished.
+//
+// ctx may be already canceled, so the query is aborted within a separate timeout.
+func abortQuery(ctx context.Context, yc yt.QueryTrackerClient, id yt.QueryID) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), queryAbortTimeout)
+	defer cancel()
+
+	_ = yc.AbortQuery(ctx, id, nil)
+}
+
+// clusterErrorCodes are codes of errors that are not caused by the query itself.
+var clusterErrorCodes = []yterrors.ErrorCode{
+	yterrors.CodeCanceled,
+	yterrors.CodeTimeout,
+	yterrors.CodeTransportError,
+	yterrors.CodeUnavailable,
+	yterrors.CodeRPCAuthenticationError,
+	yterrors.CodeTransientFailure,
+	yterrors.CodeOverloaded,
+	yterrors.CodeAuthenticationError,
+	yterrors.CodeAuthorizationError,
+}
+
+// isQueryError checks whether the query has failed due to its text or the tables it reads,
+// e.g. because of a syntax error, a missing table or exceeded row limits.
+func isQueryError(err error) bool {
+	for _, code := range clusterErrorCodes {
+		if yterrors.ContainsErrorCode(err, code) {
+			return false
+		}
+	}
+	return true
+}
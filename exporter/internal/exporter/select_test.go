@@ -0,0 +1,93 @@
+package exporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/guid"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+)
+
+func TestIsQueryError(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "syntax",
+			err:      yterrors.Err("Error while parsing query"),
+			expected: true,
+		},
+		{
+			name:     "resolve",
+			err:      yterrors.Err(yterrors.CodeResolveError, "Node //home/missing has no child"),
+			expected: true,
+		},
+		{
+			name:     "row-limit",
+			err:      yterrors.Err(yterrors.CodeQueryInputRowCountLimitExceeded, "Input row limit exceeded"),
+			expected: true,
+		},
+		{
+			name: "unavailable",
+			err: yterrors.Err("Error executing query",
+				yterrors.Err(yterrors.CodeUnavailable, "Tablet cell is not available")),
+		},
+		{
+			name: "authorization",
+			err:  xerrors.Errorf("query failed: %w", yterrors.Err(yterrors.CodeAuthorizationError, "Access denied")),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, isQueryError(tc.err))
+		})
+	}
+}
+
+// queryRecorder is a yt.QueryTrackerClient running queries that never finish.
+type queryRecorder struct {
+	yt.QueryTrackerClient
+
+	getErr  error
+	aborted []yt.QueryID
+}
+
+func (c *queryRecorder) GetQuery(ctx context.Context, id yt.QueryID, opts *yt.GetQueryOptions) (*yt.Query, error) {
+	if c.getErr != nil {
+		return nil, c.getErr
+	}
+	state := yt.QueryStateRunning
+	return &yt.Query{ID: id, State: &state}, nil
+}
+
+func (c *queryRecorder) AbortQuery(ctx context.Context, id yt.QueryID, opts *yt.AbortQueryOptions) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	c.aborted = append(c.aborted, id)
+	return nil
+}
+
+func TestWaitQuery_abort(t *testing.T) {
+	id := yt.QueryID(guid.New())
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		yc := &queryRecorder{}
+		require.ErrorIs(t, waitQuery(ctx, yc, id), context.Canceled)
+		require.Equal(t, []yt.QueryID{id}, yc.aborted)
+	})
+
+	t.Run("get-error", func(t *testing.T) {
+		yc := &queryRecorder{getErr: yterrors.Err(yterrors.CodeUnavailable, "Query tracker is not available")}
+		require.Error(t, waitQuery(context.Background(), yc, id))
+		require.Equal(t, []yt.QueryID{id}, yc.aborted)
+	})
+}
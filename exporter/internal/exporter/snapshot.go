@@ -0,0 +1,70 @@
+package exporter
+
+import (
+	"context"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yt"
+)
+
+// ErrTableChanged is an error that signals that the table does not have the expected revision.
+var ErrTableChanged = xerrors.NewSentinel("table changed")
+
+// tableSnapshot is the exported table locked in the transaction of the export.
+//
+// The schema, the attributes and the rows are read within the transaction by the id of the locked node,
+// so that they belong to the same version of the table even if it is concurrently overwritten, moved or replaced.
+type tableSnapshot struct {
+	tx yt.Tx
+	// node is the path of the locked node by its id, e.g. #1-2-3-4.
+	node ypath.Path
+}
+
+// lockSnapshot starts the transaction of the export and takes snapshot lock of the table.
+//
+// The transaction is aborted on error; otherwise it must be aborted by the caller when the export is finished.
+func lockSnapshot(ctx context.Context, yc yt.Client, path ypath.Path) (*tableSnapshot, error) {
+	tx, err := yc.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("error starting export transaction: %w", err)
+	}
+
+	table, err := lockTable(ctx, tx, path)
+	if err != nil {
+		_ = tx.Abort()
+		return nil, err
+	}
+	return table, nil
+}
+
+// lockTable takes snapshot lock of the table in the given transaction,
+// so that several tables can be exported from the same transaction.
+func lockTable(ctx context.Context, tx yt.Tx, path ypath.Path) (*tableSnapshot, error) {
+	lock, err := tx.LockNode(ctx, path, yt.LockSnapshot, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("error locking %q: %w", path, err)
+	}
+	return &tableSnapshot{tx: tx, node: lock.NodeID.YPath()}, nil
+}
+
+// readRevision returns the value of @revision table attribute.
+func (t *tableSnapshot) readRevision(ctx context.Context) (uint64, error) {
+	var revision uint64
+	if err := t.tx.GetNode(ctx, t.node.Attr("revision"), &revision, nil); err != nil {
+		return 0, xerrors.Errorf("error reading table revision: %w", err)
+	}
+	return revision, nil
+}
+
+// checkRevision fails with ErrTableChanged if the table does not have the expected revision.
+func (t *tableSnapshot) checkRevision(ctx context.Context, path ypath.Path, expected uint64) error {
+	revision, err := t.readRevision(ctx)
+	if err != nil {
+		return err
+	}
+	if revision != expected {
+		return ErrTableChanged.Wrap(xerrors.Errorf("table %q has revision %d; expected %d", path, revision, expected))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+type snapshotRow struct {
+	ID int64 `yson:"id"`
+}
+
+type replacedRow struct {
+	Name string `yson:"name"`
+}
+
+func TestExport_snapshot(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	path := env.TmpPath()
+	_, err := yt.CreateTable(env.Ctx, env.YT, path, yt.WithSchema(schema.MustInfer(&snapshotRow{})))
+	require.NoError(t, err)
+	require.NoError(t, env.UploadSlice(path, []snapshotRow{{ID: 1}, {ID: 2}}))
+
+	for _, tc := range []struct {
+		name   string
+		change func(t *testing.T)
+	}{
+		{
+			name: "overwrite",
+			change: func(t *testing.T) {
+				require.NoError(t, env.UploadSlice(path, []snapshotRow{{ID: 3}}))
+			},
+		},
+		{
+			name: "replace",
+			change: func(t *testing.T) {
+				require.NoError(t, env.YT.RemoveNode(env.Ctx, path, nil))
+				_, err := yt.CreateTable(env.Ctx, env.YT, path, yt.WithSchema(schema.MustInfer(&replacedRow{})))
+				require.NoError(t, err)
+				require.NoError(t, env.UploadSlice(path, []replacedRow{{Name: "a"}}))
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var expected [][]string
+			{
+				req, err := MakeExportRequest(path.String(), NumberPrecisionModeError)
+				require.NoError(t, err)
+				f, err := exportFile(t, env.Ctx, env.YT, req, &ExportOptions{MaxExcelFileSize: 1024 * 1024}, "snapshot.xlsx")
+				require.NoError(t, err)
+				expected, err = f.GetRows(SheetName)
+				require.NoError(t, err)
+			}
+
+			req, err := MakeExportRequest(path.String(), NumberPrecisionModeError)
+			require.NoError(t, err)
+			rsp, err := Export(env.Ctx, env.YT, req, &ExportOptions{MaxExcelFileSize: 1024 * 1024})
+			require.NoError(t, err)
+			defer func() { _ = rsp.Close() }()
+
+			tc.change(t)
+
+			f, err := writeFile(t, rsp, "snapshot_"+tc.name+".xlsx")
+			require.NoError(t, err)
+			rows, err := f.GetRows(SheetName)
+			require.NoError(t, err)
+			require.Equal(t, expected, rows, "rows are read from the locked version of the table")
+		})
+	}
+}
+
+func TestExport_expectedRevision(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	path := env.TmpPath()
+	_, err := yt.CreateTable(env.Ctx, env.YT, path, yt.WithSchema(schema.MustInfer(&snapshotRow{})))
+	require.NoError(t, err)
+	require.NoError(t, env.UploadSlice(path, []snapshotRow{{ID: 1}}))
+
+	var revision uint64
+	require.NoError(t, env.YT.GetNode(env.Ctx, path.Attr("revision"), &revision, nil))
+
+	req, err := MakeExportRequest(path.String(), NumberPrecisionModeError)
+	require.NoError(t, err)
+	req.ExpectedRevision = &revision
+
+	opts := &ExportOptions{MaxExcelFileSize: 1024 * 1024}
+	_, err = exportFile(t, env.Ctx, env.YT, req, opts, "expected_revision.xlsx")
+	require.NoError(t, err)
+
+	require.NoError(t, env.UploadSlice(path, []snapshotRow{{ID: 2}}))
+
+	_, err = Export(env.Ctx, env.YT, req, opts)
+	require.ErrorIs(t, err, ErrTableChanged)
+}
@@ -0,0 +1,226 @@
+package exporter
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+)
+
+// maxSheetNameLength is the max number of characters in the name of excel sheet.
+const maxSheetNameLength = 31
+
+// ExportWorkbookRequest represents a request to export several tables and query results into a single workbook,
+// one sheet per source.
+type ExportWorkbookRequest struct {
+	// Filename is the name of the resulting file; taken from the first source if empty.
+	Filename string
+	Sources  []WorkbookSource
+	// NumberPrecisionMode, Format, ComplexTypeFormat and Header apply to all the sources.
+	NumberPrecisionMode NumberPrecisionMode
+	Format              Format
+	ComplexTypeFormat   ComplexTypeFormat
+	Header              HeaderOptions
+}
+
+// WorkbookSource is a static table or a query result exported into a separate sheet.
+//
+// Exactly one of Table and QueryResult must be set.
+type WorkbookSource struct {
+	// Sheet is the name of the sheet; SheetN by the position of the source if empty.
+	Sheet       string
+	Table       *ExportRequest
+	QueryResult *ExportQueryResultRequest
+}
+
+// validate checks the sources and sets the default names of the sheets.
+func (r *ExportWorkbookRequest) validate() error {
+	if len(r.Sources) == 0 {
+		return xerrors.New("no sources to export")
+	}
+	if len(r.Sources) > MaxSheetCount {
+		return xerrors.Errorf("too many sources to export; max is %d", MaxSheetCount)
+	}
+	if !r.Format.SupportsSheets() {
+		return xerrors.Errorf("%s format does not support several sheets", r.Format)
+	}
+
+	names := make(map[string]int)
+	for i := range r.Sources {
+		src := &r.Sources[i]
+		if (src.Table == nil) == (src.QueryResult == nil) {
+			return xerrors.Errorf("source %d must be either a table or a query result", i)
+		}
+		if t := src.Table; t != nil {
+			switch {
+			case t.MultiSheet || t.SheetPerRange:
+				return xerrors.Errorf("source %d: rows of each source must fit into a single sheet", i)
+			case t.Profile != "":
+				return xerrors.Errorf("source %d: profiles are not supported by workbook export", i)
+			case t.Metadata:
+				return xerrors.Errorf("source %d: metadata sheet is not supported by workbook export", i)
+			}
+		}
+
+		if src.Sheet == "" {
+			src.Sheet = makeSheetName(i + 1)
+		}
+		if err := checkSheetName(src.Sheet); err != nil {
+			return xerrors.Errorf("source %d: %w", i, err)
+		}
+
+		key := strings.ToLower(src.Sheet)
+		if j, ok := names[key]; ok {
+			return xerrors.Errorf("sources %d and %d have the same sheet name %q", j, i, src.Sheet)
+		}
+		names[key] = i
+	}
+	return nil
+}
+
+// hasTables checks whether some of the sources is a table.
+func (r *ExportWorkbookRequest) hasTables() bool {
+	for _, src := range r.Sources {
+		if src.Table != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkSheetName checks that excel accepts the name of the sheet.
+func checkSheetName(name string) error {
+	switch {
+	case utf8.RuneCountInString(name) > maxSheetNameLength:
+		return xerrors.Errorf("sheet name %q is longer than %d characters", name, maxSheetNameLength)
+	case strings.ContainsAny(name, `:\/?*[]`):
+		return xerrors.Errorf(`sheet name %q contains any of the characters :\/?*[]`, name)
+	case strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'"):
+		return xerrors.Errorf("sheet name %q starts or ends with a single quote", name)
+	case name == SchemaSheetName || name == MetadataSheetName:
+		return xerrors.Errorf("sheet name %q is reserved", name)
+	}
+	return nil
+}
+
+// ExportWorkbook prepares given workbook export request.
+//
+// The tables are read under snapshot lock taken in a single transaction that lasts until ExportResponse.Close.
+// The limits of the number of rows, columns and of the file size apply to the whole workbook,
+// the rows of each source must fit into a single sheet.
+// Conversion itself happens on ExportResponse.Write, the sources are read one at a time.
+func ExportWorkbook(
+	ctx context.Context,
+	yc yt.Client,
+	req *ExportWorkbookRequest,
+	opts *ExportOptions,
+) (*ExportResponse, error) {
+	if err := req.validate(); err != nil {
+		return nil, ErrBadRequest.Wrap(err)
+	}
+
+	var tx yt.Tx
+	if req.hasTables() {
+		var err error
+		if tx, err = yc.BeginTx(ctx, nil); err != nil {
+			return nil, xerrors.Errorf("error starting export transaction: %w", err)
+		}
+	}
+
+	rsp, err := exportWorkbook(ctx, yc, tx, req, opts)
+	if err != nil {
+		if tx != nil {
+			_ = tx.Abort()
+		}
+		return nil, err
+	}
+	return rsp, nil
+}
+
+func exportWorkbook(
+	ctx context.Context,
+	yc yt.Client,
+	tx yt.Tx,
+	req *ExportWorkbookRequest,
+	opts *ExportOptions,
+) (*ExportResponse, error) {
+	rsp := &ExportResponse{
+		Filename:    req.Filename,
+		ContentType: req.Format.ContentType(),
+		tx:          tx,
+		convertOpts: &ConvertOptions{
+			ExportOptions:       opts,
+			NumberPrecisionMode: req.NumberPrecisionMode,
+			Format:              req.Format,
+			ComplexTypeFormat:   req.ComplexTypeFormat,
+			Header:              req.Header,
+		},
+	}
+
+	var sources []string
+	var columnCount int
+	var rowCount int64
+	for _, src := range req.Sources {
+		var sheet *ExportResponse
+		if t := src.Table; t != nil {
+			t.NumberPrecisionMode = req.NumberPrecisionMode
+			t.Format = req.Format
+			t.ComplexTypeFormat = req.ComplexTypeFormat
+			t.Header = req.Header
+
+			table, err := lockTable(ctx, tx, t.Path)
+			if err != nil {
+				if yterrors.ContainsResolveError(err) {
+					return nil, ErrBadRequest.Wrap(err)
+				}
+				return nil, err
+			}
+			if sheet, err = prepareExport(ctx, yc, table, t, opts); err != nil {
+				return nil, xerrors.Errorf("sheet %q: %w", src.Sheet, err)
+			}
+		} else {
+			q := src.QueryResult
+			q.NumberPrecisionMode = req.NumberPrecisionMode
+			q.Format = req.Format
+			q.ComplexTypeFormat = req.ComplexTypeFormat
+			q.Header = req.Header
+
+			var err error
+			if sheet, err = prepareQueryResult(ctx, yc, q, opts); err != nil {
+				return nil, xerrors.Errorf("sheet %q: %w", src.Sheet, err)
+			}
+		}
+
+		if rsp.Filename == "" {
+			rsp.Filename = sheet.Filename
+		}
+
+		columnCount += len(sheet.convertOpts.Columns)
+		if columnCount > excelMaxColCount {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d in the workbook",
+				excelMaxColCount))
+		}
+
+		// Rows of the sources filtered by queries are counted while they are converted.
+		rowCount += sheet.rowCount
+		if rowCount > MaxRowCount {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("too many rows to export in the workbook; max is %d", MaxRowCount))
+		}
+
+		sources = append(sources, sheet.source)
+		rsp.sheets = append(rsp.sheets, WorkbookSheet{
+			Name:        src.Sheet,
+			Open:        sheet.tables[0],
+			Columns:     sheet.convertOpts.Columns,
+			Schema:      sheet.convertOpts.Schema,
+			ColumnSpecs: sheet.convertOpts.ColumnSpecs,
+		})
+	}
+
+	rsp.Filename = ensureExtension(rsp.Filename, req.Format)
+	rsp.source = strings.Join(sources, ", ")
+	return rsp, nil
+}
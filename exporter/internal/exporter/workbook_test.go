@@ -0,0 +1,261 @@
+package exporter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+func TestExportWorkbookRequest_validate(t *testing.T) {
+	table := func() *ExportRequest { return &ExportRequest{Path: "//tmp/a"} }
+
+	req := &ExportWorkbookRequest{Sources: []WorkbookSource{
+		{Table: table()},
+		{Sheet: "Orders", Table: table()},
+		{QueryResult: &ExportQueryResultRequest{}},
+	}}
+	require.NoError(t, req.validate())
+	require.Equal(t, "Sheet1", req.Sources[0].Sheet)
+	require.Equal(t, "Orders", req.Sources[1].Sheet)
+	require.Equal(t, "Sheet3", req.Sources[2].Sheet)
+
+	for _, tc := range []struct {
+		name string
+		req  *ExportWorkbookRequest
+	}{
+		{name: "no_sources", req: &ExportWorkbookRequest{}},
+		{
+			name: "too_many_sources",
+			req:  &ExportWorkbookRequest{Sources: make([]WorkbookSource, MaxSheetCount+1)},
+		},
+		{
+			name: "csv",
+			req:  &ExportWorkbookRequest{Format: FormatCSV, Sources: []WorkbookSource{{Table: table()}}},
+		},
+		{
+			name: "no_table_nor_query_result",
+			req:  &ExportWorkbookRequest{Sources: []WorkbookSource{{Sheet: "a"}}},
+		},
+		{
+			name: "table_and_query_result",
+			req: &ExportWorkbookRequest{Sources: []WorkbookSource{
+				{Table: table(), QueryResult: &ExportQueryResultRequest{}},
+			}},
+		},
+		{
+			name: "multi_sheet",
+			req: &ExportWorkbookRequest{Sources: []WorkbookSource{
+				{Table: &ExportRequest{Path: "//tmp/a", MultiSheet: true}},
+			}},
+		},
+		{
+			name: "same_sheet_name",
+			req: &ExportWorkbookRequest{Sources: []WorkbookSource{
+				{Sheet: "Orders", Table: table()},
+				{Sheet: "orders", Table: table()},
+			}},
+		},
+		{
+			name: "default_sheet_name_taken",
+			req: &ExportWorkbookRequest{Sources: []WorkbookSource{
+				{Sheet: "Sheet2", Table: table()},
+				{Table: table()},
+			}},
+		},
+		{
+			name: "long_sheet_name",
+			req: &ExportWorkbookRequest{Sources: []WorkbookSource{
+				{Sheet: strings.Repeat("a", maxSheetNameLength+1), Table: table()},
+			}},
+		},
+		{
+			name: "invalid_sheet_name",
+			req:  &ExportWorkbookRequest{Sources: []WorkbookSource{{Sheet: "a/b", Table: table()}}},
+		},
+		{
+			name: "reserved_sheet_name",
+			req:  &ExportWorkbookRequest{Sources: []WorkbookSource{{Sheet: MetadataSheetName, Table: table()}}},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Error(t, tc.req.validate())
+		})
+	}
+}
+
+func TestConvertWorkbook(t *testing.T) {
+	open := func(rows ...any) OpenTableFunc {
+		return func() (yt.TableReader, error) {
+			return &rowsReader{rows: rows}, nil
+		}
+	}
+
+	sheets := []WorkbookSheet{
+		{
+			Name:    "Orders",
+			Open:    open(map[string]any{"id": 1, "sum": 2.5}),
+			Columns: []string{"id", "sum"},
+			Schema: &schema.Schema{Columns: []schema.Column{
+				{Name: "id", Type: schema.TypeInt64},
+				{Name: "sum", Type: schema.TypeFloat64},
+			}},
+		},
+		{
+			Name:    "Users",
+			Open:    open(map[string]any{"name": "a"}, map[string]any{"name": "b"}),
+			Columns: []string{"name"},
+			Schema: &schema.Schema{Columns: []schema.Column{
+				{Name: "name", Type: schema.TypeString},
+			}},
+		},
+		{
+			Open: open(map[string]any{"b": 1, "a": 2}),
+		},
+	}
+
+	opts := &ConvertOptions{
+		ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+		NumberPrecisionMode: NumberPrecisionModeString,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, ConvertWorkbook(&buf, sheets, opts))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	require.Equal(t, []string{"Orders", "Users", "Sheet3"}, f.GetSheetList())
+
+	for sheet, expected := range map[string][][]string{
+		"Orders": {{"id", "sum"}, {"int64", "double"}, {"1", "2.5"}},
+		"Users":  {{"name"}, {"utf8"}, {"a"}, {"b"}},
+		"Sheet3": {{"a", "b"}, {"2", "1"}},
+	} {
+		rows, err := f.GetRows(sheet)
+		require.NoError(t, err)
+		require.Equal(t, expected, rows, sheet)
+	}
+
+	t.Run("size_limit", func(t *testing.T) {
+		sheets := []WorkbookSheet{
+			{Open: open(map[string]any{"a": strings.Repeat("a", 600)})},
+			{Open: open(map[string]any{"a": strings.Repeat("b", 600)})},
+		}
+		opts := &ConvertOptions{
+			ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024},
+			NumberPrecisionMode: NumberPrecisionModeString,
+		}
+
+		var buf bytes.Buffer
+		require.Error(t, ConvertWorkbook(&buf, sheets, opts), "size limit applies to the whole workbook")
+	})
+
+	t.Run("row_limit", func(t *testing.T) {
+		sheets := []WorkbookSheet{
+			{Open: open(map[string]any{"a": 1}, map[string]any{"a": 2})},
+			{Open: open(map[string]any{"a": 3}, map[string]any{"a": 4})},
+		}
+		opts := &ConvertOptions{
+			ExportOptions:       &ExportOptions{MaxExcelFileSize: 1024 * 1024},
+			NumberPrecisionMode: NumberPrecisionModeString,
+			workbookRowLimit:    3,
+		}
+
+		var buf bytes.Buffer
+		err := ConvertWorkbook(&buf, sheets, opts)
+		require.ErrorIs(t, err, ErrBadRequest, "row limit applies to the whole workbook")
+	})
+}
+
+type orderRow struct {
+	ID int64 `yson:"id"`
+}
+
+type userRow struct {
+	Name string `yson:"name"`
+}
+
+func TestExportWorkbook(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	orders, users := env.TmpPath(), env.TmpPath()
+	_, err := yt.CreateTable(env.Ctx, env.YT, orders, yt.WithSchema(schema.MustInfer(&orderRow{})),
+		yt.WithAttributes(map[string]any{"file_name": "orders.xlsx"}))
+	require.NoError(t, err)
+	require.NoError(t, env.UploadSlice(orders, []orderRow{{ID: 1}, {ID: 2}}))
+
+	_, err = yt.CreateTable(env.Ctx, env.YT, users, yt.WithSchema(schema.MustInfer(&userRow{})))
+	require.NoError(t, err)
+	require.NoError(t, env.UploadSlice(users, []userRow{{Name: "a"}}))
+
+	makeSource := func(t *testing.T, sheet, path string) WorkbookSource {
+		req, err := MakeExportRequest(path, "")
+		require.NoError(t, err)
+		return WorkbookSource{Sheet: sheet, Table: req}
+	}
+
+	opts := &ExportOptions{MaxExcelFileSize: 1024 * 1024}
+
+	t.Run("success", func(t *testing.T) {
+		req := &ExportWorkbookRequest{
+			NumberPrecisionMode: NumberPrecisionModeString,
+			Sources: []WorkbookSource{
+				makeSource(t, "Orders", orders.String()),
+				makeSource(t, "", users.String()),
+				makeSource(t, "First order", orders.String()+"[#0:#1]"),
+			},
+		}
+
+		rsp, err := ExportWorkbook(env.Ctx, env.YT, req, opts)
+		require.NoError(t, err)
+		defer func() { _ = rsp.Close() }()
+		require.Equal(t, "orders.xlsx", rsp.Filename, "file name is taken from the first source")
+
+		f, err := writeFile(t, rsp, "workbook.xlsx")
+		require.NoError(t, err)
+		require.Equal(t, []string{"Orders", "Sheet2", "First order"}, f.GetSheetList())
+
+		for sheet, expected := range map[string][][]string{
+			"Orders":      {{"id"}, {"int64"}, {"1"}, {"2"}},
+			"Sheet2":      {{"name"}, {"utf8"}, {"a"}},
+			"First order": {{"id"}, {"int64"}, {"1"}},
+		} {
+			rows, err := f.GetRows(sheet)
+			require.NoError(t, err)
+			require.Equal(t, expected, rows, sheet)
+		}
+	})
+
+	t.Run("filename", func(t *testing.T) {
+		req := &ExportWorkbookRequest{
+			Filename:            "report",
+			NumberPrecisionMode: NumberPrecisionModeString,
+			Sources:             []WorkbookSource{makeSource(t, "", orders.String())},
+		}
+
+		rsp, err := ExportWorkbook(env.Ctx, env.YT, req, opts)
+		require.NoError(t, err)
+		defer func() { _ = rsp.Close() }()
+		require.Equal(t, "report.xlsx", rsp.Filename)
+	})
+
+	t.Run("missing_table", func(t *testing.T) {
+		req := &ExportWorkbookRequest{
+			NumberPrecisionMode: NumberPrecisionModeString,
+			Sources: []WorkbookSource{
+				makeSource(t, "", orders.String()),
+				makeSource(t, "", env.TmpPath().String()),
+			},
+		}
+
+		_, err := ExportWorkbook(env.Ctx, env.YT, req, opts)
+		require.ErrorIs(t, err, ErrBadRequest)
+	})
+}
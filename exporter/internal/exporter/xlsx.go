@@ -0,0 +1,571 @@
+package exporter
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+)
+
+const (
+	xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+	nsSpreadsheetML = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRelationships = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsPackageRels   = "http://schemas.openxmlformats.org/package/2006/relationships"
+
+	// firstCustomNumFmtID is the first number format id that is not reserved for built-in formats.
+	firstCustomNumFmtID = 164
+)
+
+// xlsxWriter is a streaming writer of Office Open XML workbooks.
+//
+// Unlike excelize.File it does not keep the workbook in memory:
+// each row is encoded as sheet xml and compressed into the output as soon as it is written.
+// Strings are stored inline, so there is no shared string table to accumulate either.
+//
+// Sheets are written one after another. The workbook is complete only after Close.
+type xlsxWriter struct {
+	zw *zip.Writer
+
+	// numFmts stores custom number formats of registered styles; style id is index + 1.
+	// Empty format is the general one.
+	numFmts []string
+	// headerStyle is the id of the bold style of the header cells.
+	headerStyle int
+	sheets      []string
+	// hidden marks the hidden sheets.
+	hidden []bool
+	layout sheetLayout
+	// filters are the autofilter ranges of the sheets; empty if the sheet has none.
+	filters []string
+
+	sheet io.Writer
+	// spool stores rows of the current sheet when its header is deferred.
+	spool *spool
+	// sample stores the first rows of the current sheet until the widths of the columns are known.
+	sample  *bytes.Buffer
+	widths  columnWidths
+	header  []excelize.Cell
+	lastRow int
+	// lastColumn is the max number of cells in the rows of the current sheet.
+	lastColumn int
+	cellNames  []string
+
+	buf bytes.Buffer
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{zw: zip.NewWriter(w)}
+}
+
+// NewStyle registers cell style with given number format and returns its id.
+func (w *xlsxWriter) NewStyle(numFmt string) int {
+	w.numFmts = append(w.numFmts, numFmt)
+	return len(w.numFmts)
+}
+
+// SetLayout sets presentation of the sheets started after the call.
+func (w *xlsxWriter) SetLayout(l sheetLayout) {
+	w.layout = l
+	if l.Style && l.headerRows > 0 && w.headerStyle == 0 {
+		w.headerStyle = w.NewStyle("")
+	}
+}
+
+// NewSheet finishes the current sheet and starts a new one.
+//
+// If deferHeader is set, the first row of the sheet is expected to be set via SetHeader
+// at any moment before the sheet is finished. Meanwhile rows are spooled to a temporary file.
+//
+// With AutoWidth layout the first rows of the sheet are kept in memory until the widths of the columns are known.
+func (w *xlsxWriter) NewSheet(name string, deferHeader bool) error {
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+
+	w.sheets = append(w.sheets, name)
+	w.hidden = append(w.hidden, w.layout.hidden)
+	w.filters = append(w.filters, "")
+	w.lastRow = 0
+	w.lastColumn = 0
+	w.widths = w.widths[:0]
+
+	if deferHeader {
+		spool, err := newSpool()
+		if err != nil {
+			return err
+		}
+		w.spool = spool
+		w.sheet = spool
+		w.lastRow = 1
+		return nil
+	}
+
+	if w.layout.AutoWidth {
+		w.sample = &bytes.Buffer{}
+		w.sheet = w.sample
+		return nil
+	}
+
+	return w.createSheetEntry()
+}
+
+// SetHeader sets the first row of the sheet started with deferred header.
+func (w *xlsxWriter) SetHeader(cells []excelize.Cell) {
+	w.header = cells
+}
+
+// WriteRow appends a row to the current sheet.
+//
+// Cell i is written to the excel column i+1. Cells without value are skipped.
+func (w *xlsxWriter) WriteRow(cells []excelize.Cell) error {
+	if w.sheet == nil {
+		return xerrors.New("no sheet to write the row to")
+	}
+
+	w.lastRow++
+	w.lastColumn = max(w.lastColumn, len(cells))
+	if w.layout.AutoWidth && w.lastRow <= widthSampleRows {
+		w.widths.update(cells, w.numFmts)
+	}
+
+	w.buf.Reset()
+	w.encodeRow(w.lastRow, cells)
+
+	if _, err := w.sheet.Write(w.buf.Bytes()); err != nil {
+		return err
+	}
+
+	if w.sample != nil && w.lastRow == widthSampleRows {
+		return w.flushSample()
+	}
+	return nil
+}
+
+// flushSample starts the sheet entry once the widths of the columns are known and writes the sampled rows to it.
+func (w *xlsxWriter) flushSample() error {
+	sample := w.sample
+	w.sample = nil
+
+	if err := w.createSheetEntry(); err != nil {
+		return err
+	}
+	_, err := w.sheet.Write(sample.Bytes())
+	return err
+}
+
+// Close finishes the last sheet and writes the rest of the workbook.
+func (w *xlsxWriter) Close() error {
+	if len(w.sheets) == 0 {
+		if err := w.NewSheet(SheetName, false); err != nil {
+			return err
+		}
+	}
+
+	if err := w.finishSheet(); err != nil {
+		return err
+	}
+
+	for _, part := range []struct {
+		name  string
+		write func(b *bytes.Buffer)
+	}{
+		{name: "[Content_Types].xml", write: w.encodeContentTypes},
+		{name: "_rels/.rels", write: encodeRootRels},
+		{name: "xl/workbook.xml", write: w.encodeWorkbook},
+		{name: "xl/_rels/workbook.xml.rels", write: w.encodeWorkbookRels},
+		{name: "xl/styles.xml", write: w.encodeStyles},
+	} {
+		entry, err := w.zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+
+		w.buf.Reset()
+		part.write(&w.buf)
+		if _, err := entry.Write(w.buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return w.zw.Close()
+}
+
+// cleanup removes temporary files.
+//
+// It is safe to call cleanup multiple times.
+func (w *xlsxWriter) cleanup() {
+	if w.spool != nil {
+		w.spool.Remove()
+		w.spool = nil
+	}
+}
+
+func (w *xlsxWriter) createSheetEntry() error {
+	entry, err := w.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return err
+	}
+	w.sheet = entry
+
+	w.buf.Reset()
+	w.buf.WriteString(xmlHeader + `<worksheet xmlns="` + nsSpreadsheetML + `" xmlns:r="` + nsRelationships + `">`)
+
+	if rows := w.layout.headerRows; w.layout.Style && rows > 0 {
+		fmt.Fprintf(&w.buf, `<sheetViews><sheetView workbookViewId="0">`+
+			`<pane ySplit="%d" topLeftCell="A%d" activePane="bottomLeft" state="frozen"/>`+
+			`<selection pane="bottomLeft" activeCell="A%d" sqref="A%d"/>`+
+			`</sheetView></sheetViews>`, rows, rows+1, rows+1, rows+1)
+	}
+
+	if len(w.widths) > 0 {
+		w.buf.WriteString(`<cols>`)
+		for i, width := range w.widths {
+			if width > 0 {
+				fmt.Fprintf(&w.buf, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, width)
+			}
+		}
+		w.buf.WriteString(`</cols>`)
+	}
+
+	w.buf.WriteString(`<sheetData>`)
+	_, err = w.sheet.Write(w.buf.Bytes())
+	return err
+}
+
+func (w *xlsxWriter) finishSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+
+	if w.sample != nil {
+		if err := w.flushSample(); err != nil {
+			return err
+		}
+	}
+
+	if w.spool != nil {
+		defer w.cleanup()
+
+		if w.layout.AutoWidth {
+			w.widths.update(w.header, w.numFmts)
+		}
+		if err := w.createSheetEntry(); err != nil {
+			return err
+		}
+
+		w.buf.Reset()
+		w.encodeRow(1, w.header)
+		if _, err := w.sheet.Write(w.buf.Bytes()); err != nil {
+			return err
+		}
+
+		if err := w.spool.CopyTo(w.sheet); err != nil {
+			return err
+		}
+		w.header = nil
+	}
+
+	w.buf.Reset()
+	w.buf.WriteString(`</sheetData>`)
+	if w.layout.AutoFilter && w.layout.headerRows > 0 {
+		// Names are on the first row, the filter covers them along with all the rows below.
+		ref := "A1:" + w.cellName(max(w.lastColumn, len(w.header), 1), max(w.lastRow, 1))
+		w.filters[len(w.filters)-1] = ref
+		w.buf.WriteString(`<autoFilter ref="` + ref + `"/>`)
+	}
+	w.buf.WriteString(`</worksheet>`)
+
+	_, err := w.sheet.Write(w.buf.Bytes())
+	w.sheet = nil
+	w.header = nil
+	return err
+}
+
+func (w *xlsxWriter) cellName(col, row int) string {
+	for len(w.cellNames) < col {
+		name, _ := excelize.ColumnNumberToName(len(w.cellNames) + 1)
+		w.cellNames = append(w.cellNames, name)
+	}
+	return w.cellNames[col-1] + strconv.Itoa(row)
+}
+
+func (w *xlsxWriter) encodeRow(row int, cells []excelize.Cell) {
+	b := &w.buf
+
+	b.WriteString(`<row r="`)
+	b.WriteString(strconv.Itoa(row))
+	b.WriteString(`">`)
+
+	for i, cell := range cells {
+		if cell.Value == nil {
+			continue
+		}
+
+		b.WriteString(`<c r="`)
+		b.WriteString(w.cellName(i+1, row))
+		b.WriteByte('"')
+		styleID := cell.StyleID
+		if styleID == 0 && row <= w.layout.headerRows {
+			styleID = w.headerStyle
+		}
+		if styleID != 0 {
+			b.WriteString(` s="`)
+			b.WriteString(strconv.Itoa(styleID))
+			b.WriteByte('"')
+		}
+		encodeCellValue(b, cell.Value)
+		b.WriteString(`</c>`)
+	}
+
+	b.WriteString(`</row>`)
+}
+
+// encodeCellValue writes cell type attribute and the value element.
+func encodeCellValue(b *bytes.Buffer, v any) {
+	switch v := v.(type) {
+	case string:
+		encodeInlineString(b, v)
+	case []byte:
+		encodeInlineString(b, string(v))
+	case bool:
+		if v {
+			b.WriteString(` t="b"><v>1</v>`)
+		} else {
+			b.WriteString(` t="b"><v>0</v>`)
+		}
+	case float32:
+		encodeFloat(b, float64(v), 32)
+	case float64:
+		encodeFloat(b, v, 64)
+	default:
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			b.WriteString(`><v>`)
+			b.WriteString(strconv.FormatInt(rv.Int(), 10))
+			b.WriteString(`</v>`)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			b.WriteString(`><v>`)
+			b.WriteString(strconv.FormatUint(rv.Uint(), 10))
+			b.WriteString(`</v>`)
+		case reflect.Float32:
+			encodeFloat(b, rv.Float(), 32)
+		case reflect.Float64:
+			encodeFloat(b, rv.Float(), 64)
+		case reflect.String:
+			encodeInlineString(b, rv.String())
+		default:
+			encodeInlineString(b, fmt.Sprint(v))
+		}
+	}
+}
+
+func encodeFloat(b *bytes.Buffer, v float64, bitSize int) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		encodeInlineString(b, fmt.Sprint(v))
+		return
+	}
+
+	b.WriteString(`><v>`)
+	b.WriteString(strconv.FormatFloat(v, 'f', -1, bitSize))
+	b.WriteString(`</v>`)
+}
+
+func encodeInlineString(b *bytes.Buffer, s string) {
+	b.WriteString(` t="inlineStr"><is><t xml:space="preserve">`)
+	escapeString(b, s)
+	b.WriteString(`</t></is>`)
+}
+
+// escapeString writes s as xml text.
+//
+// Characters that are not allowed in xml 1.0 are escaped the way Excel does it, i.e. as _xHHHH_.
+// Underscores that start such sequences in the original string are escaped too,
+// so that the string is read back unchanged.
+// Invalid utf-8 bytes are replaced with U+FFFD.
+func escapeString(b *bytes.Buffer, s string) {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			b.WriteRune(utf8.RuneError)
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case r == '\r':
+			b.WriteString("&#xD;")
+		case r == '\t' || r == '\n':
+			b.WriteRune(r)
+		case r < 0x20 || r == 0xFFFE || r == 0xFFFF:
+			fmt.Fprintf(b, "_x%04X_", r)
+		case r == '_' && isEscapeSequence(s[i:]):
+			b.WriteString("_x005F_")
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+}
+
+// isEscapeSequence checks whether s starts with _xHHHH_.
+func isEscapeSequence(s string) bool {
+	if len(s) < 7 || s[0] != '_' || s[1] != 'x' || s[6] != '_' {
+		return false
+	}
+	for _, c := range []byte(s[2:6]) {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func escapeAttr(b *bytes.Buffer, s string) {
+	_ = xml.EscapeText(b, []byte(s))
+}
+
+func (w *xlsxWriter) encodeContentTypes(b *bytes.Buffer) {
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	for i := range w.sheets {
+		fmt.Fprintf(b, `<Override PartName="/xl/worksheets/sheet%d.xml" `+
+			`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`<Override PartName="/xl/styles.xml" ` +
+		`ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	b.WriteString(`</Types>`)
+}
+
+func encodeRootRels(b *bytes.Buffer) {
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="` + nsPackageRels + `">`)
+	b.WriteString(`<Relationship Id="rId1" Type="` + nsRelationships + `/officeDocument" Target="xl/workbook.xml"/>`)
+	b.WriteString(`</Relationships>`)
+}
+
+func (w *xlsxWriter) encodeWorkbook(b *bytes.Buffer) {
+	b.WriteString(xmlHeader)
+	b.WriteString(`<workbook xmlns="` + nsSpreadsheetML + `" xmlns:r="` + nsRelationships + `"><sheets>`)
+	for i, name := range w.sheets {
+		b.WriteString(`<sheet name="`)
+		escapeAttr(b, name)
+		fmt.Fprintf(b, `" sheetId="%d"`, i+1)
+		if w.hidden[i] {
+			b.WriteString(` state="hidden"`)
+		}
+		fmt.Fprintf(b, ` r:id="rId%d"/>`, i+1)
+	}
+	b.WriteString(`</sheets>`)
+
+	// Excel keeps the ranges of autofilters as hidden defined names.
+	hasFilters := false
+	for i, ref := range w.filters {
+		if ref == "" {
+			continue
+		}
+		if !hasFilters {
+			b.WriteString(`<definedNames>`)
+			hasFilters = true
+		}
+		fmt.Fprintf(b, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">`, i)
+		escapeString(b, "'"+strings.ReplaceAll(w.sheets[i], "'", "''")+"'!"+absoluteRef(ref))
+		b.WriteString(`</definedName>`)
+	}
+	if hasFilters {
+		b.WriteString(`</definedNames>`)
+	}
+	b.WriteString(`</workbook>`)
+}
+
+// absoluteRef turns range reference like A1:C10 into $A$1:$C$10.
+func absoluteRef(ref string) string {
+	cells := strings.Split(ref, ":")
+	for i, cell := range cells {
+		col, row, err := excelize.SplitCellName(cell)
+		if err == nil {
+			cells[i] = "$" + col + "$" + strconv.Itoa(row)
+		}
+	}
+	return strings.Join(cells, ":")
+}
+
+func (w *xlsxWriter) encodeWorkbookRels(b *bytes.Buffer) {
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="` + nsPackageRels + `">`)
+	for i := range w.sheets {
+		fmt.Fprintf(b, `<Relationship Id="rId%d" Type="`+nsRelationships+`/worksheet" `+
+			`Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(b, `<Relationship Id="rId%d" Type="`+nsRelationships+`/styles" Target="styles.xml"/>`,
+		len(w.sheets)+1)
+	b.WriteString(`</Relationships>`)
+}
+
+func (w *xlsxWriter) encodeStyles(b *bytes.Buffer) {
+	b.WriteString(xmlHeader)
+	b.WriteString(`<styleSheet xmlns="` + nsSpreadsheetML + `">`)
+
+	numFmtCount := 0
+	for _, numFmt := range w.numFmts {
+		if numFmt != "" {
+			numFmtCount++
+		}
+	}
+	if numFmtCount > 0 {
+		fmt.Fprintf(b, `<numFmts count="%d">`, numFmtCount)
+		for i, numFmt := range w.numFmts {
+			if numFmt == "" {
+				continue
+			}
+			fmt.Fprintf(b, `<numFmt numFmtId="%d" formatCode="`, firstCustomNumFmtID+i)
+			escapeAttr(b, numFmt)
+			b.WriteString(`"/>`)
+		}
+		b.WriteString(`</numFmts>`)
+	}
+
+	b.WriteString(`<fonts count="2"><font><sz val="11"/><name val="Calibri"/><family val="2"/></font>` +
+		`<font><b/><sz val="11"/><name val="Calibri"/><family val="2"/></font></fonts>`)
+	b.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill>` +
+		`<fill><patternFill patternType="gray125"/></fill></fills>`)
+	b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+
+	fmt.Fprintf(b, `<cellXfs count="%d">`, len(w.numFmts)+1)
+	b.WriteString(`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	for i, numFmt := range w.numFmts {
+		if i+1 == w.headerStyle {
+			b.WriteString(`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>`)
+			continue
+		}
+		numFmtID := 0
+		if numFmt != "" {
+			numFmtID = firstCustomNumFmtID + i
+		}
+		fmt.Fprintf(b, `<xf numFmtId="%d" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`,
+			numFmtID)
+	}
+	b.WriteString(`</cellXfs>`)
+
+	b.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	b.WriteString(`</styleSheet>`)
+}
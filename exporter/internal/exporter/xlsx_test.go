@@ -0,0 +1,127 @@
+package exporter
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := newXLSXWriter(&buf)
+	defer w.cleanup()
+
+	numberStyle := w.NewStyle("0")
+
+	require.NoError(t, w.NewSheet("first", false))
+	require.NoError(t, w.WriteRow([]excelize.Cell{{Value: "id"}, {Value: "value"}, {Value: "comment"}}))
+	require.NoError(t, w.WriteRow([]excelize.Cell{
+		{StyleID: numberStyle, Value: int64(-64)},
+		{Value: 0.5},
+		{Value: "a < b && c > d"},
+	}))
+	require.NoError(t, w.WriteRow([]excelize.Cell{
+		{StyleID: numberStyle, Value: uint64(64)},
+		{},
+		{Value: []byte("bytes")},
+	}))
+	require.NoError(t, w.WriteRow([]excelize.Cell{
+		{StyleID: numberStyle, Value: schema.Interval(42)},
+		{Value: true},
+		{Value: math.NaN()},
+	}))
+
+	require.NoError(t, w.NewSheet("second", true))
+	require.NoError(t, w.WriteRow([]excelize.Cell{{Value: "x"}}))
+	require.NoError(t, w.WriteRow([]excelize.Cell{{}, {Value: "y"}}))
+	w.SetHeader([]excelize.Cell{{Value: "a"}, {Value: "b"}})
+
+	require.NoError(t, w.Close())
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"first", "second"}, f.GetSheetList())
+
+	rows, err := f.GetRows("first")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"id", "value", "comment"},
+		{"-64", "0.5", "a < b && c > d"},
+		{"64", "", "bytes"},
+		{"42", "TRUE", "NaN"},
+	}, rows)
+
+	rows, err = f.GetRows("second")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"a", "b"},
+		{"x"},
+		{"", "y"},
+	}, rows)
+}
+
+func TestXLSXWriter_emptyWorkbook(t *testing.T) {
+	var buf bytes.Buffer
+	w := newXLSXWriter(&buf)
+	require.NoError(t, w.Close())
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	require.Equal(t, []string{SheetName}, f.GetSheetList())
+}
+
+func TestEscapeString(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      string
+		escaped string
+	}{
+		{name: "plain", in: "hello", escaped: "hello"},
+		{name: "xml", in: `<a href="x">&</a>`, escaped: `&lt;a href="x"&gt;&amp;&lt;/a&gt;`},
+		{name: "whitespace", in: "a\tb\nc\rd", escaped: "a\tb\nc&#xD;d"},
+		{name: "control", in: "\x01\x02", escaped: "_x0001__x0002_"},
+		{name: "escape-sequence", in: "_x0041_", escaped: "_x005F_x0041_"},
+		{name: "not-escape-sequence", in: "_x00G1_", escaped: "_x00G1_"},
+		{name: "invalid-utf8", in: "a\xffb", escaped: "a�b"},
+		{name: "unicode", in: "привет", escaped: "привет"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var b bytes.Buffer
+			escapeString(&b, tc.in)
+			require.Equal(t, tc.escaped, b.String())
+		})
+	}
+}
+
+func TestEscapeString_roundTrip(t *testing.T) {
+	values := []string{
+		"\x01\x02\x03\x04\x05\x06\x07\x08",
+		"_x0041_ and _x005F_",
+		"line\r\nbreak",
+		strings.Repeat("&", 100),
+	}
+
+	var buf bytes.Buffer
+	w := newXLSXWriter(&buf)
+	require.NoError(t, w.NewSheet(SheetName, false))
+	for _, v := range values {
+		require.NoError(t, w.WriteRow([]excelize.Cell{{Value: v}}))
+	}
+	require.NoError(t, w.Close())
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+
+	rows, err := f.GetRows(SheetName)
+	require.NoError(t, err)
+	for i, v := range values {
+		require.Equal(t, []string{v}, rows[i])
+	}
+}
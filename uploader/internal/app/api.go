@@ -1,11 +1,18 @@
 package app
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/xuri/excelize/v2"
@@ -14,12 +21,17 @@ import (
 	"go.ytsaurus.tech/library/go/core/log"
 	"go.ytsaurus.tech/library/go/core/metrics"
 	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/guid"
 	"go.ytsaurus.tech/yt/go/yt"
 	"go.ytsaurus.tech/yt/microservices/excel/uploader/internal/uploader"
 )
 
 const (
 	uploadFormName = "uploadfile"
+	// manifestFormName is the form name of the json manifest of the workbook upload.
+	manifestFormName = "manifest"
+
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 
 	// maxMemory is a max number of bytes of the upload file parts that could be stored in memory.
 	maxMemory = 32 << 20 // 32 mb
@@ -54,6 +66,7 @@ func (a *API) Routes() chi.Router {
 	r.Route("/upload", func(r chi.Router) {
 		r.Use(waitReady(&a.ready))
 		r.Post("/", a.uploadFile)
+		r.Post("/workbook", a.uploadWorkbook)
 	})
 
 	return r
@@ -113,6 +126,23 @@ func (a *API) uploadFile(w http.ResponseWriter, r *http.Request) {
 		types = ts[0] == "true"
 	}
 
+	profile := uploader.Profile(q.Get("profile"))
+	if profile != "" && !slices.Contains(uploader.Profiles, profile) {
+		err := xerrors.Errorf("unexpected profile: %q; expected one of %q", profile, uploader.Profiles)
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	if profile == uploader.ProfileRoundtrip {
+		if q.Has("columns") {
+			err := xerrors.Errorf("unable to use column mapping together with %q profile", profile)
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		// Roundtrip files always have names and types in the header.
+		header = true
+		types = true
+	}
+
 	columnMapping := make(map[string]string)
 	if columns, ok := q["columns"]; ok {
 		if header {
@@ -141,12 +171,55 @@ func (a *API) uploadFile(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	schemaMode := uploader.SchemaMode(q.Get("schema_mode"))
+	if schemaMode != "" && !slices.Contains(uploader.SchemaModes, schemaMode) {
+		err := xerrors.Errorf("unexpected schema mode: %q; expected one of %q", schemaMode, uploader.SchemaModes)
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	dryRun := q.Get("dry_run") == "true"
+	errorWorkbook := q.Get("error_workbook") == "true"
+
+	onError, maxErrors, err := parseErrorHandling(q)
+	if err != nil {
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	batchSize := uploader.DefaultBatchSize
+	if s := q.Get("batch_size"); s != "" {
+		var err error
+		batchSize, err = strconv.Atoi(s)
+		if err != nil || batchSize <= 0 {
+			err := xerrors.Errorf("invalid batch size %q; expected positive integer", s)
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+	}
+
+	txID, err := parseTransactionID(q)
+	if err != nil {
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
 	req, err := uploader.MakeUploadRequest(path, startRow, rowCount, sheet, header, types, columnMapping, appendRows, create)
 	if err != nil {
 		err = xerrors.Errorf("error parsing request: %w", err)
 		replyError(w, r, err, http.StatusBadRequest)
 		return
 	}
+	req.OnError = onError
+	req.MaxErrors = maxErrors
+	req.Update = q.Get("update") == "true"
+	req.Aggregate = q.Get("aggregate") == "true"
+	req.LockRows = q.Get("lock_rows") == "true"
+	req.BatchSize = batchSize
+	req.Profile = profile
+	req.SchemaMode = schemaMode
+	req.Cluster = a.conf.Proxy
+	req.TransactionID = txID
 	a.l.Info("parsed url params", log.Any("upload_request", req))
 
 	if err := r.ParseMultipartForm(maxMemory); err != nil {
@@ -156,23 +229,213 @@ func (a *API) uploadFile(w http.ResponseWriter, r *http.Request) {
 	}
 	defer func() { _ = r.MultipartForm.RemoveAll() }()
 
-	file, _, err := r.FormFile(uploadFormName)
+	data, filename, err := readFormData(r)
+	if err != nil {
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = data.Close() }()
+	req.Data = data
+
+	if dryRun {
+		a.dryRun(w, r, req)
+		return
+	}
+
+	result, err := uploader.Upload(r.Context(), a.yc, req)
+	if err != nil {
+		var convErr *uploader.ConversionError
+		if errorWorkbook && errors.As(err, &convErr) {
+			a.replyErrorWorkbook(w, r, err, req.Data, convErr, filename)
+			return
+		}
+		if errors.Is(err, uploader.ErrUnauthorized) {
+			replyError(w, r, err, http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, uploader.ErrBadRequest) {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		replyError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	replyJSON(w, http.StatusOK, result)
+}
+
+// uploadWorkbook uploads several sheets of excel file to static yt tables in a single transaction.
+//
+// Sheets and their tables are listed in the json manifest passed in the form along with the file.
+func (a *API) uploadWorkbook(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	onError, maxErrors, err := parseErrorHandling(q)
+	if err != nil {
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	txID, err := parseTransactionID(q)
+	if err != nil {
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	if err := r.ParseMultipartForm(maxMemory); err != nil {
+		err := xerrors.Errorf("unable to read request: %w", err)
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	manifest, err := uploader.ParseWorkbookManifest([]byte(r.FormValue(manifestFormName)))
+	if err != nil {
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	reqs, err := manifest.MakeUploadRequests()
+	if err != nil {
+		err = xerrors.Errorf("error parsing manifest: %w", err)
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	data, _, err := readFormData(r)
 	if err != nil {
-		fmt.Println(err)
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+	defer func() { _ = data.Close() }()
+
+	for _, req := range reqs {
+		req.OnError = onError
+		req.MaxErrors = maxErrors
+		req.Cluster = a.conf.Proxy
+		req.TransactionID = txID
+		req.Data = data
+	}
+	a.l.Info("parsed workbook manifest", log.Any("upload_requests", reqs))
+
+	result, err := uploader.UploadWorkbook(r.Context(), a.yc, reqs)
+	if err != nil {
+		if errors.Is(err, uploader.ErrUnauthorized) {
+			replyError(w, r, err, http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, uploader.ErrBadRequest) {
+			replyError(w, r, err, http.StatusBadRequest)
+			return
+		}
+		replyError(w, r, err, http.StatusInternalServerError)
 		return
 	}
+
+	replyJSON(w, http.StatusOK, result)
+}
+
+// parseErrorHandling parses on_error and max_errors params.
+func parseErrorHandling(q url.Values) (uploader.OnErrorMode, int, error) {
+	onError := uploader.OnErrorMode(q.Get("on_error"))
+	if onError == "" {
+		onError = uploader.OnErrorFail
+	}
+	if !slices.Contains(uploader.OnErrorModes, onError) {
+		return "", 0, xerrors.Errorf("unexpected on error mode: %q; expected one of %q", onError, uploader.OnErrorModes)
+	}
+
+	maxErrors := uploader.DefaultMaxErrors
+	if s := q.Get("max_errors"); s != "" {
+		var err error
+		maxErrors, err = strconv.Atoi(s)
+		if err != nil || maxErrors < 0 {
+			return "", 0, xerrors.Errorf("invalid max errors %q; expected non-negative integer", s)
+		}
+		if maxErrors > uploader.MaxErrorsLimit {
+			return "", 0, xerrors.Errorf("too many max errors %d; max is %d", maxErrors, uploader.MaxErrorsLimit)
+		}
+	}
+	return onError, maxErrors, nil
+}
+
+// parseTransactionID parses transaction_id param; returns zero id if it is not set.
+func parseTransactionID(q url.Values) (yt.TxID, error) {
+	s := q.Get("transaction_id")
+	if s == "" {
+		return yt.TxID{}, nil
+	}
+
+	id, err := guid.ParseString(s)
+	if err != nil {
+		return yt.TxID{}, xerrors.Errorf("invalid transaction id %q: %w", s, err)
+	}
+	return yt.TxID(id), nil
+}
+
+// readFormData reads the uploaded file from the parsed multipart form.
+//
+// Returns the workbook and the name of the file.
+func readFormData(r *http.Request) (*excelize.File, string, error) {
+	file, fileHeader, err := r.FormFile(uploadFormName)
+	if err != nil {
+		return nil, "", xerrors.Errorf("unable to read form file %q: %w", uploadFormName, err)
+	}
 	defer func() { _ = file.Close() }()
 
-	xlsx, err := excelize.OpenReader(file)
+	q := r.URL.Query()
+	format := uploader.Format(q.Get("format"))
+	if format == "" {
+		format = uploader.DetectFormat(fileHeader.Header.Get("Content-Type"), fileHeader.Filename)
+	}
+
+	data, err := readData(file, format, q.Get("delimiter"))
 	if err != nil {
-		err := xerrors.Errorf("unable to read excel file: %w", err)
+		return nil, "", err
+	}
+	return data, fileHeader.Filename, nil
+}
+
+// replyErrorWorkbook replies with the uploaded workbook with annotated conversion errors.
+//
+// The error is passed in the headers without the list of conversion errors; the Errors sheet lists them instead.
+func (a *API) replyErrorWorkbook(
+	w http.ResponseWriter,
+	r *http.Request,
+	err error,
+	data *excelize.File,
+	convErr *uploader.ConversionError,
+	filename string,
+) {
+	if annotateErr := uploader.AnnotateErrors(data, convErr.Stats); annotateErr != nil {
+		a.l.Error("error annotating workbook", log.Error(annotateErr))
 		replyError(w, r, err, http.StatusBadRequest)
 		return
 	}
-	defer func() { _ = xlsx.Close() }()
-	req.Data = xlsx
 
-	if err := uploader.Upload(r.Context(), a.yc, req); err != nil {
+	var buf bytes.Buffer
+	if writeErr := data.Write(&buf); writeErr != nil {
+		a.l.Error("error writing annotated workbook", log.Error(writeErr))
+		replyError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if name == "" {
+		name = "upload"
+	}
+
+	setErrorHeaders(w, r, err)
+	w.Header().Set("Content-Type", xlsxContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"-errors.xlsx"))
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// dryRun checks the upload request and replies with the json report.
+func (a *API) dryRun(w http.ResponseWriter, r *http.Request, req *uploader.UploadRequest) {
+	report, err := uploader.DryRun(r.Context(), a.yc, req)
+	if err != nil {
 		if errors.Is(err, uploader.ErrUnauthorized) {
 			replyError(w, r, err, http.StatusUnauthorized)
 			return
@@ -184,4 +447,48 @@ func (a *API) uploadFile(w http.ResponseWriter, r *http.Request) {
 		replyError(w, r, err, http.StatusInternalServerError)
 		return
 	}
+
+	replyJSON(w, http.StatusOK, report)
+}
+
+func replyJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// readData reads uploaded file of the given format into a workbook.
+func readData(file io.Reader, format uploader.Format, delimiter string) (*excelize.File, error) {
+	if delimiter != "" && format != uploader.FormatCSV {
+		return nil, xerrors.Errorf("delimiter is supported only for %q format", uploader.FormatCSV)
+	}
+
+	switch format {
+	case uploader.FormatXLSX:
+		xlsx, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to read excel file: %w", err)
+		}
+		return xlsx, nil
+	case uploader.FormatCSV, uploader.FormatTSV:
+		comma := ','
+		if format == uploader.FormatTSV {
+			comma = '\t'
+		}
+		if delimiter != "" {
+			d := []rune(delimiter)
+			if len(d) != 1 || d[0] == '"' || d[0] == '\r' || d[0] == '\n' || d[0] == utf8.RuneError {
+				return nil, xerrors.Errorf("invalid delimiter %q; expected a single character", delimiter)
+			}
+			comma = d[0]
+		}
+
+		data, err := uploader.ReadCSV(file, comma)
+		if err != nil {
+			return nil, xerrors.Errorf("unable to read %s file: %w", format, err)
+		}
+		return data, nil
+	default:
+		return nil, xerrors.Errorf("unexpected format: %q; expected one of %q", format, uploader.Formats)
+	}
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"maps"
 	"net/http"
 	"os"
 	"strconv"
@@ -21,6 +22,7 @@ import (
 	"go.ytsaurus.tech/yt/go/proto/core/rpc"
 	"go.ytsaurus.tech/yt/go/yt"
 	"go.ytsaurus.tech/yt/go/yterrors"
+	"go.ytsaurus.tech/yt/microservices/excel/uploader/internal/uploader"
 )
 
 const (
@@ -194,17 +196,41 @@ func ForwardUserTicket(next http.Handler) http.Handler {
 var host, _ = os.Hostname()
 
 func replyError(w http.ResponseWriter, r *http.Request, err error, status int) {
+	ytErr := setErrorHeaders(w, r, err)
+
+	w.WriteHeader(status)
+
+	js, _ := json.MarshalIndent(ytErr, "", "  ")
+	_, _ = w.Write(js)
+}
+
+// setErrorHeaders adds the error to the response headers.
+//
+// Lists of conversion errors are left out of the header, since headers are limited in size;
+// the message still has the counts and the first error.
+func setErrorHeaders(w http.ResponseWriter, r *http.Request, err error) *yterrors.Error {
 	ytErr := yterrors.FromError(err).(*yterrors.Error)
 	ytErr.AddAttr("host", host)
 	ytErr.AddAttr("request_id", contextRequestID(r.Context()))
 
-	js, _ := json.Marshal(ytErr)
+	js, _ := json.Marshal(headerError(ytErr))
 	w.Header().Add(xYTError, string(js))
 	w.Header().Add(xYTResponseCode, strconv.Itoa(int(ytErr.Code)))
 	w.Header().Add(xYTResponseMessage, ytErr.Message)
+	return ytErr
+}
 
-	w.WriteHeader(status)
+// headerError returns a copy of the error and its inner errors without conversion error lists.
+func headerError(ytErr *yterrors.Error) *yterrors.Error {
+	e := *ytErr
+	if _, ok := e.Attributes[uploader.ConversionErrorsAttr]; ok {
+		e.Attributes = maps.Clone(e.Attributes)
+		delete(e.Attributes, uploader.ConversionErrorsAttr)
+	}
 
-	js, _ = json.MarshalIndent(ytErr, "", "  ")
-	_, _ = w.Write(js)
+	e.InnerErrors = nil
+	for _, inner := range ytErr.InnerErrors {
+		e.InnerErrors = append(e.InnerErrors, headerError(inner))
+	}
+	return &e
 }
@@ -0,0 +1,214 @@
+package uploader
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+)
+
+const (
+	// ErrorsSheetName is a name of the summary sheet added by AnnotateErrors.
+	ErrorsSheetName = "Errors"
+
+	errorCommentAuthor = "YTsaurus"
+	errorFillColor     = "FF9999"
+
+	// maxCommentLen is the max length of the excel comment text.
+	maxCommentLen = 32512
+)
+
+// AnnotateErrors marks cells that can not be converted in the workbook of the failed upload.
+//
+// Each bad cell is filled red and gets a comment with conversion errors.
+// Errors are also listed on a separate summary sheet with links to the cells.
+func AnnotateErrors(f *excelize.File, stats *RowStats) error {
+	var cells []string
+	cellErrors := make(map[string][]*CellError)
+	for _, e := range stats.Errors {
+		ref := fmt.Sprintf("%s!%s", e.Sheet, e.Cell)
+		if _, ok := cellErrors[ref]; !ok {
+			cells = append(cells, ref)
+		}
+		cellErrors[ref] = append(cellErrors[ref], e)
+	}
+
+	styles := make(map[int]int)
+	comments := make(map[string]map[string]excelize.Comment)
+	for _, ref := range cells {
+		errs := cellErrors[ref]
+		sheet, cell := errs[0].Sheet, errs[0].Cell
+
+		if err := markErrorCell(f, sheet, cell, styles); err != nil {
+			return err
+		}
+
+		sheetComments, ok := comments[sheet]
+		if !ok {
+			var err error
+			sheetComments, err = readComments(f, sheet)
+			if err != nil {
+				return err
+			}
+			comments[sheet] = sheetComments
+		}
+
+		if err := addErrorComment(f, sheet, cell, sheetComments[cell], errs); err != nil {
+			return err
+		}
+	}
+
+	return addErrorsSheet(f, stats)
+}
+
+// markErrorCell fills the cell red preserving the rest of its style, e.g. number format.
+//
+// styles caches red styles by original style ids.
+func markErrorCell(f *excelize.File, sheet, cell string, styles map[int]int) error {
+	styleID, err := f.GetCellStyle(sheet, cell)
+	if err != nil {
+		return xerrors.Errorf("unable to read style of cell %s: %w", cell, err)
+	}
+
+	errorStyleID, ok := styles[styleID]
+	if !ok {
+		style, err := f.GetStyle(styleID)
+		if err != nil {
+			return xerrors.Errorf("unable to read style %d: %w", styleID, err)
+		}
+		style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{errorFillColor}}
+
+		errorStyleID, err = f.NewStyle(style)
+		if err != nil {
+			return xerrors.Errorf("unable to create error style: %w", err)
+		}
+		styles[styleID] = errorStyleID
+	}
+
+	if err := f.SetCellStyle(sheet, cell, cell, errorStyleID); err != nil {
+		return xerrors.Errorf("unable to set style of cell %s: %w", cell, err)
+	}
+	return nil
+}
+
+func readComments(f *excelize.File, sheet string) (map[string]excelize.Comment, error) {
+	list, err := f.GetComments(sheet)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to read comments of sheet %q: %w", sheet, err)
+	}
+
+	comments := make(map[string]excelize.Comment)
+	for _, c := range list {
+		comments[c.Cell] = c
+	}
+	return comments, nil
+}
+
+// addErrorComment adds conversion errors to the cell comment keeping the original comment text.
+func addErrorComment(f *excelize.File, sheet, cell string, existing excelize.Comment, errs []*CellError) error {
+	var text strings.Builder
+	if existing.Cell != "" {
+		text.WriteString(commentText(existing))
+		text.WriteString("\n")
+
+		if err := f.DeleteComment(sheet, cell); err != nil {
+			return xerrors.Errorf("unable to replace comment of cell %s: %w", cell, err)
+		}
+	}
+
+	for i, e := range errs {
+		if i > 0 {
+			text.WriteString("\n")
+		}
+		fmt.Fprintf(&text, "Column %q (%s): %s", e.Column, e.Type, e.Message)
+	}
+
+	comment := text.String()
+	if len(comment) > maxCommentLen {
+		comment = strings.ToValidUTF8(comment[:maxCommentLen], "")
+	}
+
+	err := f.AddComment(sheet, excelize.Comment{
+		Cell:   cell,
+		Author: errorCommentAuthor,
+		Text:   comment,
+		Width:  300,
+		Height: 80,
+	})
+	if err != nil {
+		return xerrors.Errorf("unable to add comment to cell %s: %w", cell, err)
+	}
+	return nil
+}
+
+// commentText returns plain text of the comment.
+func commentText(c excelize.Comment) string {
+	text := c.Text
+	for _, r := range c.Paragraph {
+		text += r.Text
+	}
+	return text
+}
+
+// addErrorsSheet adds a sheet listing conversion errors.
+//
+// The sheet gets a numeric suffix if the workbook already has a sheet with the same name.
+func addErrorsSheet(f *excelize.File, stats *RowStats) error {
+	name := ErrorsSheetName
+	for i := 2; ; i++ {
+		index, err := f.GetSheetIndex(name)
+		if err != nil {
+			return err
+		}
+		if index == -1 {
+			break
+		}
+		name = fmt.Sprintf("%s (%d)", ErrorsSheetName, i)
+	}
+
+	if _, err := f.NewSheet(name); err != nil {
+		return xerrors.Errorf("unable to create errors sheet: %w", err)
+	}
+
+	header := []any{"Sheet", "Cell", "Column", "Type", "Value", "Error"}
+	if err := f.SetSheetRow(name, "A1", &header); err != nil {
+		return err
+	}
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(name, "A1", "F1", bold); err != nil {
+		return err
+	}
+
+	for i, e := range stats.Errors {
+		row := []any{e.Sheet, e.Cell, e.Column, e.Type, e.Value, e.Message}
+		axis := fmt.Sprintf("A%d", i+2)
+		if err := f.SetSheetRow(name, axis, &row); err != nil {
+			return err
+		}
+
+		link := fmt.Sprintf("'%s'!%s", strings.ReplaceAll(e.Sheet, "'", "''"), e.Cell)
+		if err := f.SetCellHyperLink(name, fmt.Sprintf("B%d", i+2), link, "Location"); err != nil {
+			return err
+		}
+	}
+
+	if int64(len(stats.Errors)) < stats.ErrorCount {
+		note := fmt.Sprintf("%d more error(s) are not listed", stats.ErrorCount-int64(len(stats.Errors)))
+		if err := f.SetCellStr(name, fmt.Sprintf("A%d", len(stats.Errors)+2), note); err != nil {
+			return err
+		}
+	}
+
+	for col, width := range map[string]float64{"A": 16, "B": 8, "C": 16, "D": 16, "E": 24, "F": 80} {
+		if err := f.SetColWidth(name, col, col, width); err != nil {
+			return err
+		}
+	}
+	return nil
+}
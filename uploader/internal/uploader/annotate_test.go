@@ -0,0 +1,78 @@
+package uploader
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestAnnotateErrors(t *testing.T) {
+	f := makeExcelFile(t, table{
+		"A1": "x", "B1": 1.5,
+		"A2": "y",
+	})
+
+	numFmt, err := f.NewStyle(&excelize.Style{NumFmt: 2})
+	require.NoError(t, err)
+	require.NoError(t, f.SetCellStyle(testSheet, "B1", "B1", numFmt))
+	require.NoError(t, f.AddComment(testSheet, excelize.Comment{Cell: "A2", Author: "user", Text: "check me"}))
+	_, err = f.NewSheet(ErrorsSheetName)
+	require.NoError(t, err)
+
+	stats := &RowStats{
+		ErrorCount: 5,
+		Errors: []*CellError{
+			{Sheet: testSheet, Cell: "A1", Column: "id", Type: "int64", Value: "x", Message: "bad int"},
+			{Sheet: testSheet, Cell: "A1", Column: "id2", Type: "uint64", Value: "x", Message: "bad uint"},
+			{Sheet: testSheet, Cell: "B1", Column: "flag", Type: "boolean", Value: "1.5", Message: "bad bool"},
+			{Sheet: testSheet, Cell: "A2", Column: "id", Type: "int64", Value: "y", Message: "bad int"},
+		},
+	}
+
+	require.NoError(t, AnnotateErrors(f, stats))
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+	f, err = excelize.OpenReader(&buf)
+	require.NoError(t, err)
+
+	for _, cell := range []string{"A1", "B1", "A2"} {
+		styleID, err := f.GetCellStyle(testSheet, cell)
+		require.NoError(t, err)
+		style, err := f.GetStyle(styleID)
+		require.NoError(t, err)
+		require.Equal(t, []string{errorFillColor}, style.Fill.Color, cell)
+		if cell == "B1" {
+			require.Equal(t, 2, style.NumFmt, "number format is preserved")
+		}
+	}
+
+	comments, err := f.GetComments(testSheet)
+	require.NoError(t, err)
+	texts := make(map[string]string)
+	for _, c := range comments {
+		texts[c.Cell] = commentText(c)
+	}
+	require.Contains(t, texts["A1"], `Column "id" (int64): bad int`)
+	require.Contains(t, texts["A1"], `Column "id2" (uint64): bad uint`)
+	require.Contains(t, texts["A2"], "check me")
+	require.Contains(t, texts["A2"], "bad int")
+
+	rows, err := f.GetRows(ErrorsSheetName + " (2)")
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"Sheet", "Cell", "Column", "Type", "Value", "Error"},
+		{testSheet, "A1", "id", "int64", "x", "bad int"},
+		{testSheet, "A1", "id2", "uint64", "x", "bad uint"},
+		{testSheet, "B1", "flag", "boolean", "1.5", "bad bool"},
+		{testSheet, "A2", "id", "int64", "y", "bad int"},
+		{"1 more error(s) are not listed"},
+	}, rows)
+
+	link, target, err := f.GetCellHyperLink(ErrorsSheetName+" (2)", "B3")
+	require.NoError(t, err)
+	require.True(t, link)
+	require.Equal(t, "'Sheet1'!A1", target)
+}
@@ -0,0 +1,128 @@
+package uploader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+)
+
+// Format is a format of the uploaded file.
+type Format string
+
+const (
+	FormatXLSX Format = "xlsx"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+)
+
+// Formats lists all supported formats.
+var Formats = []Format{FormatXLSX, FormatCSV, FormatTSV}
+
+// DetectFormat determines format of the uploaded file by its content type or, if it is not specific, by file extension.
+//
+// Excel is used by default.
+func DetectFormat(contentType, filename string) Format {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch mediaType {
+		case "text/csv":
+			return FormatCSV
+		case "text/tab-separated-values":
+			return FormatTSV
+		case "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":
+			return FormatXLSX
+		}
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return FormatCSV
+	case ".tsv", ".tab":
+		return FormatTSV
+	default:
+		return FormatXLSX
+	}
+}
+
+// utf8BOM is a byte order mark that is prepended to csv files by Excel.
+const utf8BOM = "\uFEFF"
+
+// ReadCSV reads delimiter-separated values into a workbook with a single sheet.
+//
+// Cells are stored as strings, so the file is uploaded the same way as an excel file with textual cells,
+// e.g. column A corresponds to the first field of each record.
+func ReadCSV(r io.Reader, delimiter rune) (*excelize.File, error) {
+	br := bufio.NewReader(r)
+	if prefix, err := br.Peek(len(utf8BOM)); err == nil && string(prefix) == utf8BOM {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = delimiter
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	var values []any
+	for row := 1; ; row++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			_ = f.Close()
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("unable to read record: %w", err))
+		}
+
+		if row > ExcelMaxRowCount {
+			_ = f.Close()
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("too many rows to upload; max is %d", ExcelMaxRowCount))
+		}
+
+		if len(record) > ExcelMaxColCount {
+			_ = f.Close()
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d on line %d",
+				ExcelMaxColCount, row))
+		}
+
+		values = values[:0]
+		for i, field := range record {
+			if utf8.RuneCountInString(field) > excelize.TotalCellChars {
+				cell, _ := excelize.CoordinatesToCellName(i+1, row)
+				_ = f.Close()
+				return nil, ErrBadRequest.Wrap(xerrors.Errorf("value of cell %s exceeds max length %d",
+					cell, excelize.TotalCellChars))
+			}
+			values = append(values, field)
+		}
+
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		if err := sw.SetRow(cell, values); err != nil {
+			_ = f.Close()
+			return nil, xerrors.Errorf("unable to write row %d: %w", row, err)
+		}
+	}
+
+	if err := sw.Flush(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return f, nil
+}
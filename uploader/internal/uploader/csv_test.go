@@ -0,0 +1,91 @@
+package uploader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestDetectFormat(t *testing.T) {
+	for _, tc := range []struct {
+		contentType string
+		filename    string
+		expected    Format
+	}{
+		{contentType: "text/csv; charset=utf-8", filename: "data.xlsx", expected: FormatCSV},
+		{contentType: "text/tab-separated-values", filename: "data", expected: FormatTSV},
+		{contentType: "application/octet-stream", filename: "data.CSV", expected: FormatCSV},
+		{contentType: "", filename: "data.tsv", expected: FormatTSV},
+		{contentType: "", filename: "data.xlsx", expected: FormatXLSX},
+		{contentType: "", filename: "data", expected: FormatXLSX},
+	} {
+		t.Run(tc.contentType+"/"+tc.filename, func(t *testing.T) {
+			require.Equal(t, tc.expected, DetectFormat(tc.contentType, tc.filename))
+		})
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		data      string
+		delimiter rune
+		expected  [][]string
+		error     bool
+	}{
+		{
+			name:      "csv",
+			data:      "\uFEFFid,name\r\n1,\"a, \"\"b\"\"\"\r\n2,\"multi\nline\"\r\n",
+			delimiter: ',',
+			expected:  [][]string{{"id", "name"}, {"1", `a, "b"`}, {"2", "multi\nline"}},
+		},
+		{
+			name:      "tsv",
+			data:      "id\tname\n1\tx\n3\n",
+			delimiter: '\t',
+			expected:  [][]string{{"id", "name"}, {"1", "x"}, {"3"}},
+		},
+		{
+			name:      "semicolon",
+			data:      "1;;3\n",
+			delimiter: ';',
+			expected:  [][]string{{"1", "", "3"}},
+		},
+		{
+			name:      "bad-quotes",
+			data:      "1,\"a\"b\n",
+			delimiter: ',',
+			error:     true,
+		},
+		{
+			name:      "too-long-cell",
+			data:      strings.Repeat("a", excelize.TotalCellChars+1),
+			delimiter: ',',
+			error:     true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := ReadCSV(strings.NewReader(tc.data), tc.delimiter)
+			if tc.error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			defer func() { _ = f.Close() }()
+
+			rows, err := f.GetRows(testSheet, excelize.Options{RawCellValue: true})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, rows)
+		})
+	}
+}
+
+func readCSVFile(t *testing.T, data string) *excelize.File {
+	t.Helper()
+
+	f, err := ReadCSV(strings.NewReader(data), ',')
+	require.NoError(t, err)
+	return f
+}
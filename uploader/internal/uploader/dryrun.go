@@ -0,0 +1,177 @@
+package uploader
+
+import (
+	"context"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+)
+
+// DryRunReport describes the upload that would be executed for the request.
+type DryRunReport struct {
+	Path  ypath.Path `json:"path"`
+	Sheet string     `json:"sheet"`
+	// Create is true if the table would be created with the inferred schema.
+	Create bool `json:"create"`
+	// Schema is the schema of the existing table or the schema inferred from the sheet.
+	Schema []ReportColumn `json:"schema"`
+	// Columns maps YT columns to excel columns.
+	Columns map[string]string `json:"columns"`
+	// Origin describes the table the file is exported from if the exporter recorded it.
+	Origin *FileOrigin `json:"origin,omitempty"`
+
+	RowStats
+}
+
+// ReportColumn describes a column of the table schema.
+type ReportColumn struct {
+	Name string `json:"name"`
+	// Type is a simple type name or yson type_v3 description of the column type without optional.
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+	// Added is true for the columns that would be added to the table in SchemaModeExtend.
+	Added bool `json:"added,omitempty"`
+}
+
+// makeReport describes the columns of the schema.
+func makeReport(s *schema.Schema) []ReportColumn {
+	columns := make([]ReportColumn, 0, len(s.Columns))
+	for _, col := range s.Columns {
+		columns = append(columns, makeReportColumn(col))
+	}
+	return columns
+}
+
+func makeReportColumn(col schema.Column) ReportColumn {
+	t := columnType(col)
+	o, optional := t.(schema.Optional)
+	if optional {
+		t = o.Item
+	}
+	return ReportColumn{Name: col.Name, Type: formatType(t), Required: !optional}
+}
+
+// DryRun checks the upload request without writing anything.
+//
+// All cells of the requested range are converted as in Upload and up to req.MaxErrors conversion errors
+// are listed in the report.
+func DryRun(ctx context.Context, yc yt.Client, req *UploadRequest) (*DryRunReport, error) {
+	if err := req.EnsureSheetName(); err != nil {
+		return nil, xerrors.Errorf("unable to ensure sheet name: %w", err)
+	}
+
+	origin, err := req.readOrigin()
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := dryRun(ctx, yc, req)
+	if err != nil {
+		return nil, req.wrapParentTxError(err)
+	}
+	report.Origin = origin
+	return report, nil
+}
+
+func dryRun(ctx context.Context, yc yt.Client, req *UploadRequest) (*DryRunReport, error) {
+	if err := req.checkSchemaMode(); err != nil {
+		return nil, err
+	}
+
+	parent, err := req.attachParentTx(ctx, yc)
+	if err != nil {
+		return nil, err
+	}
+
+	// The table is read within the transaction of the request if it is set.
+	var cypress yt.CypressClient = yc
+	if parent != nil {
+		cypress = parent
+	}
+
+	var s *schema.Schema
+	var dynamic bool
+	if req.create {
+		ok, err := cypress.NodeExists(ctx, req.Path, nil)
+		if err != nil {
+			if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
+				return nil, ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when checking table %q: %w", req.Path, err))
+			}
+			return nil, xerrors.Errorf("error checking table %q: %w", req.Path, err)
+		}
+		if ok {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("table %q already exists", req.Path))
+		}
+
+		s, err = MakeSchema(req)
+		if err != nil {
+			return nil, xerrors.Errorf("error inferring schema from excel table: %w", err)
+		}
+	} else {
+		s, err = readTableSchema(ctx, cypress, req.Path)
+		if err != nil {
+			return nil, err
+		}
+		if dynamic, err = readDynamic(ctx, cypress, req.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	if dynamic && parent != nil {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("transaction is not supported for dynamic tables"))
+	}
+	// Columns that would be added in extend mode are reported and checked along with the existing ones.
+	existing := len(s.Columns)
+	if dynamic {
+		if err := req.checkDynamicRequest(s); err != nil {
+			return nil, err
+		}
+		if err := req.checkDynamicRowCount(); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := req.checkStaticRequest(); err != nil {
+			return nil, err
+		}
+		if req.SchemaMode == SchemaModeExtend {
+			if s, err = req.extendSchema(s); err != nil {
+				return nil, err
+			}
+		}
+		if err := req.checkColumnMapping(s); err != nil {
+			return nil, err
+		}
+	}
+
+	report, err := checkRows(req, s)
+	if err != nil {
+		return nil, err
+	}
+	for i := existing; i < len(report.Schema); i++ {
+		report.Schema[i].Added = true
+	}
+	return report, nil
+}
+
+// checkRows converts the requested rows and reports conversion errors.
+func checkRows(req *UploadRequest, s *schema.Schema) (*DryRunReport, error) {
+	report := &DryRunReport{
+		Path:    req.Path,
+		Sheet:   req.Sheet,
+		Create:  req.create,
+		Schema:  makeReport(s),
+		Columns: req.Columns,
+	}
+
+	err := convertRows(req, s, &report.RowStats, func(row map[string]any) error {
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
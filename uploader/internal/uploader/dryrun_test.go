@@ -0,0 +1,54 @@
+package uploader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+func TestCheckRows(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "date", Type: schema.TypeDate},
+		{Name: "tags", ComplexType: schema.Optional{Item: schema.List{Item: schema.TypeString}}},
+	}}
+
+	req := &UploadRequest{
+		Sheet:     testSheet,
+		StartRow:  2,
+		RowCount:  ExcelMaxRowCount,
+		Columns:   map[string]string{"id": "A", "date": "B", "tags": "C"},
+		MaxErrors: 2,
+		Data: makeExcelFile(t, table{
+			"A1": "id", "B1": "date", "C1": "tags",
+			"A2": 1, "B2": "2000-01-01", "C2": "[a;b]",
+			"A3": "x", "B3": "bad", "C3": "[a;b]",
+			"A4": 3,
+			"A5": 4, "B5": "2000-01-02", "C5": "[",
+		}),
+	}
+
+	report, err := checkRows(req, s)
+	require.NoError(t, err)
+
+	require.Equal(t, []ReportColumn{
+		{Name: "id", Type: "int64", Required: true},
+		{Name: "date", Type: "date"},
+		{Name: "tags", Type: `{"type_name"=list;item=utf8;}`},
+	}, report.Schema)
+	require.Equal(t, int64(4), report.RowCount)
+	require.Equal(t, int64(2), report.InvalidRowCount)
+	require.Equal(t, int64(3), report.ErrorCount)
+
+	require.Len(t, report.Errors, 2)
+	require.Equal(t, "A3", report.Errors[0].Cell)
+	require.Equal(t, 3, report.Errors[0].Row)
+	require.Equal(t, "id", report.Errors[0].Column)
+	require.Equal(t, "int64", report.Errors[0].Type)
+	require.Equal(t, "x", report.Errors[0].Value)
+	require.Equal(t, testSheet, report.Errors[0].Sheet)
+	require.Equal(t, "B3", report.Errors[1].Cell)
+	require.Equal(t, "date", report.Errors[1].Column)
+}
@@ -0,0 +1,297 @@
+package uploader
+
+import (
+	"context"
+	"slices"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+)
+
+// DefaultBatchSize is the default number of rows inserted into dynamic table by a single request.
+const DefaultBatchSize = 10000
+
+// MaxDynamicRowCount is the max number of rows uploaded into dynamic table.
+//
+// All rows are inserted in a single tablet transaction, and yt limits the number of rows changed
+// by a transaction with max_rows_per_transaction of 100000 by default.
+const MaxDynamicRowCount = 100000
+
+// uploadDynamic inserts rows into dynamic table in a single tablet transaction.
+//
+// Sheets of more than MaxDynamicRowCount rows are rejected before the transaction starts.
+func uploadDynamic(ctx context.Context, yc yt.Client, req *UploadRequest) (*UploadResult, error) {
+	s, err := readTableSchema(ctx, yc, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.checkDynamicRequest(s); err != nil {
+		return nil, err
+	}
+	if err := req.checkDynamicRowCount(); err != nil {
+		return nil, err
+	}
+
+	var locks []string
+	if req.LockRows {
+		locks = unmappedLocks(req, s)
+	}
+
+	tx, err := yc.BeginTabletTx(ctx, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to start tablet transaction: %w", err)
+	}
+	defer func() { _ = tx.Abort() }()
+
+	out := &tabletWriter{
+		ctx:        ctx,
+		tx:         tx,
+		path:       req.Path,
+		batchSize:  req.BatchSize,
+		keyColumns: keyColumnNames(s),
+		locks:      locks,
+		opts: &yt.InsertRowsOptions{
+			Update:    &req.Update,
+			Aggregate: &req.Aggregate,
+		},
+	}
+	if out.batchSize <= 0 {
+		out.batchSize = DefaultBatchSize
+	}
+
+	result, err := upload(req, s, out)
+	if err != nil {
+		return nil, xerrors.Errorf("error uploading %s: %w", req, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
+			return nil, ErrUnauthorized.Wrap(err)
+		}
+		return nil, xerrors.Errorf("unable to commit tablet transaction: %w", err)
+	}
+	return result, nil
+}
+
+// checkDynamicRequest checks that the request and its column mapping are suitable
+// for inserting rows into dynamic table.
+//
+// Rows are always inserted, so the request must append them; dynamic tables can not be overwritten.
+// All key columns must be mapped, computed columns must not; the default mapping skips computed columns.
+// With Update only a subset of columns may be mapped. With LockRows some lock group must be left unmapped.
+func (r *UploadRequest) checkDynamicRequest(s *schema.Schema) error {
+	if !r.append {
+		return ErrBadRequest.Wrap(xerrors.Errorf("dynamic table %q can not be overwritten; use append mode to insert rows", r.Path))
+	}
+	if r.SchemaMode != "" && r.SchemaMode != SchemaModeStrict {
+		return ErrBadRequest.Wrap(xerrors.Errorf("schema mode %q is supported only for static tables", r.SchemaMode))
+	}
+
+	if len(r.Columns) == 0 {
+		var err error
+		if r.Header {
+			err = r.makeColumnMappingFromHeader(s)
+		} else {
+			err = r.makeDefaultColumnMapping(writableColumns(s))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if (r.Update || r.LockRows) && len(keyColumnNames(s)) == 0 {
+		return ErrBadRequest.Wrap(xerrors.Errorf("update and row locks are supported only for sorted dynamic tables"))
+	}
+
+	writable := writableColumns(s)
+	for name := range r.Columns {
+		if !slices.ContainsFunc(writable, func(c schema.Column) bool { return c.Name == name }) {
+			return ErrBadRequest.Wrap(xerrors.Errorf("column %q is computed or missing in the table schema", name))
+		}
+	}
+
+	for _, name := range keyColumnNames(s) {
+		if _, ok := r.Columns[name]; !ok {
+			return ErrBadRequest.Wrap(xerrors.Errorf("key column %q is not mapped to any excel column", name))
+		}
+	}
+
+	if !r.Update && len(r.Columns) != len(writable) {
+		err := xerrors.Errorf("schema has %d column(s), request - %d; use update mode to upload a subset of columns",
+			len(writable), len(r.Columns))
+		return ErrBadRequest.Wrap(err)
+	}
+
+	if r.LockRows && len(unmappedLocks(r, s)) == 0 {
+		return ErrBadRequest.Wrap(xerrors.Errorf("table %q has no lock groups of columns that are not uploaded", r.Path))
+	}
+
+	if len(r.Columns) > ExcelMaxColCount {
+		return ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d", ExcelMaxColCount))
+	}
+	return nil
+}
+
+// checkStaticRequest checks that the request does not use options supported only for dynamic tables.
+func (r *UploadRequest) checkStaticRequest() error {
+	if r.Update || r.Aggregate || r.LockRows {
+		return ErrBadRequest.Wrap(xerrors.Errorf("update, aggregate and row locks are supported only for dynamic tables"))
+	}
+	return nil
+}
+
+// checkDynamicRowCount checks that the requested rows fit into a single tablet transaction.
+func (r *UploadRequest) checkDynamicRowCount() error {
+	n, err := r.countRows()
+	if err != nil {
+		return err
+	}
+	if n > MaxDynamicRowCount {
+		return ErrBadRequest.Wrap(xerrors.Errorf("too many rows to upload into dynamic table: %d; max is %d", n, MaxDynamicRowCount))
+	}
+	return nil
+}
+
+// countRows returns the number of the requested rows of the sheet selected the same way as in convertRows.
+func (r *UploadRequest) countRows() (int64, error) {
+	rows, err := r.Data.Rows(r.Sheet)
+	if err != nil {
+		return 0, ErrBadRequest.Wrap(xerrors.Errorf("unable to read rows of sheet %q: %w", r.Sheet, err))
+	}
+	defer func() { _ = rows.Close() }()
+
+	var n int64
+	for i := 1; rows.Next(); i++ {
+		if !r.allRows && int64(i) < r.StartRow {
+			continue
+		}
+		if !r.allRows && int64(i) >= r.StartRow+r.RowCount {
+			break
+		}
+
+		row, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return 0, ErrBadRequest.Wrap(xerrors.Errorf("unable to read row of sheet %q: %w", r.Sheet, err))
+		}
+		if len(row) == 0 && r.Profile != ProfileRoundtrip {
+			continue
+		}
+		n++
+	}
+	if err := rows.Error(); err != nil {
+		return 0, ErrBadRequest.Wrap(xerrors.Errorf("unable to read rows of sheet %q: %w", r.Sheet, err))
+	}
+	return n, nil
+}
+
+// keyColumnNames returns names of key columns that are written by the client, i.e. not computed.
+func keyColumnNames(s *schema.Schema) []string {
+	var names []string
+	for _, c := range s.Columns {
+		if c.SortOrder != schema.SortNone && c.Expression == "" {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// writableColumns returns columns that are not computed.
+func writableColumns(s *schema.Schema) []schema.Column {
+	var columns []schema.Column
+	for _, c := range s.Columns {
+		if c.Expression == "" {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// unmappedLocks returns lock groups of the non-key columns that are not uploaded.
+func unmappedLocks(req *UploadRequest, s *schema.Schema) []string {
+	var locks []string
+	for _, c := range s.Columns {
+		if c.SortOrder != schema.SortNone || c.Lock == "" {
+			continue
+		}
+		if _, ok := req.Columns[c.Name]; ok {
+			continue
+		}
+		if !slices.Contains(locks, c.Lock) {
+			locks = append(locks, c.Lock)
+		}
+	}
+	return locks
+}
+
+// tabletWriter is a yt.TableWriter that inserts rows into dynamic table in batches.
+//
+// If locks are set, rows are locked with shared strong lock before the insertion,
+// so that the transaction conflicts with concurrent changes of the locked columns.
+type tabletWriter struct {
+	ctx        context.Context
+	tx         yt.TabletClient
+	path       ypath.Path
+	opts       *yt.InsertRowsOptions
+	batchSize  int
+	keyColumns []string
+	locks      []string
+
+	batch []any
+}
+
+func (w *tabletWriter) Write(value any) error {
+	w.batch = append(w.batch, value)
+	if len(w.batch) >= w.batchSize {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *tabletWriter) flush() error {
+	if len(w.batch) == 0 {
+		return nil
+	}
+
+	if len(w.locks) > 0 {
+		keys := make([]any, 0, len(w.batch))
+		for _, row := range w.batch {
+			m := row.(map[string]any)
+			key := make(map[string]any, len(w.keyColumns))
+			for _, name := range w.keyColumns {
+				key[name] = m[name]
+			}
+			keys = append(keys, key)
+		}
+
+		if err := w.tx.LockRows(w.ctx, w.path, w.locks, yt.LockTypeSharedStrong, keys, nil); err != nil {
+			return xerrors.Errorf("error locking rows: %w", err)
+		}
+	}
+
+	if err := w.tx.InsertRows(w.ctx, w.path, w.batch, w.opts); err != nil {
+		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
+			return ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when inserting rows: %w", err))
+		}
+		return xerrors.Errorf("error inserting rows: %w", err)
+	}
+
+	w.batch = w.batch[:0]
+	return nil
+}
+
+// Commit inserts the rest of the rows. The tablet transaction is committed by the caller.
+func (w *tabletWriter) Commit() error {
+	return w.flush()
+}
+
+// Rollback drops buffered rows. The tablet transaction is aborted by the caller.
+func (w *tabletWriter) Rollback() error {
+	w.batch = nil
+	return nil
+}
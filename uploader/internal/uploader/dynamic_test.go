@@ -0,0 +1,285 @@
+package uploader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/migrate"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+var testDynamicSchema = schema.Schema{
+	UniqueKeys: true,
+	Columns: []schema.Column{
+		{Name: "hash", Type: schema.TypeUint64, SortOrder: schema.SortAscending, Expression: "farm_hash(id)"},
+		{Name: "id", Type: schema.TypeInt64, SortOrder: schema.SortAscending},
+		{Name: "name", Type: schema.TypeString},
+		{Name: "count", Type: schema.TypeInt64, Aggregate: "sum", Lock: "counter"},
+	},
+}
+
+func TestCheckDynamicRequest(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		req       *UploadRequest
+		overwrite bool
+		expected  map[string]string
+		error     bool
+	}{
+		{
+			name:     "default",
+			req:      &UploadRequest{},
+			expected: map[string]string{"id": "A", "name": "B", "count": "C"},
+		},
+		{
+			name:     "all-columns",
+			req:      &UploadRequest{Columns: map[string]string{"id": "B", "name": "A", "count": "C"}},
+			expected: map[string]string{"id": "B", "name": "A", "count": "C"},
+		},
+		{
+			name:  "subset-without-update",
+			req:   &UploadRequest{Columns: map[string]string{"id": "A", "name": "B"}},
+			error: true,
+		},
+		{
+			name:     "subset-with-update",
+			req:      &UploadRequest{Update: true, Columns: map[string]string{"id": "A", "name": "B"}},
+			expected: map[string]string{"id": "A", "name": "B"},
+		},
+		{
+			name:  "missing-key",
+			req:   &UploadRequest{Update: true, Columns: map[string]string{"name": "B"}},
+			error: true,
+		},
+		{
+			name:  "computed-column",
+			req:   &UploadRequest{Columns: map[string]string{"hash": "A", "id": "B", "name": "C", "count": "D"}},
+			error: true,
+		},
+		{
+			name:      "overwrite",
+			req:       &UploadRequest{},
+			overwrite: true,
+			error:     true,
+		},
+		{
+			name:  "unknown-column",
+			req:   &UploadRequest{Update: true, Columns: map[string]string{"id": "A", "other": "B"}},
+			error: true,
+		},
+		{
+			name:     "lock-rows",
+			req:      &UploadRequest{Update: true, LockRows: true, Columns: map[string]string{"id": "A", "name": "B"}},
+			expected: map[string]string{"id": "A", "name": "B"},
+		},
+		{
+			name:  "lock-rows-all-locks-mapped",
+			req:   &UploadRequest{LockRows: true, Columns: map[string]string{"id": "A", "name": "B", "count": "C"}},
+			error: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.req.append = !tc.overwrite
+			err := tc.req.checkDynamicRequest(&testDynamicSchema)
+			if tc.error {
+				require.ErrorIs(t, err, ErrBadRequest)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, tc.req.Columns)
+		})
+	}
+}
+
+func TestCheckStaticRequest(t *testing.T) {
+	require.NoError(t, (&UploadRequest{}).checkStaticRequest())
+	for _, req := range []*UploadRequest{{Update: true}, {Aggregate: true}, {LockRows: true}} {
+		require.ErrorIs(t, req.checkStaticRequest(), ErrBadRequest)
+	}
+}
+
+func TestUploadRequest_countRows(t *testing.T) {
+	f := makeExcelFile(t, table{"A1": "id", "A2": 1, "A3": 2, "A5": 3, "A6": 4})
+
+	for _, tc := range []struct {
+		name               string
+		startRow, rowCount int64
+		header             bool
+		expected           int64
+	}{
+		{name: "all", expected: 5},
+		{name: "header", header: true, expected: 4},
+		{name: "range", startRow: 3, rowCount: 3, expected: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := MakeUploadRequest("//tmp/a", tc.startRow, tc.rowCount, testSheet, tc.header, false, nil, true, false)
+			require.NoError(t, err)
+			req.Data = f
+
+			n, err := req.countRows()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, n)
+		})
+	}
+}
+
+func TestUnmappedLocks(t *testing.T) {
+	req := &UploadRequest{Columns: map[string]string{"id": "A", "name": "B"}}
+	require.Equal(t, []string{"counter"}, unmappedLocks(req, &testDynamicSchema))
+
+	req.Columns["count"] = "C"
+	require.Empty(t, unmappedLocks(req, &testDynamicSchema))
+}
+
+// tabletRecorder is a yt.TabletClient that records inserted and locked rows.
+type tabletRecorder struct {
+	yt.TabletClient
+
+	inserts [][]any
+	locks   [][]any
+}
+
+func (c *tabletRecorder) InsertRows(ctx context.Context, path ypath.Path, rows []any, opts *yt.InsertRowsOptions) error {
+	c.inserts = append(c.inserts, append([]any(nil), rows...))
+	return nil
+}
+
+func (c *tabletRecorder) LockRows(
+	ctx context.Context,
+	path ypath.Path,
+	locks []string,
+	lockType yt.LockType,
+	keys []any,
+	opts *yt.LockRowsOptions,
+) error {
+	c.locks = append(c.locks, keys)
+	return nil
+}
+
+func TestTabletWriter(t *testing.T) {
+	tx := &tabletRecorder{}
+	w := &tabletWriter{
+		ctx:        context.Background(),
+		tx:         tx,
+		batchSize:  2,
+		keyColumns: []string{"id"},
+		locks:      []string{"counter"},
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		require.NoError(t, w.Write(map[string]any{"id": i, "name": "x"}))
+	}
+	require.Len(t, tx.inserts, 1)
+	require.NoError(t, w.Commit())
+
+	require.Equal(t, [][]any{
+		{map[string]any{"id": int64(1), "name": "x"}, map[string]any{"id": int64(2), "name": "x"}},
+		{map[string]any{"id": int64(3), "name": "x"}},
+	}, tx.inserts)
+	require.Equal(t, [][]any{
+		{map[string]any{"id": int64(1)}, map[string]any{"id": int64(2)}},
+		{map[string]any{"id": int64(3)}},
+	}, tx.locks)
+
+	require.NoError(t, w.Commit())
+	require.Len(t, tx.inserts, 2, "empty batch is not inserted")
+}
+
+func TestMakeColumnMapping_computedColumns(t *testing.T) {
+	req := &UploadRequest{}
+	require.NoError(t, req.MakeColumnMapping(&testDynamicSchema))
+	require.Equal(t, map[string]string{"hash": "A", "id": "B", "name": "C", "count": "D"}, req.Columns,
+		"computed columns are skipped only for dynamic tables")
+}
+
+func TestUpload_dynamicTable(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	path := env.TmpPath()
+	require.NoError(t, migrate.Create(env.Ctx, env.YT, path, testDynamicSchema))
+	require.NoError(t, migrate.MountAndWait(env.Ctx, env.YT, path))
+
+	type row struct {
+		ID    int64  `yson:"id"`
+		Name  string `yson:"name"`
+		Count int64  `yson:"count"`
+	}
+
+	readRows := func() []row {
+		r, err := env.YT.SelectRows(env.Ctx, "id, name, count from ["+path.String()+"] order by id limit 100", nil)
+		require.NoError(t, err)
+		defer func() { _ = r.Close() }()
+
+		var rows []row
+		for r.Next() {
+			var v row
+			require.NoError(t, r.Scan(&v))
+			rows = append(rows, v)
+		}
+		require.NoError(t, r.Err())
+		return rows
+	}
+
+	_, err := Upload(env.Ctx, env.YT, &UploadRequest{
+		Path:    path,
+		allRows: true,
+		Data:    makeExcelFile(t, table{"A1": 1, "B1": "a", "C1": 1}),
+	})
+	require.ErrorIs(t, err, ErrBadRequest, "dynamic table can not be overwritten")
+
+	_, err = Upload(env.Ctx, env.YT, &UploadRequest{
+		Path:      path,
+		allRows:   true,
+		append:    true,
+		BatchSize: 1,
+		Data: makeExcelFile(t, table{
+			"A1": 1, "B1": "a", "C1": 1,
+			"A2": 2, "B2": "b", "C2": 2,
+		}),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []row{{1, "a", 1}, {2, "b", 2}}, readRows())
+
+	_, err = Upload(env.Ctx, env.YT, &UploadRequest{
+		Path:     path,
+		allRows:  true,
+		append:   true,
+		Update:   true,
+		LockRows: true,
+		Columns:  map[string]string{"id": "A", "name": "B"},
+		Data: makeExcelFile(t, table{
+			"A1": 2, "B1": "c",
+			"A2": 3, "B2": "d",
+		}),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []row{{1, "a", 1}, {2, "c", 2}, {3, "d", 0}}, readRows())
+
+	_, err = Upload(env.Ctx, env.YT, &UploadRequest{
+		Path:      path,
+		allRows:   true,
+		append:    true,
+		Update:    true,
+		Aggregate: true,
+		Columns:   map[string]string{"id": "A", "count": "B"},
+		Data:      makeExcelFile(t, table{"A1": 1, "B1": 10}),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []row{{1, "a", 11}, {2, "c", 2}, {3, "d", 0}}, readRows())
+
+	_, err = Upload(env.Ctx, env.YT, &UploadRequest{
+		Path:    path,
+		allRows: true,
+		append:  true,
+		Update:  true,
+		Columns: map[string]string{"name": "A"},
+		Data:    makeExcelFile(t, table{"A1": "e"}),
+	})
+	require.ErrorIs(t, err, ErrBadRequest)
+}
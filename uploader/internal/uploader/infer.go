@@ -0,0 +1,308 @@
+package uploader
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// cellKind is a kind of cell value used to infer the type of the column.
+//
+// Kinds of the cells of a column are merged by widen.
+type cellKind int
+
+const (
+	// kindNone is the kind of the column without values.
+	kindNone cellKind = iota
+	kindBoolean
+	kindInt
+	kindDouble
+	kindDate
+	kindDatetime
+	kindString
+)
+
+// columnType returns the type of the column whose values are of kind k.
+func (k cellKind) columnType() schema.Type {
+	switch k {
+	case kindBoolean:
+		return schema.TypeBoolean
+	case kindInt:
+		return schema.TypeInt64
+	case kindDouble:
+		return schema.TypeFloat64
+	case kindDate:
+		return schema.TypeDate
+	case kindDatetime:
+		return schema.TypeDatetime
+	case kindString:
+		return schema.TypeString
+	default:
+		return schema.TypeAny
+	}
+}
+
+// widen returns the kind that accepts values of both kinds.
+//
+// Integers widen to doubles and dates to datetimes, any other conflict widens to strings.
+func widen(a, b cellKind) cellKind {
+	switch {
+	case a == b || b == kindNone:
+		return a
+	case a == kindNone:
+		return b
+	case a == kindInt && b == kindDouble || a == kindDouble && b == kindInt:
+		return kindDouble
+	case a == kindDate && b == kindDatetime || a == kindDatetime && b == kindDate:
+		return kindDatetime
+	default:
+		return kindString
+	}
+}
+
+// numFmtKind is the kind of the values displayed with excel number format.
+type numFmtKind int
+
+const (
+	numFmtNumber numFmtKind = iota
+	numFmtDate
+	numFmtDatetime
+)
+
+// builtInNumFmtKinds lists built-in excel number formats of dates and datetimes.
+//
+// Formats 27-36 and 50-58 are locale-specific dates. Time formats 18-21 and 45-47 are not listed,
+// since they display durations or time of day that are uploaded as numbers.
+var builtInNumFmtKinds = map[int]numFmtKind{
+	14: numFmtDate, 15: numFmtDate, 16: numFmtDate, 17: numFmtDate,
+	22: numFmtDatetime,
+	27: numFmtDate, 28: numFmtDate, 29: numFmtDate, 30: numFmtDate, 31: numFmtDate, 36: numFmtDate,
+	50: numFmtDate, 51: numFmtDate, 54: numFmtDate, 57: numFmtDate, 58: numFmtDate,
+}
+
+// numFmtLiterals matches parts of number format codes that are displayed as is:
+// quoted text, escaped and padding characters and bracketed colors, conditions and locales.
+var numFmtLiterals = regexp.MustCompile(`"[^"]*"|\\.|[_*].|\[[^\]]*\]`)
+
+// customNumFmtKind determines whether the custom number format code displays dates.
+//
+// Codes with years or days are dates, the ones also showing hours or seconds are datetimes.
+// Minutes alone are ambiguous with months, so codes like mm:ss are numbers.
+func customNumFmtKind(code string) numFmtKind {
+	// Only the section of positive numbers is used.
+	code, _, _ = strings.Cut(code, ";")
+	code = strings.ToLower(numFmtLiterals.ReplaceAllString(code, ""))
+
+	if !strings.ContainsAny(code, "yd") {
+		return numFmtNumber
+	}
+	if strings.ContainsAny(code, "hs") {
+		return numFmtDatetime
+	}
+	return numFmtDate
+}
+
+// typeInferrer infers the types of the columns from the cells of the sheet.
+type typeInferrer struct {
+	f     *excelize.File
+	sheet string
+	// numFmts caches number format kinds by style index.
+	numFmts map[int]numFmtKind
+}
+
+// cellKind determines the kind of the non-empty cell by its type, raw value and number format.
+func (i *typeInferrer) cellKind(cell, value string) (cellKind, error) {
+	t, err := i.f.GetCellType(i.sheet, cell)
+	if err != nil {
+		return kindNone, err
+	}
+
+	switch t {
+	case excelize.CellTypeBool:
+		return kindBoolean, nil
+	case excelize.CellTypeUnset, excelize.CellTypeNumber:
+		fmtKind, err := i.numFmtKind(cell)
+		if err != nil {
+			return kindNone, err
+		}
+		return numberKind(value, fmtKind), nil
+	case excelize.CellTypeError:
+		return kindString, nil
+	default:
+		// Text cells, e.g. cells of csv files, and results of text formulas are parsed as literals.
+		return literalKind(value), nil
+	}
+}
+
+// numFmtKind returns the kind of the number format of the cell.
+func (i *typeInferrer) numFmtKind(cell string) (numFmtKind, error) {
+	idx, err := i.f.GetCellStyle(i.sheet, cell)
+	if err != nil {
+		return numFmtNumber, err
+	}
+	if kind, ok := i.numFmts[idx]; ok {
+		return kind, nil
+	}
+
+	style, err := i.f.GetStyle(idx)
+	if err != nil {
+		return numFmtNumber, err
+	}
+
+	kind := builtInNumFmtKinds[style.NumFmt]
+	if style.CustomNumFmt != nil {
+		kind = customNumFmtKind(*style.CustomNumFmt)
+	}
+	i.numFmts[idx] = kind
+	return kind, nil
+}
+
+// numberKind determines the kind of the raw value of the numeric cell.
+//
+// Serial numbers preceding unix epoch are numbers, since YT dates can not precede it.
+func numberKind(value string, fmtKind numFmtKind) cellKind {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if fmtKind != numFmtNumber {
+			return dateKind(value, fmtKind)
+		}
+		return kindInt
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil:
+		return kindString
+	case fmtKind != numFmtNumber && v >= float64(excelUnixEpochDays):
+		// Dates with time of day are datetimes.
+		return kindDatetime
+	default:
+		return kindDouble
+	}
+}
+
+// dateKind determines the kind of the integer serial number displayed as date or datetime.
+func dateKind(value string, fmtKind numFmtKind) cellKind {
+	v, _ := strconv.ParseInt(value, 10, 64)
+	switch {
+	case v < excelUnixEpochDays:
+		return kindInt
+	case fmtKind == numFmtDate:
+		return kindDate
+	default:
+		return kindDatetime
+	}
+}
+
+// literalKind determines the kind of the text value.
+//
+// Integers with leading zeros, e.g. zip codes, are kept as strings.
+func literalKind(value string) cellKind {
+	switch value {
+	case "true", "True", "TRUE", "false", "False", "FALSE":
+		return kindBoolean
+	}
+
+	digits := strings.TrimLeft(value, "+-")
+	if strings.HasPrefix(digits, "0") && len(digits) > 1 && digits[1] >= '0' && digits[1] <= '9' {
+		return kindString
+	}
+
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return kindInt
+	}
+	// ParseFloat accepts inf and nan that are rather words than numbers.
+	if v, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(v, 0) && !math.IsNaN(v) {
+		return kindDouble
+	}
+
+	if t, err := time.Parse(time.DateOnly, value); err == nil && !t.Before(unixEpoch) {
+		return kindDate
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil && !t.Before(unixEpoch) {
+		return kindDatetime
+	}
+	return kindString
+}
+
+// inferColumnTypes sets the types of the columns created by MakeSchema from the cells of the requested rows.
+//
+// The type of each column accepts all its values, the column is required if none of its cells is empty.
+// Columns without values are left optional any.
+func (r *UploadRequest) inferColumnTypes(columns map[string]*schema.Column, excelColToYTCols map[string][]string) error {
+	type columnStats struct {
+		excelCol string
+		index    int
+		kind     cellKind
+		empty    bool
+	}
+	var stats []*columnStats
+	for excelCol := range excelColToYTCols {
+		n, err := excelize.ColumnNameToNumber(excelCol)
+		if err != nil {
+			return xerrors.Errorf("invalid column name %q: %w", excelCol, err)
+		}
+		stats = append(stats, &columnStats{excelCol: excelCol, index: n - 1})
+	}
+
+	inferrer := &typeInferrer{f: r.Data, sheet: r.Sheet, numFmts: make(map[int]numFmtKind)}
+
+	rows, err := r.Data.Rows(r.Sheet)
+	if err != nil {
+		return ErrBadRequest.Wrap(xerrors.Errorf("unable to read rows of sheet %q: %w", r.Sheet, err))
+	}
+	defer func() { _ = rows.Close() }()
+
+	for i := 1; rows.Next(); i++ {
+		row, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return ErrBadRequest.Wrap(xerrors.Errorf("unable to read row of sheet %q: %w", r.Sheet, err))
+		}
+
+		// Rows are selected the same way as in convertRows.
+		if len(row) == 0 {
+			continue
+		}
+		if !r.allRows && int64(i) < r.StartRow {
+			continue
+		}
+		if !r.allRows && int64(i) >= r.StartRow+r.RowCount {
+			break
+		}
+
+		for _, s := range stats {
+			if s.index >= len(row) || row[s.index] == "" {
+				s.empty = true
+				continue
+			}
+
+			cell := s.excelCol + strconv.Itoa(i)
+			kind, err := inferrer.cellKind(cell, row[s.index])
+			if err != nil {
+				return ErrBadRequest.Wrap(xerrors.Errorf("unable to read cell %s of sheet %q: %w", cell, r.Sheet, err))
+			}
+			s.kind = widen(s.kind, kind)
+		}
+	}
+	if err := rows.Error(); err != nil {
+		return ErrBadRequest.Wrap(xerrors.Errorf("unable to read rows of sheet %q: %w", r.Sheet, err))
+	}
+
+	for _, s := range stats {
+		if s.kind == kindNone {
+			continue
+		}
+		for _, name := range excelColToYTCols[s.excelCol] {
+			col := columns[name]
+			col.Type = s.kind.columnType()
+			col.Required = !s.empty
+		}
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package uploader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+func TestWiden(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     cellKind
+		expected cellKind
+	}{
+		{a: kindNone, b: kindInt, expected: kindInt},
+		{a: kindInt, b: kindNone, expected: kindInt},
+		{a: kindInt, b: kindInt, expected: kindInt},
+		{a: kindInt, b: kindDouble, expected: kindDouble},
+		{a: kindDouble, b: kindInt, expected: kindDouble},
+		{a: kindDate, b: kindDatetime, expected: kindDatetime},
+		{a: kindDatetime, b: kindDate, expected: kindDatetime},
+		{a: kindBoolean, b: kindInt, expected: kindString},
+		{a: kindDate, b: kindInt, expected: kindString},
+		{a: kindString, b: kindDouble, expected: kindString},
+	} {
+		require.Equal(t, tc.expected, widen(tc.a, tc.b), "%d, %d", tc.a, tc.b)
+	}
+}
+
+func TestCustomNumFmtKind(t *testing.T) {
+	for code, expected := range map[string]numFmtKind{
+		"0.00":                    numFmtNumber,
+		"#,##0;[Red]-#,##0":       numFmtNumber,
+		"mm:ss":                   numFmtNumber,
+		`"day "0`:                 numFmtNumber,
+		"yyyy-mm-dd":              numFmtDate,
+		"[$-409]d mmmm yyyy;@":    numFmtDate,
+		"dd/mm/yyyy hh:mm":        numFmtDatetime,
+		"yyyy-mm-dd\\ hh:mm:ss.0": numFmtDatetime,
+	} {
+		require.Equal(t, expected, customNumFmtKind(code), code)
+	}
+}
+
+func TestLiteralKind(t *testing.T) {
+	for value, expected := range map[string]cellKind{
+		"true":                 kindBoolean,
+		"FALSE":                kindBoolean,
+		"42":                   kindInt,
+		"-7":                   kindInt,
+		"0":                    kindInt,
+		"00123":                kindString,
+		"1.5":                  kindDouble,
+		"0.5":                  kindDouble,
+		"1e3":                  kindDouble,
+		"99999999999999999999": kindDouble,
+		"inf":                  kindString,
+		"NaN":                  kindString,
+		"2000-12-12":           kindDate,
+		"1960-01-01":           kindString,
+		"2000-12-12T10:22:17Z": kindDatetime,
+		"yes":                  kindString,
+	} {
+		require.Equal(t, expected, literalKind(value), value)
+	}
+}
+
+func TestMakeSchema_inferTypes(t *testing.T) {
+	f := makeExcelFile(t, table{
+		"A1": "id", "B1": "price", "C1": "active", "D1": "created", "E1": "updated", "F1": "name", "G1": "mixed",
+		"H1": "comment", "I1": "code", "J1": "empty",
+		"A2": 1, "B2": 10, "C2": true, "D2": time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		"E2": time.Date(2020, 1, 2, 10, 30, 0, 0, time.UTC), "F2": "a", "G2": 1, "H2": "first", "I2": "007",
+		"A3": 2, "B3": 2.5, "C3": false, "D3": time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC),
+		"E3": time.Date(2020, 1, 3, 0, 0, 0, 0, time.UTC), "F3": "b", "G3": "x", "I3": "008",
+	})
+
+	// Custom number format of dates is recognized too.
+	dateFmt := "yyyy-mm-dd"
+	style, err := f.NewStyle(&excelize.Style{CustomNumFmt: &dateFmt})
+	require.NoError(t, err)
+	require.NoError(t, f.SetCellInt(testSheet, "K2", 43831))
+	require.NoError(t, f.SetCellStyle(testSheet, "K2", "K2", style))
+	require.NoError(t, f.SetCellValue(testSheet, "K1", "day"))
+
+	req, err := MakeUploadRequest("//tmp/a", 0, 0, testSheet, true, false, nil, false, true)
+	require.NoError(t, err)
+	req.Data = f
+
+	s, err := MakeSchema(req)
+	require.NoError(t, err)
+	require.Equal(t, []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "price", Type: schema.TypeFloat64, Required: true},
+		{Name: "active", Type: schema.TypeBoolean, Required: true},
+		{Name: "created", Type: schema.TypeDate, Required: true},
+		{Name: "updated", Type: schema.TypeDatetime, Required: true},
+		{Name: "name", Type: schema.TypeString, Required: true},
+		{Name: "mixed", Type: schema.TypeString, Required: true},
+		{Name: "comment", Type: schema.TypeString},
+		{Name: "code", Type: schema.TypeString, Required: true},
+		{Name: "empty", Type: schema.TypeAny},
+		{Name: "day", Type: schema.TypeDate},
+	}, s.Columns)
+
+	// Inferred types accept all the values of the sheet.
+	require.NoError(t, req.checkColumnMapping(s))
+	var stats RowStats
+	require.NoError(t, convertRows(req, s, &stats, func(row map[string]any) error { return nil }))
+	require.Equal(t, int64(2), stats.RowCount)
+	require.Zero(t, stats.ErrorCount, "%v", stats.Errors)
+}
+
+func TestMakeSchema_inferTypesCSV(t *testing.T) {
+	req, err := MakeUploadRequest("//tmp/a", 0, 0, "", true, false, nil, false, true)
+	require.NoError(t, err)
+	req.Data = readCSVFile(t, "id,ratio,ok,day,at,zip\r\n"+
+		"1,0.5,true,2000-12-12,2000-12-12T10:22:17Z,01234\r\n"+
+		"2,1,False,2000-12-13,2000-12-13,\r\n")
+	require.NoError(t, req.EnsureSheetName())
+
+	s, err := MakeSchema(req)
+	require.NoError(t, err)
+	require.Equal(t, []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "ratio", Type: schema.TypeFloat64, Required: true},
+		{Name: "ok", Type: schema.TypeBoolean, Required: true},
+		{Name: "day", Type: schema.TypeDate, Required: true},
+		{Name: "at", Type: schema.TypeDatetime, Required: true},
+		{Name: "zip", Type: schema.TypeString},
+	}, s.Columns)
+
+	require.NoError(t, req.checkColumnMapping(s))
+	var stats RowStats
+	require.NoError(t, convertRows(req, s, &stats, func(row map[string]any) error { return nil }))
+	require.Zero(t, stats.ErrorCount, "%v", stats.Errors)
+}
+
+func TestUpload_inferredSchema(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	req, err := MakeUploadRequest(env.TmpPath().String(), 0, 0, testSheet, true, false, nil, false, true)
+	require.NoError(t, err)
+	req.Data = makeExcelFile(t, table{
+		"A1": "id", "B1": "name",
+		"A2": 1, "B2": "a",
+		"A3": 2,
+	})
+
+	result, err := Upload(env.Ctx, env.YT, req)
+	require.NoError(t, err)
+	require.Equal(t, []ReportColumn{
+		{Name: "id", Type: "int64", Required: true},
+		{Name: "name", Type: "utf8"},
+	}, result.Schema)
+}
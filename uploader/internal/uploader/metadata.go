@@ -0,0 +1,78 @@
+package uploader
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/ypath"
+)
+
+// MetadataSheetName is the name of the hidden sheet describing the table the file is exported from.
+//
+// Each row of the sheet holds a key in the first column and its text value split over the next columns.
+const MetadataSheetName = "_metadata"
+
+// FileOrigin describes the table the file is exported from as recorded by the exporter.
+type FileOrigin struct {
+	Cluster string     `json:"cluster"`
+	Path    ypath.Path `json:"path"`
+	// Revision is the revision of the table at the time of export.
+	Revision         string `json:"revision"`
+	ModificationTime string `json:"modification_time"`
+	ExportTime       string `json:"export_time"`
+	User             string `json:"user"`
+	// SameTable is true if the file is uploaded to the table it is exported from.
+	SameTable bool `json:"same_table"`
+}
+
+// readFileOrigin reads the origin of the file from its metadata sheet.
+//
+// Returns nil if the file has no such sheet.
+func readFileOrigin(f *excelize.File) (*FileOrigin, error) {
+	if !slices.Contains(f.GetSheetList(), MetadataSheetName) {
+		return nil, nil
+	}
+
+	rows, err := f.GetRows(MetadataSheetName, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("unable to read sheet %q: %w", MetadataSheetName, err))
+	}
+
+	origin := &FileOrigin{}
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+
+		value := strings.Join(row[1:], "")
+		switch row[0] {
+		case "cluster":
+			origin.Cluster = value
+		case "path":
+			origin.Path = ypath.Path(value)
+		case "revision":
+			origin.Revision = value
+		case "modification_time":
+			origin.ModificationTime = value
+		case "export_time":
+			origin.ExportTime = value
+		case "user":
+			origin.User = value
+		}
+	}
+	return origin, nil
+}
+
+// readOrigin reads the origin of the uploaded file and checks whether it is exported from the target table.
+func (r *UploadRequest) readOrigin() (*FileOrigin, error) {
+	origin, err := readFileOrigin(r.Data)
+	if err != nil || origin == nil {
+		return nil, err
+	}
+
+	origin.SameTable = origin.Cluster != "" && origin.Cluster == r.Cluster && origin.Path == r.Path
+	return origin, nil
+}
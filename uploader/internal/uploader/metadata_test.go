@@ -0,0 +1,79 @@
+package uploader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/ypath"
+)
+
+func makeMetadataFile(t *testing.T, rows [][]any) *excelize.File {
+	t.Helper()
+
+	f := excelize.NewFile()
+	t.Cleanup(func() { _ = f.Close() })
+
+	_, err := f.NewSheet(MetadataSheetName)
+	require.NoError(t, err)
+	require.NoError(t, f.SetSheetVisible(MetadataSheetName, false))
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		require.NoError(t, err)
+		require.NoError(t, f.SetSheetRow(MetadataSheetName, cell, &row))
+	}
+	return f
+}
+
+func TestUploadRequest_readOrigin(t *testing.T) {
+	f := makeMetadataFile(t, [][]any{
+		{"cluster", "hahn"},
+		{"path", "//home/", "table"},
+		{"rows", ""},
+		{"revision", "1234567890123456789"},
+		{"modification_time", "2024-02-29T10:00:00.000000Z"},
+		{"export_time", "2024-03-01T12:30:00Z"},
+		{"user", "root"},
+		{"unknown", "value"},
+	})
+	expected := &FileOrigin{
+		Cluster:          "hahn",
+		Path:             "//home/table",
+		Revision:         "1234567890123456789",
+		ModificationTime: "2024-02-29T10:00:00.000000Z",
+		ExportTime:       "2024-03-01T12:30:00Z",
+		User:             "root",
+	}
+
+	for _, tc := range []struct {
+		name      string
+		cluster   string
+		path      ypath.Path
+		sameTable bool
+	}{
+		{name: "same", cluster: "hahn", path: "//home/table", sameTable: true},
+		{name: "other_table", cluster: "hahn", path: "//home/other"},
+		{name: "other_cluster", cluster: "arnold", path: "//home/table"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &UploadRequest{Path: tc.path, Cluster: tc.cluster, Data: f}
+			origin, err := req.readOrigin()
+			require.NoError(t, err)
+
+			expected := *expected
+			expected.SameTable = tc.sameTable
+			require.Equal(t, &expected, origin)
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		f := excelize.NewFile()
+		defer func() { _ = f.Close() }()
+
+		req := &UploadRequest{Path: "//home/table", Cluster: "hahn", Data: f}
+		origin, err := req.readOrigin()
+		require.NoError(t, err)
+		require.Nil(t, origin)
+	})
+}
@@ -0,0 +1,76 @@
+package uploader
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yson"
+)
+
+// Profile is a preset of upload settings for files of a particular origin.
+type Profile string
+
+const (
+	// ProfileRoundtrip uploads files exported with the roundtrip profile of the exporter.
+	//
+	// Names and types are read from the header, the table is created with the schema
+	// stored in the hidden sheet SchemaSheetName, and empty text cells of optional columns
+	// are uploaded as empty strings rather than nulls.
+	ProfileRoundtrip Profile = "roundtrip"
+)
+
+// Profiles lists all supported upload profiles.
+var Profiles = []Profile{ProfileRoundtrip}
+
+// SchemaSheetName is the name of the hidden sheet with the table schema written by the exporter.
+//
+// The schema is stored as yson text split over the cells of the first column.
+const SchemaSheetName = "_schema"
+
+// readSchemaSheet reads the table schema stored in the hidden sheet of the workbook.
+func readSchemaSheet(f *excelize.File) (*schema.Schema, error) {
+	if !slices.Contains(f.GetSheetList(), SchemaSheetName) {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("sheet %q with table schema not found; "+
+			"the file must be exported with roundtrip profile", SchemaSheetName))
+	}
+
+	rows, err := f.GetRows(SchemaSheetName, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("unable to read sheet %q: %w", SchemaSheetName, err))
+	}
+
+	var b strings.Builder
+	for _, row := range rows {
+		if len(row) > 0 {
+			b.WriteString(row[0])
+		}
+	}
+
+	var s schema.Schema
+	if err := yson.Unmarshal([]byte(b.String()), &s); err != nil {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("unable to parse table schema from sheet %q: %w", SchemaSheetName, err))
+	}
+	if len(s.Columns) == 0 {
+		return nil, ErrBadRequest.Wrap(xerrors.Errorf("table schema in sheet %q has no columns", SchemaSheetName))
+	}
+	return &s, nil
+}
+
+// isTextCell checks whether the cell holds a string rather than nothing.
+func isTextCell(f *excelize.File, sheet, cell string) (bool, error) {
+	t, err := f.GetCellType(sheet, cell)
+	if err != nil {
+		return false, err
+	}
+	return t == excelize.CellTypeInlineString || t == excelize.CellTypeSharedString, nil
+}
+
+// convertEmptyText converts empty text of an optional column to the value of its item type.
+func convertEmptyText(c schema.Column) (any, error) {
+	o := columnType(c).(schema.Optional)
+	return convert("", schema.Column{Name: c.Name, ComplexType: o.Item})
+}
@@ -0,0 +1,133 @@
+package uploader
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yson"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+const (
+	// roundtripTable is the table that the exporter tests export to roundtripFile with the roundtrip profile.
+	roundtripTable = "../../../exporter/internal/exporter/testdata/roundtrip.yson"
+	roundtripFile  = "../../../exporter/internal/exporter/testdata/roundtrip.xlsx"
+)
+
+func readRoundtripTable(t *testing.T) (*schema.Schema, []map[string]any) {
+	t.Helper()
+
+	data, err := os.ReadFile(roundtripTable)
+	require.NoError(t, err)
+
+	var table struct {
+		Schema schema.Schema `yson:"schema"`
+		Rows   []any         `yson:"rows"`
+	}
+	require.NoError(t, yson.Unmarshal(data, &table))
+	return &table.Schema, normalizeRows(t, table.Rows)
+}
+
+// normalizeRows turns rows into generic yson values without nulls, so that rows of different origin can be compared.
+func normalizeRows(t *testing.T, rows []any) []map[string]any {
+	t.Helper()
+
+	normalized := make([]map[string]any, len(rows))
+	for i, row := range rows {
+		data, err := yson.Marshal(row)
+		require.NoError(t, err)
+		require.NoError(t, yson.Unmarshal(data, &normalized[i]))
+
+		for k, v := range normalized[i] {
+			if v == nil {
+				delete(normalized[i], k)
+			}
+		}
+	}
+	return normalized
+}
+
+func makeRoundtripRequest(t *testing.T, path string) *UploadRequest {
+	t.Helper()
+
+	req, err := MakeUploadRequest(path, 0, 0, "", true, true, nil, false, true)
+	require.NoError(t, err)
+	req.Profile = ProfileRoundtrip
+
+	req.Data, err = excelize.OpenFile(roundtripFile)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = req.Data.Close() })
+	return req
+}
+
+func TestUpload_roundtripRows(t *testing.T) {
+	expectedSchema, expectedRows := readRoundtripTable(t)
+
+	req := makeRoundtripRequest(t, "//tmp/roundtrip")
+	require.NoError(t, req.EnsureSheetName())
+	require.Equal(t, testSheet, req.Sheet, "hidden schema sheet is not uploaded")
+
+	s, err := MakeSchema(req)
+	require.NoError(t, err)
+	require.Equal(t, expectedSchema, s)
+	require.NoError(t, req.checkColumnMapping(s))
+
+	out := &rowsWriter{}
+	result, err := upload(req, s, out)
+	require.NoError(t, err)
+	require.Zero(t, result.ErrorCount)
+	require.Equal(t, expectedRows, normalizeRows(t, out.rows))
+}
+
+func TestUpload_roundtripTable(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	expectedSchema, expectedRows := readRoundtripTable(t)
+
+	req := makeRoundtripRequest(t, "//tmp/roundtrip")
+	defer func() { _ = env.YT.RemoveNode(env.Ctx, req.Path, nil) }()
+
+	_, err := Upload(env.Ctx, env.YT, req)
+	require.NoError(t, err)
+
+	s, err := ReadSchema(env.Ctx, env.YT, req.Path)
+	require.NoError(t, err)
+	require.Equal(t, expectedSchema.UniqueKeys, s.UniqueKeys)
+	require.Equal(t, expectedSchema.KeyColumns(), s.KeyColumns())
+	require.Equal(t, getColumnNames(expectedSchema), getColumnNames(s))
+
+	r, err := env.YT.ReadTable(env.Ctx, ypath.Path(req.Path), nil)
+	require.NoError(t, err)
+	defer func() { _ = r.Close() }()
+
+	var rows []any
+	for r.Next() {
+		var row map[string]any
+		require.NoError(t, r.Scan(&row))
+		rows = append(rows, row)
+	}
+	require.NoError(t, r.Err())
+	require.Equal(t, expectedRows, normalizeRows(t, rows))
+}
+
+func TestReadSchemaSheet_missing(t *testing.T) {
+	req := makeRoundtripRequest(t, "//tmp/roundtrip")
+	require.NoError(t, req.Data.DeleteSheet(SchemaSheetName))
+
+	_, err := MakeSchema(req)
+	require.ErrorIs(t, err, ErrBadRequest)
+}
+
+func getColumnNames(s *schema.Schema) []string {
+	var names []string
+	for _, col := range s.Columns {
+		names = append(names, col.Name)
+	}
+	return names
+}
@@ -0,0 +1,179 @@
+package uploader
+
+import (
+	"context"
+	"slices"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+)
+
+// SchemaMode controls how the columns of the sheet are matched against the schema of the table on append.
+type SchemaMode string
+
+const (
+	// SchemaModeStrict requires the sheet to have exactly the columns of the table.
+	SchemaModeStrict SchemaMode = "strict"
+	// SchemaModeExtend adds the columns of the sheet missing from the table as optional columns of the inferred type.
+	// Missing optional columns are allowed as in SchemaModeRelaxed.
+	SchemaModeExtend SchemaMode = "extend"
+	// SchemaModeRelaxed allows the sheet to miss optional columns of the table.
+	SchemaModeRelaxed SchemaMode = "relaxed"
+)
+
+// SchemaModes lists all supported schema modes.
+var SchemaModes = []SchemaMode{SchemaModeStrict, SchemaModeExtend, SchemaModeRelaxed}
+
+// relaxed checks whether the sheet may miss optional columns of the table.
+func (m SchemaMode) relaxed() bool {
+	return m == SchemaModeExtend || m == SchemaModeRelaxed
+}
+
+// checkSchemaMode checks that the schema mode of the request can be used along with its other settings.
+func (r *UploadRequest) checkSchemaMode() error {
+	switch {
+	case r.SchemaMode == "" || r.SchemaMode == SchemaModeStrict:
+		return nil
+	case !slices.Contains(SchemaModes, r.SchemaMode):
+		return ErrBadRequest.Wrap(xerrors.Errorf("unexpected schema mode: %q; expected one of %q", r.SchemaMode, SchemaModes))
+	case !r.append || r.create:
+		return ErrBadRequest.Wrap(xerrors.Errorf("schema mode %q requires append=true and create=false", r.SchemaMode))
+	case r.SchemaMode == SchemaModeExtend && r.hasParentTx():
+		return ErrBadRequest.Wrap(xerrors.Errorf("schema mode %q is not supported within a transaction", r.SchemaMode))
+	}
+	return nil
+}
+
+// checkRelaxedMapping checks that all mapped columns are in the schema and all required columns are mapped.
+func (r *UploadRequest) checkRelaxedMapping(s *schema.Schema) error {
+	columns := makeColumnSet(s)
+	for name := range r.Columns {
+		if _, ok := columns[name]; !ok {
+			return ErrBadRequest.Wrap(xerrors.Errorf("column %q is missing in the table schema; use schema mode %q to add it",
+				name, SchemaModeExtend))
+		}
+	}
+
+	for _, col := range s.Columns {
+		if _, ok := r.Columns[col.Name]; ok {
+			continue
+		}
+		if _, optional := columnType(col).(schema.Optional); !optional {
+			return ErrBadRequest.Wrap(xerrors.Errorf("required column %q is not mapped to any excel column", col.Name))
+		}
+	}
+	return nil
+}
+
+// extendSchema returns the schema with the mapped columns missing from it appended
+// as optional columns of the type inferred by MakeSchema.
+//
+// Column mapping is made from the header if it is not set.
+func (r *UploadRequest) extendSchema(s *schema.Schema) (*schema.Schema, error) {
+	if len(r.Columns) == 0 {
+		if err := r.MakeColumnMapping(s); err != nil {
+			return nil, err
+		}
+	}
+
+	inferred, err := MakeSchema(r)
+	if err != nil {
+		return nil, xerrors.Errorf("error inferring schema from excel table: %w", err)
+	}
+
+	existing := makeColumnSet(s)
+	extended := *s
+	extended.Columns = slices.Clone(s.Columns)
+	for _, col := range inferred.Columns {
+		if _, ok := existing[col.Name]; ok {
+			continue
+		}
+		extended.Columns = append(extended.Columns, makeOptional(col))
+	}
+	return &extended, nil
+}
+
+// makeOptional makes the column accept nulls.
+func makeOptional(col schema.Column) schema.Column {
+	col.Required = false
+	if col.ComplexType != nil {
+		if _, ok := col.ComplexType.(schema.Optional); !ok {
+			col.ComplexType = schema.Optional{Item: col.ComplexType}
+		}
+	}
+	return col
+}
+
+// extendTable adds the mapped columns missing from the static table to its schema and returns their names.
+//
+// alter_table can not be run within the upload transaction, so the columns are added before the upload starts
+// and are kept if the upload fails later, e.g. on commit. They are optional, so the rows of the table remain valid.
+// The rows are converted before the columns are added, so that an upload failing on conversion does not change the schema.
+func extendTable(ctx context.Context, yc yt.Client, req *UploadRequest) ([]string, error) {
+	extended, added, err := planExtension(ctx, yc, req)
+	if err != nil || len(added) == 0 {
+		return nil, err
+	}
+
+	if err := checkUpload(ctx, yc, req); err != nil {
+		return nil, err
+	}
+	if err := alterTable(ctx, yc, req, extended, added); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// planExtension returns the extended schema of the static table and the names of the columns it adds.
+func planExtension(ctx context.Context, yc yt.Client, req *UploadRequest) (*schema.Schema, []string, error) {
+	dynamic, err := readDynamic(ctx, yc, req.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dynamic {
+		return nil, nil, ErrBadRequest.Wrap(xerrors.Errorf("schema mode %q is supported only for static tables", req.SchemaMode))
+	}
+
+	s, err := readTableSchema(ctx, yc, req.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	extended, err := req.extendSchema(s)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var added []string
+	for _, col := range extended.Columns[len(s.Columns):] {
+		added = append(added, col.Name)
+	}
+	return extended, added, nil
+}
+
+// checkUpload runs all the checks of the upload including conversion of the rows without writing anything.
+//
+// Conversion errors are returned the same way as by Upload.
+func checkUpload(ctx context.Context, yc yt.Client, req *UploadRequest) error {
+	report, err := dryRun(ctx, yc, req)
+	if err != nil {
+		return err
+	}
+	if report.ErrorCount > 0 && req.OnError != OnErrorSkip {
+		return ErrBadRequest.Wrap(&ConversionError{Sheet: req.Sheet, Stats: &report.RowStats})
+	}
+	return nil
+}
+
+// alterTable sets the extended schema of the table.
+func alterTable(ctx context.Context, yc yt.Client, req *UploadRequest, extended *schema.Schema, added []string) error {
+	if err := yc.AlterTable(ctx, req.Path, &yt.AlterTableOptions{Schema: extended}); err != nil {
+		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
+			return ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when altering table %q: %w", req.Path, err))
+		}
+		return xerrors.Errorf("unable to add columns %q to table %q: %w", added, req.Path, err)
+	}
+	return nil
+}
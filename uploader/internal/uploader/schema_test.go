@@ -0,0 +1,190 @@
+package uploader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/guid"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+func TestUploadRequest_checkSchemaMode(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		req   *UploadRequest
+		error bool
+	}{
+		{name: "default", req: &UploadRequest{}},
+		{name: "strict", req: &UploadRequest{SchemaMode: SchemaModeStrict, create: true}},
+		{name: "relaxed", req: &UploadRequest{SchemaMode: SchemaModeRelaxed, append: true}},
+		{name: "extend", req: &UploadRequest{SchemaMode: SchemaModeExtend, append: true}},
+		{
+			name: "relaxed_in_tx",
+			req:  &UploadRequest{SchemaMode: SchemaModeRelaxed, append: true, TransactionID: yt.TxID(guid.New())},
+		},
+		{name: "unknown", req: &UploadRequest{SchemaMode: "loose", append: true}, error: true},
+		{name: "overwrite", req: &UploadRequest{SchemaMode: SchemaModeRelaxed}, error: true},
+		{name: "create", req: &UploadRequest{SchemaMode: SchemaModeExtend, append: true, create: true}, error: true},
+		{
+			name:  "extend_in_tx",
+			req:   &UploadRequest{SchemaMode: SchemaModeExtend, append: true, TransactionID: yt.TxID(guid.New())},
+			error: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.req.checkSchemaMode()
+			if tc.error {
+				require.ErrorIs(t, err, ErrBadRequest)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestUploadRequest_checkColumnMapping_relaxed(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "name", Type: schema.TypeString},
+		{Name: "tags", ComplexType: schema.Optional{Item: schema.List{Item: schema.TypeString}}},
+	}}
+
+	for _, tc := range []struct {
+		name    string
+		mode    SchemaMode
+		columns map[string]string
+		error   bool
+	}{
+		{name: "strict_all", mode: SchemaModeStrict, columns: map[string]string{"id": "A", "name": "B", "tags": "C"}},
+		{name: "strict_missing_optional", mode: SchemaModeStrict, columns: map[string]string{"id": "A"}, error: true},
+		{name: "relaxed_missing_optional", mode: SchemaModeRelaxed, columns: map[string]string{"id": "A"}},
+		{name: "relaxed_missing_required", mode: SchemaModeRelaxed, columns: map[string]string{"name": "A"}, error: true},
+		{name: "relaxed_unknown", mode: SchemaModeRelaxed, columns: map[string]string{"id": "A", "age": "B"}, error: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := &UploadRequest{SchemaMode: tc.mode, Columns: tc.columns}
+			err := req.checkColumnMapping(s)
+			if tc.error {
+				require.ErrorIs(t, err, ErrBadRequest)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestUploadRequest_extendSchema(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "name", Type: schema.TypeString},
+	}}
+
+	req := &UploadRequest{
+		Sheet:      testSheet,
+		Header:     true,
+		Types:      true,
+		StartRow:   3,
+		SchemaMode: SchemaModeExtend,
+		append:     true,
+		Data: makeExcelFile(t, table{
+			"A1": "id", "B1": "age", "C1": "tags", "D1": "", "E1": "name",
+			"A2": "int64", "B2": "uint8", "C2": "list<utf8>", "E2": "utf8",
+			"A3": 1, "B3": 30, "C3": `["a"]`, "E3": "a",
+		}),
+	}
+
+	extended, err := req.extendSchema(s)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"id": "A", "age": "B", "tags": "C", "name": "E"}, req.Columns,
+		"header columns missing from the schema are mapped")
+	require.Equal(t, []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "name", Type: schema.TypeString},
+		{Name: "age", Type: schema.TypeUint8},
+		{Name: "tags", ComplexType: schema.Optional{Item: schema.List{Item: schema.TypeString}}},
+	}, extended.Columns)
+	require.Len(t, s.Columns, 2, "schema of the table is not changed")
+
+	require.NoError(t, req.checkColumnMapping(extended))
+}
+
+type evolvedRow struct {
+	ID   int64   `yson:"id"`
+	Name *string `yson:"name"`
+	Age  *uint64 `yson:"age"`
+}
+
+func TestUpload_schemaMode(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	path := env.TmpPath()
+	_, err := yt.CreateTable(env.Ctx, env.YT, path, yt.WithSchema(schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "name", Type: schema.TypeString},
+	}}))
+	require.NoError(t, err)
+
+	newRequest := func(t *testing.T, mode SchemaMode, data table) *UploadRequest {
+		req, err := MakeUploadRequest(path.String(), 0, 0, testSheet, true, true, nil, true, false)
+		require.NoError(t, err)
+		req.SchemaMode = mode
+		req.Data = makeExcelFile(t, data)
+		return req
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		req := newRequest(t, SchemaModeStrict, table{"A1": "id", "A2": "int64", "A3": 1})
+		_, err := Upload(env.Ctx, env.YT, req)
+		require.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("relaxed", func(t *testing.T) {
+		req := newRequest(t, SchemaModeRelaxed, table{"A1": "id", "A2": "int64", "A3": 1})
+		result, err := Upload(env.Ctx, env.YT, req)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), result.RowCount)
+		require.Empty(t, result.AddedColumns)
+	})
+
+	t.Run("extend", func(t *testing.T) {
+		req := newRequest(t, SchemaModeExtend, table{
+			"A1": "id", "B1": "age",
+			"A2": "int64", "B2": "uint64",
+			"A3": 2, "B3": 30,
+		})
+		result, err := Upload(env.Ctx, env.YT, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"age"}, result.AddedColumns)
+
+		s, err := ReadSchema(env.Ctx, env.YT, path)
+		require.NoError(t, err)
+		require.Len(t, s.Columns, 3)
+		require.Equal(t, "age", s.Columns[2].Name)
+		require.False(t, s.Columns[2].Required)
+
+		var rows []evolvedRow
+		require.NoError(t, env.DownloadSlice(path, &rows))
+		require.Len(t, rows, 2)
+		require.Nil(t, rows[0].Age)
+		require.Equal(t, uint64(30), *rows[1].Age)
+	})
+
+	t.Run("extend_conversion_error", func(t *testing.T) {
+		req := newRequest(t, SchemaModeExtend, table{
+			"A1": "id", "B1": "score",
+			"A2": "int64", "B2": "double",
+			"A3": "x", "B3": 1.5,
+		})
+		_, err := Upload(env.Ctx, env.YT, req)
+		var convErr *ConversionError
+		require.ErrorAs(t, err, &convErr)
+
+		s, err := ReadSchema(env.Ctx, env.YT, path)
+		require.NoError(t, err)
+		require.Len(t, s.Columns, 3, "columns are not added by the failed upload")
+	})
+}
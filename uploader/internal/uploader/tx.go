@@ -0,0 +1,37 @@
+package uploader
+
+import (
+	"context"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+)
+
+// hasParentTx checks whether the upload runs within the transaction of the caller.
+func (r *UploadRequest) hasParentTx() bool {
+	return r.TransactionID != yt.TxID{}
+}
+
+// attachParentTx attaches to the transaction of the request; returns nil if it is not set.
+//
+// The transaction belongs to the caller, so it is neither pinged nor finished by the uploader.
+func (r *UploadRequest) attachParentTx(ctx context.Context, yc yt.Client) (yt.Tx, error) {
+	if !r.hasParentTx() {
+		return nil, nil
+	}
+
+	tx, err := yc.AttachTx(ctx, r.TransactionID, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to attach to transaction %s: %w", r.TransactionID, err)
+	}
+	return tx, nil
+}
+
+// wrapParentTxError explains the errors caused by the transaction of the request that is aborted or expired.
+func (r *UploadRequest) wrapParentTxError(err error) error {
+	if !r.hasParentTx() || !yterrors.ContainsErrorCode(err, yterrors.CodeNoSuchTransaction) {
+		return err
+	}
+	return ErrBadRequest.Wrap(xerrors.Errorf("transaction %s is aborted or expired: %w", r.TransactionID, err))
+}
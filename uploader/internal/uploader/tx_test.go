@@ -0,0 +1,85 @@
+package uploader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/guid"
+	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+func TestUploadRequest_wrapParentTxError(t *testing.T) {
+	noSuchTx := xerrors.Errorf("error writing rows: %w", yterrors.Err(yterrors.CodeNoSuchTransaction, "no such transaction"))
+	other := yterrors.Err(yterrors.CodeResolveError, "no such node")
+
+	req := &UploadRequest{}
+	require.Equal(t, noSuchTx, req.wrapParentTxError(noSuchTx), "own transaction is not reported as the parent one")
+
+	req.TransactionID = yt.TxID(guid.New())
+	require.Equal(t, other, req.wrapParentTxError(other))
+
+	err := req.wrapParentTxError(noSuchTx)
+	require.ErrorIs(t, err, ErrBadRequest)
+	require.Contains(t, err.Error(), req.TransactionID.String())
+	require.Contains(t, err.Error(), "aborted or expired")
+}
+
+func TestUpload_parentTx(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	newRequest := func(t *testing.T, parent yt.Tx) *UploadRequest {
+		path := env.TmpPath()
+		return &UploadRequest{
+			Path:          path,
+			allRows:       true,
+			create:        true,
+			TransactionID: parent.ID(),
+			Data:          makeExcelFile(t, table{"A1": 1, "A2": 2}),
+		}
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		parent, err := env.YT.BeginTx(env.Ctx, nil)
+		require.NoError(t, err)
+		defer func() { _ = parent.Abort() }()
+
+		req := newRequest(t, parent)
+		result, err := Upload(env.Ctx, env.YT, req)
+		require.NoError(t, err)
+		require.Equal(t, int64(2), result.RowCount)
+
+		ok, err := env.YT.NodeExists(env.Ctx, req.Path, nil)
+		require.NoError(t, err)
+		require.False(t, ok, "table is not visible until the parent transaction is committed")
+
+		ok, err = parent.NodeExists(env.Ctx, req.Path, nil)
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		require.NoError(t, parent.Commit())
+
+		var rows []S3
+		require.NoError(t, env.DownloadSlice(req.Path, &rows))
+		require.Len(t, rows, 2)
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		parent, err := env.YT.BeginTx(env.Ctx, nil)
+		require.NoError(t, err)
+		require.NoError(t, parent.Abort())
+
+		req := newRequest(t, parent)
+		_, err = Upload(env.Ctx, env.YT, req)
+		require.ErrorIs(t, err, ErrBadRequest)
+		require.Contains(t, err.Error(), "aborted or expired")
+
+		req.create = false
+		_, err = DryRun(env.Ctx, env.YT, req)
+		require.ErrorIs(t, err, ErrBadRequest)
+	})
+}
@@ -0,0 +1,797 @@
+package uploader
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/yson"
+)
+
+// Simple types of type_v3 schemas that have no constants in the schema package.
+const (
+	typeUUID        schema.Type = "uuid"
+	typeJSON        schema.Type = "json"
+	typeNull        schema.Type = "null"
+	typeVoid        schema.Type = "void"
+	typeDate32      schema.Type = "date32"
+	typeDatetime64  schema.Type = "datetime64"
+	typeTimestamp64 schema.Type = "timestamp64"
+	typeInterval64  schema.Type = "interval64"
+)
+
+// v3OnlyTypes are simple types that can only be specified in type_v3 column schema.
+var v3OnlyTypes = map[schema.Type]struct{}{
+	typeUUID:        {},
+	typeJSON:        {},
+	typeNull:        {},
+	typeVoid:        {},
+	typeDate32:      {},
+	typeDatetime64:  {},
+	typeTimestamp64: {},
+	typeInterval64:  {},
+}
+
+// excelUnixEpochDays is the excel serial number of unix epoch.
+var excelUnixEpochDays = int64(unixEpoch.Add(day).Sub(excelEpoch).Hours() / 24)
+
+// ParseType parses text description of type_v3 type.
+//
+// Description is either a simple type name, e.g. int64, or a composite type in the form written by the exporter,
+// e.g. optional<int64>, list<utf8>, struct<a:int64,"b c":list<double>>, tuple<int64,utf8>, dict<utf8,int64>,
+// variant<int64,utf8>, variant<a:int64,b:utf8>, tagged<"tag",int64> or decimal(10,2).
+// Names of composite types are case-insensitive.
+//
+// YSON type_v3 description, e.g. {type_name=list;item=int64}, is accepted too.
+func ParseType(s string) (schema.ComplexType, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "{") {
+		var t schema.ComplexType
+		if err := yson.Unmarshal([]byte(s), &t); err != nil {
+			return nil, xerrors.Errorf("unable to parse yson type %q: %w", s, err)
+		}
+		return t, nil
+	}
+
+	if !strings.ContainsAny(s, "<(") {
+		return simpleType(s), nil
+	}
+
+	p := &typeParser{s: s}
+	t, err := p.parseType()
+	if err != nil {
+		return nil, xerrors.Errorf("unable to parse type %q: %w", s, err)
+	}
+	if p.skipSpaces(); p.pos != len(p.s) {
+		return nil, xerrors.Errorf("unable to parse type %q: unexpected %q at %d", s, p.s[p.pos:], p.pos)
+	}
+	return t, nil
+}
+
+// simpleType returns simple type by name. Type_v3 names bool and yson are replaced with boolean and any.
+func simpleType(name string) schema.Type {
+	switch name {
+	case "bool":
+		return schema.TypeBoolean
+	case "yson":
+		return schema.TypeAny
+	default:
+		return schema.Type(name)
+	}
+}
+
+// isV1Type checks whether t can be specified as legacy column type.
+func isV1Type(t schema.ComplexType) bool {
+	st, ok := t.(schema.Type)
+	if !ok {
+		return false
+	}
+	_, v3Only := v3OnlyTypes[st]
+	return !v3Only
+}
+
+// typeParser is a recursive descent parser of type descriptions.
+type typeParser struct {
+	s   string
+	pos int
+}
+
+func (p *typeParser) skipSpaces() {
+	for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// peek returns the next non-space character or 0 at the end of input.
+func (p *typeParser) peek() byte {
+	p.skipSpaces()
+	if p.pos == len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *typeParser) expect(c byte) error {
+	if p.peek() != c {
+		return xerrors.Errorf("expected %q at %d", c, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func (p *typeParser) parseIdent() (string, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && isIdentChar(p.s[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", xerrors.Errorf("expected name at %d", p.pos)
+	}
+	return p.s[start:p.pos], nil
+}
+
+// parseName parses plain or double-quoted name.
+func (p *typeParser) parseName() (string, error) {
+	if p.peek() != '"' {
+		return p.parseIdent()
+	}
+
+	start := p.pos
+	for p.pos++; p.pos < len(p.s); p.pos++ {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos++
+		case '"':
+			p.pos++
+			return strconv.Unquote(p.s[start:p.pos])
+		}
+	}
+	return "", xerrors.Errorf("unterminated name at %d", start)
+}
+
+func (p *typeParser) parseInt() (int, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.s) && p.s[p.pos] >= '0' && p.s[p.pos] <= '9' {
+		p.pos++
+	}
+	return strconv.Atoi(p.s[start:p.pos])
+}
+
+func (p *typeParser) parseType() (schema.ComplexType, error) {
+	name, err := p.parseIdent()
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind := strings.ToLower(name); kind {
+	case "decimal":
+		var d schema.Decimal
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		if d.Precision, err = p.parseInt(); err != nil {
+			return nil, xerrors.Errorf("invalid decimal precision: %w", err)
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		if d.Scale, err = p.parseInt(); err != nil {
+			return nil, xerrors.Errorf("invalid decimal scale: %w", err)
+		}
+		if d.Precision < 1 || d.Precision > 76 || d.Scale > d.Precision {
+			return nil, xerrors.Errorf("invalid decimal precision %d and scale %d", d.Precision, d.Scale)
+		}
+		return d, p.expect(')')
+
+	case "optional", "list":
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		item, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		if kind == "optional" {
+			return schema.Optional{Item: item}, p.expect('>')
+		}
+		return schema.List{Item: item}, p.expect('>')
+
+	case "dict":
+		var d schema.Dict
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		if d.Key, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		if d.Value, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		return d, p.expect('>')
+
+	case "tagged":
+		var t schema.Tagged
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		if t.Tag, err = p.parseName(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(','); err != nil {
+			return nil, err
+		}
+		if t.Item, err = p.parseType(); err != nil {
+			return nil, err
+		}
+		return t, p.expect('>')
+
+	case "struct":
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		members, err := p.parseMembers()
+		if err != nil {
+			return nil, err
+		}
+		return schema.Struct{Members: members}, p.expect('>')
+
+	case "tuple":
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+		elements, err := p.parseElements()
+		if err != nil {
+			return nil, err
+		}
+		return schema.Tuple{Elements: elements}, p.expect('>')
+
+	case "variant":
+		if err := p.expect('<'); err != nil {
+			return nil, err
+		}
+
+		// Variant over struct has named alternatives.
+		save := p.pos
+		_, nameErr := p.parseName()
+		named := nameErr == nil && p.peek() == ':'
+		p.pos = save
+
+		var v schema.Variant
+		if named {
+			v.Members, err = p.parseMembers()
+		} else {
+			v.Elements, err = p.parseElements()
+		}
+		if err != nil {
+			return nil, err
+		}
+		return v, p.expect('>')
+
+	default:
+		return simpleType(kind), nil
+	}
+}
+
+func (p *typeParser) parseMembers() ([]schema.StructMember, error) {
+	var members []schema.StructMember
+	for {
+		name, err := p.parseName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, schema.StructMember{Name: name, Type: t})
+
+		if p.peek() != ',' {
+			return members, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *typeParser) parseElements() ([]schema.TupleElement, error) {
+	var elements []schema.TupleElement
+	for {
+		t, err := p.parseType()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, schema.TupleElement{Type: t})
+
+		if p.peek() != ',' {
+			return elements, nil
+		}
+		p.pos++
+	}
+}
+
+// formatType returns text description of the type in the form accepted by ParseType.
+func formatType(t schema.ComplexType) string {
+	if t, ok := t.(schema.Type); ok {
+		return string(t)
+	}
+
+	data, err := yson.Marshal(t)
+	if err != nil {
+		return "unknown"
+	}
+	return string(data)
+}
+
+// columnType returns type_v3 of the column.
+func columnType(c schema.Column) schema.ComplexType {
+	if c.ComplexType != nil {
+		return c.ComplexType
+	}
+	if c.Required {
+		return c.Type
+	}
+	return schema.Optional{Item: c.Type}
+}
+
+// convertComplex converts cell text to the value of type_v3 type.
+//
+// Decimals are expected as decimal numbers, uuids as text and values of containers as yson or json text.
+func convertComplex(value string, t schema.ComplexType) (any, error) {
+	switch t := t.(type) {
+	case schema.Type:
+		return convertSimple(value, t)
+	case schema.Decimal:
+		return encodeDecimal(value, t)
+	case schema.Tagged:
+		return convertComplex(value, t.Item)
+	case schema.Optional:
+		if _, nested := unwrapTagged(t.Item).(schema.Optional); !nested {
+			if value == "" {
+				return nil, nil
+			}
+			return convertComplex(value, t.Item)
+		}
+	}
+
+	v, err := unmarshalContainer(value)
+	if err != nil {
+		return nil, err
+	}
+	return convertComplexValue(v, t)
+}
+
+// convertSimple converts cell text to the value of simple type including types that exist only in type_v3.
+func convertSimple(value string, t schema.Type) (any, error) {
+	switch t {
+	case typeUUID:
+		return parseUUID(value)
+	case typeJSON:
+		if !json.Valid([]byte(value)) {
+			return nil, xerrors.Errorf("invalid json %q", value)
+		}
+		return value, nil
+	case typeNull, typeVoid:
+		if value != "" && value != "#" {
+			return nil, xerrors.Errorf("unexpected value %q of %s type", value, t)
+		}
+		return nil, nil
+	case typeDate32:
+		return convertDate32(value)
+	case typeDatetime64:
+		return convertDatetime64(value)
+	case typeTimestamp64:
+		return convertTimestamp64(value)
+	case typeInterval64:
+		return strconv.ParseInt(value, 10, 64)
+	default:
+		return convertType(value, t)
+	}
+}
+
+// unmarshalContainer parses yson or json text.
+func unmarshalContainer(value string) (any, error) {
+	var v any
+	yErr := yson.Unmarshal([]byte(value), &v)
+	if yErr == nil {
+		return v, nil
+	}
+
+	d := json.NewDecoder(strings.NewReader(value))
+	d.UseNumber()
+	if err := d.Decode(&v); err == nil && !d.More() {
+		return v, nil
+	}
+	return nil, xerrors.Errorf("unable to parse %q as yson or json: %w", value, yErr)
+}
+
+// convertComplexValue converts parsed yson or json value to the value of type_v3 type.
+//
+// Decimals and uuids are converted from text to binary representation, json numbers to integers or floats
+// and dates written as text to numbers. Structs are accepted as maps or positional lists,
+// dicts as lists of key-value pairs or maps with string keys.
+func convertComplexValue(v any, t schema.ComplexType) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case schema.Type:
+		return convertSimpleValue(v, t)
+
+	case schema.Decimal:
+		switch v := v.(type) {
+		case string:
+			return encodeDecimal(v, t)
+		case json.Number:
+			return encodeDecimal(v.String(), t)
+		case int64, uint64, float64:
+			return encodeDecimal(strconv.FormatFloat(toFloat64(v), 'f', -1, 64), t)
+		}
+		return nil, xerrors.Errorf("unexpected decimal value %v", v)
+
+	case schema.Tagged:
+		return convertComplexValue(v, t.Item)
+
+	case schema.Optional:
+		if _, nested := unwrapTagged(t.Item).(schema.Optional); nested {
+			l, ok := v.([]any)
+			if !ok || len(l) > 1 {
+				return nil, xerrors.Errorf("nested optional value must be a list of at most one item; got %v", v)
+			}
+			if len(l) == 0 {
+				return []any{}, nil
+			}
+			item, err := convertComplexValue(l[0], t.Item)
+			if err != nil {
+				return nil, err
+			}
+			return []any{item}, nil
+		}
+		return convertComplexValue(v, t.Item)
+
+	case schema.List:
+		l, ok := v.([]any)
+		if !ok {
+			return nil, xerrors.Errorf("list value must be a list; got %v", v)
+		}
+		out := make([]any, len(l))
+		for i, item := range l {
+			var err error
+			if out[i], err = convertComplexValue(item, t.Item); err != nil {
+				return nil, xerrors.Errorf("item %d: %w", i, err)
+			}
+		}
+		return out, nil
+
+	case schema.Tuple:
+		l, ok := v.([]any)
+		if !ok || len(l) != len(t.Elements) {
+			return nil, xerrors.Errorf("tuple value must be a list of %d items; got %v", len(t.Elements), v)
+		}
+		out := make([]any, len(l))
+		for i, item := range l {
+			var err error
+			if out[i], err = convertComplexValue(item, t.Elements[i].Type); err != nil {
+				return nil, xerrors.Errorf("element %d: %w", i, err)
+			}
+		}
+		return out, nil
+
+	case schema.Struct:
+		out := make(map[string]any, len(t.Members))
+		switch s := v.(type) {
+		case map[string]any:
+			for _, m := range t.Members {
+				item, err := convertComplexValue(s[m.Name], m.Type)
+				if err != nil {
+					return nil, xerrors.Errorf("member %q: %w", m.Name, err)
+				}
+				out[m.Name] = item
+			}
+		case []any:
+			if len(s) > len(t.Members) {
+				return nil, xerrors.Errorf("struct has %d members; got %d items", len(t.Members), len(s))
+			}
+			for i, m := range t.Members {
+				var item any
+				if i < len(s) {
+					item = s[i]
+				}
+				item, err := convertComplexValue(item, m.Type)
+				if err != nil {
+					return nil, xerrors.Errorf("member %q: %w", m.Name, err)
+				}
+				out[m.Name] = item
+			}
+		default:
+			return nil, xerrors.Errorf("struct value must be a map or a list; got %v", v)
+		}
+		return out, nil
+
+	case schema.Dict:
+		var pairs []any
+		switch d := v.(type) {
+		case []any:
+			pairs = d
+		case map[string]any:
+			for k, item := range d {
+				pairs = append(pairs, []any{k, item})
+			}
+		default:
+			return nil, xerrors.Errorf("dict value must be a list of pairs; got %v", v)
+		}
+
+		out := make([]any, len(pairs))
+		for i, pair := range pairs {
+			kv, ok := pair.([]any)
+			if !ok || len(kv) != 2 {
+				return nil, xerrors.Errorf("dict item must be a key-value pair; got %v", pair)
+			}
+			k, err := convertComplexValue(kv[0], t.Key)
+			if err != nil {
+				return nil, xerrors.Errorf("key %v: %w", kv[0], err)
+			}
+			item, err := convertComplexValue(kv[1], t.Value)
+			if err != nil {
+				return nil, xerrors.Errorf("value of key %v: %w", kv[0], err)
+			}
+			out[i] = []any{k, item}
+		}
+		return out, nil
+
+	case schema.Variant:
+		pair, ok := v.([]any)
+		if !ok || len(pair) != 2 {
+			return nil, xerrors.Errorf("variant value must be a pair of alternative and value; got %v", v)
+		}
+
+		if len(t.Members) > 0 {
+			name, _ := pair[0].(string)
+			for _, m := range t.Members {
+				if m.Name == name {
+					item, err := convertComplexValue(pair[1], m.Type)
+					if err != nil {
+						return nil, xerrors.Errorf("alternative %q: %w", name, err)
+					}
+					return []any{name, item}, nil
+				}
+			}
+			return nil, xerrors.Errorf("unknown variant alternative %v", pair[0])
+		}
+
+		i, err := strconv.ParseInt(formatNumber(pair[0]), 10, 64)
+		if err != nil || i < 0 || i >= int64(len(t.Elements)) {
+			return nil, xerrors.Errorf("unknown variant alternative %v", pair[0])
+		}
+		item, err := convertComplexValue(pair[1], t.Elements[i].Type)
+		if err != nil {
+			return nil, xerrors.Errorf("alternative %d: %w", i, err)
+		}
+		return []any{i, item}, nil
+	}
+
+	return nil, xerrors.Errorf("unexpected type %T", t)
+}
+
+// convertSimpleValue converts parsed yson or json value to the value of simple type.
+func convertSimpleValue(v any, t schema.Type) (any, error) {
+	switch t {
+	case schema.TypeAny:
+		if n, ok := v.(json.Number); ok {
+			return parseNumber(n.String())
+		}
+		return v, nil
+	case schema.TypeBoolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	case schema.TypeBytes, schema.TypeString, typeJSON:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+	case typeUUID:
+		if s, ok := v.(string); ok {
+			if len(s) == 16 {
+				return []byte(s), nil
+			}
+			return parseUUID(s)
+		}
+	case typeNull, typeVoid:
+		return nil, xerrors.Errorf("unexpected value %v of %s type", v, t)
+	default:
+		switch v := v.(type) {
+		case string:
+			return convertSimple(v, t)
+		case json.Number, int64, uint64, float64:
+			// Dates inside containers are written as YT numbers rather than Excel serial numbers.
+			switch t {
+			case schema.TypeDate, schema.TypeDatetime, schema.TypeTimestamp:
+				return strconv.ParseUint(formatNumber(v), 10, 64)
+			case typeDate32, typeDatetime64, typeTimestamp64:
+				return strconv.ParseInt(formatNumber(v), 10, 64)
+			}
+			return convertSimple(formatNumber(v), t)
+		}
+	}
+	return nil, xerrors.Errorf("unexpected value %v of %s type", v, t)
+}
+
+// formatNumber returns text of parsed yson or json number.
+func formatNumber(v any) string {
+	switch v := v.(type) {
+	case json.Number:
+		return v.String()
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func toFloat64(v any) float64 {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	default:
+		return math.NaN()
+	}
+}
+
+// parseNumber parses json number as int64, uint64 or float64.
+func parseNumber(s string) (any, error) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return u, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// unwrapTagged returns item of tagged type.
+func unwrapTagged(t schema.ComplexType) schema.ComplexType {
+	for {
+		tagged, ok := t.(schema.Tagged)
+		if !ok {
+			return t
+		}
+		t = tagged.Item
+	}
+}
+
+// encodeDecimal converts decimal number to YT binary representation.
+//
+// Binary decimal is a big-endian integer of 4, 8, 16 or 32 bytes depending on the precision
+// with the sign bit inverted, that is scaled by 10^scale.
+// Maximum integer denotes nan, the next to maximum one is inf and its negation is -inf.
+func encodeDecimal(value string, t schema.Decimal) ([]byte, error) {
+	size := decimalByteSize(t.Precision)
+	maxValue := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(size*8-1)), big.NewInt(1))
+
+	var v *big.Int
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "nan":
+		v = maxValue
+	case "inf", "+inf":
+		v = new(big.Int).Sub(maxValue, big.NewInt(1))
+	case "-inf":
+		v = new(big.Int).Neg(new(big.Int).Sub(maxValue, big.NewInt(1)))
+	default:
+		r, ok := new(big.Rat).SetString(strings.TrimSpace(value))
+		if !ok {
+			return nil, xerrors.Errorf("invalid decimal %q", value)
+		}
+		r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.Scale)), nil)))
+		if !r.IsInt() {
+			return nil, xerrors.Errorf("decimal %q has more than %d digits after the point", value, t.Scale)
+		}
+
+		v = r.Num()
+		if digits := len(new(big.Int).Abs(v).String()); v.Sign() != 0 && digits > t.Precision {
+			return nil, xerrors.Errorf("decimal %q does not fit into precision %d", value, t.Precision)
+		}
+	}
+
+	if v.Sign() < 0 {
+		// Two's complement of negative value.
+		v = new(big.Int).Add(v, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+
+	b := v.FillBytes(make([]byte, size))
+	b[0] ^= 0x80
+	return b, nil
+}
+
+// decimalByteSize returns the size of binary decimal with given precision.
+func decimalByteSize(precision int) int {
+	switch {
+	case precision <= 9:
+		return 4
+	case precision <= 18:
+		return 8
+	case precision <= 38:
+		return 16
+	default:
+		return 32
+	}
+}
+
+// parseUUID converts uuid text, e.g. 01234567-89ab-cdef-0123-456789abcdef, to 16 bytes.
+func parseUUID(value string) ([]byte, error) {
+	s := strings.ReplaceAll(value, "-", "")
+	b, err := hex.DecodeString(s)
+	if err != nil || len(b) != 16 {
+		return nil, xerrors.Errorf("invalid uuid %q", value)
+	}
+	return b, nil
+}
+
+// convertDate32 converts Excel date or date text to the signed number of days since unix epoch.
+func convertDate32(value string) (int64, error) {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		if t, parseErr := time.Parse(time.DateOnly, value); parseErr == nil {
+			return floorDiv(t.Unix(), 86400), nil
+		}
+		return 0, xerrors.Errorf("unable to convert %q to int64: %w", value, err)
+	}
+	return v - excelUnixEpochDays, nil
+}
+
+// convertDatetime64 converts Excel datetime or RFC 3339 text to the signed number of seconds since unix epoch.
+func convertDatetime64(value string) (int64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		if t, parseErr := time.Parse(time.RFC3339, value); parseErr == nil {
+			return t.Unix(), nil
+		}
+		return 0, xerrors.Errorf("unable to convert %q to float64: %w", value, err)
+	}
+	return int64(math.Round(v*86400)) - excelUnixEpochDays*86400, nil
+}
+
+// convertTimestamp64 converts Excel timestamp or RFC 3339 text to the signed number of microseconds since unix epoch.
+func convertTimestamp64(value string) (int64, error) {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		if t, parseErr := time.Parse(time.RFC3339Nano, value); parseErr == nil {
+			return t.UnixMicro(), nil
+		}
+		return 0, xerrors.Errorf("unable to convert %q to float64: %w", value, err)
+	}
+	return int64(math.Round(v*86400*1e3))*1e3 - excelUnixEpochDays*86400*1e6, nil
+}
+
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
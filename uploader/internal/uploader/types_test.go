@@ -0,0 +1,215 @@
+package uploader
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"go.ytsaurus.tech/yt/go/schema"
+)
+
+// binaryDecimal64 returns YT binary representation of decimal with precision from 10 to 18.
+func binaryDecimal64(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v)^1<<63)
+	return b
+}
+
+func TestParseType(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected schema.ComplexType
+		error    bool
+	}{
+		{in: "int64", expected: schema.TypeInt64},
+		{in: "bool", expected: schema.TypeBoolean},
+		{in: "yson", expected: schema.TypeAny},
+		{in: "uuid", expected: typeUUID},
+		{in: "decimal(10,2)", expected: schema.Decimal{Precision: 10, Scale: 2}},
+		{in: "Optional<Int64>", expected: schema.Optional{Item: schema.TypeInt64}},
+		{in: "list<optional<bool>>", expected: schema.List{Item: schema.Optional{Item: schema.TypeBoolean}}},
+		{
+			in: `struct<a:int64, "b c":list<utf8>>`,
+			expected: schema.Struct{Members: []schema.StructMember{
+				{Name: "a", Type: schema.TypeInt64},
+				{Name: "b c", Type: schema.List{Item: schema.TypeString}},
+			}},
+		},
+		{
+			in: "tuple<int64,decimal(3,1)>",
+			expected: schema.Tuple{Elements: []schema.TupleElement{
+				{Type: schema.TypeInt64},
+				{Type: schema.Decimal{Precision: 3, Scale: 1}},
+			}},
+		},
+		{in: "dict<utf8,double>", expected: schema.Dict{Key: schema.TypeString, Value: schema.TypeFloat64}},
+		{
+			in: "variant<int64,utf8>",
+			expected: schema.Variant{Elements: []schema.TupleElement{
+				{Type: schema.TypeInt64},
+				{Type: schema.TypeString},
+			}},
+		},
+		{
+			in: "variant<n:int64,s:utf8>",
+			expected: schema.Variant{Members: []schema.StructMember{
+				{Name: "n", Type: schema.TypeInt64},
+				{Name: "s", Type: schema.TypeString},
+			}},
+		},
+		{in: `tagged<"image/png",string>`, expected: schema.Tagged{Tag: "image/png", Item: schema.TypeBytes}},
+		{in: "{type_name=list;item=int64}", expected: schema.List{Item: schema.TypeInt64}},
+		{in: "list<int64", error: true},
+		{in: "list<int64>>", error: true},
+		{in: "decimal(100,2)", error: true},
+		{in: "struct<a>", error: true},
+		{in: "{type_name=unknown}", error: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			typ, err := ParseType(tc.in)
+			if tc.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, typ)
+			}
+		})
+	}
+}
+
+func TestEncodeDecimal(t *testing.T) {
+	const maxInt64 = 1<<63 - 1
+	d := schema.Decimal{Precision: 10, Scale: 3}
+
+	for _, tc := range []struct {
+		in       string
+		typ      schema.Decimal
+		expected []byte
+		error    bool
+	}{
+		{in: "1.234", typ: d, expected: binaryDecimal64(1234)},
+		{in: "-1.5", typ: d, expected: binaryDecimal64(-1500)},
+		{in: "1e-3", typ: d, expected: binaryDecimal64(1)},
+		{in: "0", typ: d, expected: binaryDecimal64(0)},
+		{in: "nan", typ: d, expected: binaryDecimal64(maxInt64)},
+		{in: "inf", typ: d, expected: binaryDecimal64(maxInt64 - 1)},
+		{in: "-inf", typ: d, expected: binaryDecimal64(-maxInt64 + 1)},
+		{in: "0.7", typ: schema.Decimal{Precision: 3, Scale: 1}, expected: []byte("\x80\x00\x00\x07")},
+		{in: "1.2345", typ: d, error: true},
+		{in: "12345678", typ: d, error: true},
+		{in: "abc", typ: d, error: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			b, err := encodeDecimal(tc.in, tc.typ)
+			if tc.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, b)
+			}
+		})
+	}
+}
+
+func TestConvert_complexTypes(t *testing.T) {
+	uuid := []byte("\x01\x23\x45\x67\x89\xab\xcd\xef\x01\x23\x45\x67\x89\xab\xcd\xef")
+	decimal := schema.Decimal{Precision: 18, Scale: 2}
+
+	for _, tc := range []struct {
+		name     string
+		typ      schema.ComplexType
+		in       string
+		expected any
+		error    bool
+	}{
+		{name: "optional", typ: schema.Optional{Item: schema.TypeInt64}, in: "42", expected: int64(42)},
+		{name: "tagged", typ: schema.Tagged{Tag: "t", Item: schema.TypeString}, in: "a", expected: "a"},
+		{name: "decimal", typ: decimal, in: "123.45", expected: binaryDecimal64(12345)},
+		{name: "uuid", typ: typeUUID, in: "01234567-89ab-cdef-0123-456789abcdef", expected: uuid},
+		{name: "bad-uuid", typ: typeUUID, in: "0123", error: true},
+		{name: "date32", typ: typeDate32, in: "1899-12-31", expected: int64(-25568)},
+		{name: "date32-excel", typ: typeDate32, in: "25570", expected: int64(1)},
+		{name: "timestamp64", typ: typeTimestamp64, in: "1969-12-31T23:59:59.999999Z", expected: int64(-1)},
+		{name: "json", typ: typeJSON, in: `{"a":1}`, expected: `{"a":1}`},
+		{name: "list-yson", typ: schema.List{Item: schema.TypeInt64}, in: "[1;2;#]", expected: []any{int64(1), int64(2), nil}},
+		{name: "list-json", typ: schema.List{Item: schema.TypeUint8}, in: "[1, 2]", expected: []any{uint64(1), uint64(2)}},
+		{
+			name: "struct",
+			typ: schema.Struct{Members: []schema.StructMember{
+				{Name: "id", Type: typeUUID},
+				{Name: "price", Type: schema.Optional{Item: decimal}},
+				{Name: "tags", Type: schema.List{Item: schema.TypeString}},
+			}},
+			in: `{"id":"01234567-89ab-cdef-0123-456789abcdef","price":"1.50","tags":["a"]}`,
+			expected: map[string]any{
+				"id":    uuid,
+				"price": binaryDecimal64(150),
+				"tags":  []any{"a"},
+			},
+		},
+		{
+			name: "positional-struct",
+			typ: schema.Struct{Members: []schema.StructMember{
+				{Name: "a", Type: schema.TypeInt64},
+				{Name: "b", Type: schema.Optional{Item: schema.TypeString}},
+			}},
+			in:       "[1]",
+			expected: map[string]any{"a": int64(1), "b": nil},
+		},
+		{
+			name:     "dict-pairs",
+			typ:      schema.Dict{Key: schema.TypeString, Value: decimal},
+			in:       `[["a";"0.01"]]`,
+			expected: []any{[]any{"a", binaryDecimal64(1)}},
+		},
+		{
+			name:     "dict-object",
+			typ:      schema.Dict{Key: schema.TypeString, Value: schema.TypeFloat64},
+			in:       `{"a": 0.5}`,
+			expected: []any{[]any{"a", 0.5}},
+		},
+		{
+			name: "variant",
+			typ: schema.Variant{Members: []schema.StructMember{
+				{Name: "n", Type: schema.TypeInt64},
+				{Name: "d", Type: decimal},
+			}},
+			in:       `[d;"-0.01"]`,
+			expected: []any{"d", binaryDecimal64(-1)},
+		},
+		{
+			name:     "tuple",
+			typ:      schema.Tuple{Elements: []schema.TupleElement{{Type: schema.TypeDate}, {Type: schema.TypeBoolean}}},
+			in:       "[1;%true]",
+			expected: []any{uint64(1), true},
+		},
+		{
+			name:     "nested-optional",
+			typ:      schema.Optional{Item: schema.Optional{Item: schema.TypeInt64}},
+			in:       "[#]",
+			expected: []any{nil},
+		},
+		{name: "bad-list", typ: schema.List{Item: schema.TypeInt64}, in: `["a"]`, error: true},
+		{name: "bad-text", typ: schema.List{Item: schema.TypeInt64}, in: "[1;", error: true},
+		{name: "bad-tuple", typ: schema.Tuple{Elements: []schema.TupleElement{{Type: schema.TypeInt64}}}, in: "[1;2]", error: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			v, err := convert(tc.in, schema.Column{Name: "c", ComplexType: tc.typ})
+			if tc.error {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, v)
+			}
+		})
+	}
+}
+
+func TestConvert_optionalComplexType(t *testing.T) {
+	_, err := convert("", schema.Column{Name: "c", ComplexType: schema.Optional{Item: schema.List{Item: schema.TypeInt64}}})
+	require.ErrorIs(t, err, errOptionalField)
+
+	_, err = convert("", schema.Column{Name: "c", ComplexType: schema.List{Item: schema.TypeInt64}})
+	require.Error(t, err)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
@@ -31,7 +32,9 @@ var (
 	unixEpoch  = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
 )
 
-// UploadRequest represents a request to upload excel file to static yt table with strict schema.
+// UploadRequest represents a request to upload excel file to yt table with strict schema.
+//
+// Rows are written to static tables with a table writer and inserted into dynamic tables in a tablet transaction.
 type UploadRequest struct {
 	Path  ypath.Path `json:"path"`
 	Sheet string     `json:"sheet"`
@@ -46,9 +49,63 @@ type UploadRequest struct {
 	append bool
 	create bool
 
+	// SchemaMode controls matching of the columns of the sheet against the schema of the table on append.
+	// SchemaModeStrict is used if empty.
+	SchemaMode SchemaMode `json:"schema_mode"`
+
+	// OnError controls handling of cells that can not be converted. OnErrorFail is used if empty.
+	OnError OnErrorMode `json:"on_error"`
+	// MaxErrors is the max number of conversion errors listed in the error or in the result.
+	MaxErrors int `json:"max_errors"`
+
+	// Update makes inserts into dynamic table change only mapped columns of existing rows.
+	Update bool `json:"update"`
+	// Aggregate makes inserts into dynamic table add values of aggregate columns instead of overwriting them.
+	Aggregate bool `json:"aggregate"`
+	// LockRows makes upload into dynamic table take shared strong locks on lock groups of columns
+	// that are not uploaded, so that the upload conflicts with concurrent changes of the same rows.
+	LockRows bool `json:"lock_rows"`
+	// BatchSize is the max number of rows inserted into dynamic table by a single request.
+	// DefaultBatchSize is used if zero.
+	BatchSize int `json:"batch_size"`
+
+	// Profile is a preset of upload settings for files of a particular origin, e.g. ProfileRoundtrip.
+	Profile Profile `json:"profile"`
+
+	// TransactionID makes the upload run in a transaction nested in the given one,
+	// so that the rows become visible when the caller commits it. Supported only for static tables.
+	TransactionID yt.TxID `json:"transaction_id"`
+
+	// Cluster is the proxy of the cluster the file is uploaded to.
+	// It is used to detect that the file is exported from the target table.
+	Cluster string `json:"cluster"`
+
 	Data *excelize.File `json:"-"`
 }
 
+// OnErrorMode controls handling of cells that can not be converted to the column type.
+type OnErrorMode string
+
+const (
+	// OnErrorFail aborts the upload listing conversion errors.
+	OnErrorFail OnErrorMode = "fail"
+	// OnErrorSkip skips rows containing cells that can not be converted.
+	OnErrorSkip OnErrorMode = "skip"
+)
+
+// OnErrorModes lists all supported error handling modes.
+var OnErrorModes = []OnErrorMode{OnErrorFail, OnErrorSkip}
+
+const (
+	// DefaultMaxErrors is the default max number of listed conversion errors.
+	DefaultMaxErrors = 100
+	// MaxErrorsLimit is the upper bound of max number of listed conversion errors.
+	MaxErrorsLimit = 1000
+)
+
+// ConversionErrorsAttr is the attribute of the error returned by Upload listing conversion errors.
+const ConversionErrorsAttr = "conversion_errors"
+
 // MakeUploadRequest creates and validates request object.
 //
 // Example paths:
@@ -169,7 +226,7 @@ func (r *UploadRequest) MakeColumnMapping(s *schema.Schema) error {
 	if r.Header {
 		return r.makeColumnMappingFromHeader(s)
 	}
-	return r.makeDefaultColumnMapping(s)
+	return r.makeDefaultColumnMapping(s.Columns)
 }
 
 // makeDefaultColumnMapping reads column mapping from the first excel row.
@@ -181,13 +238,14 @@ func (r *UploadRequest) makeColumnMappingFromHeader(s *schema.Schema) error {
 
 	ytColumnSet := makeColumnSet(s)
 
+	// Columns missing from the schema are mapped as well in extend mode, so that they are added to the table.
 	mapping := make(map[string]string)
 	for i, col := range row {
 		name, err := excelize.ColumnNumberToName(i + 1)
 		if err != nil {
 			return xerrors.Errorf("unable to convert number %d to excel column: %w", i+1, err)
 		}
-		if _, ok := ytColumnSet[col]; ok {
+		if _, ok := ytColumnSet[col]; ok || r.SchemaMode == SchemaModeExtend && col != "" {
 			mapping[col] = name
 		}
 	}
@@ -241,10 +299,10 @@ func makeColumnSet(s *schema.Schema) map[string]struct{} {
 	return ret
 }
 
-// makeDefaultColumnMapping maps columns from schema to first excel column names e.g. A, B, C...
-func (r *UploadRequest) makeDefaultColumnMapping(s *schema.Schema) error {
+// makeDefaultColumnMapping maps columns to first excel column names e.g. A, B, C...
+func (r *UploadRequest) makeDefaultColumnMapping(columns []schema.Column) error {
 	mapping := make(map[string]string)
-	for i, col := range s.Columns {
+	for i, col := range columns {
 		name, err := excelize.ColumnNumberToName(i + 1)
 		if err != nil {
 			return xerrors.Errorf("unable to convert number %d to excel column: %w", i+1, err)
@@ -266,72 +324,267 @@ var ErrBadRequest = xerrors.NewSentinel("bad request")
 // ErrUnauthorized is an error that signals that uploader is missing some permissions to make an upload.
 var ErrUnauthorized = xerrors.NewSentinel("unauthorized")
 
+// UploadResult describes a completed upload.
+type UploadResult struct {
+	// RowStats describes converted rows.
+	// Rows listed in errors are skipped, the rest are written.
+	RowStats
+	// Origin describes the table the file is exported from if the exporter recorded it.
+	Origin *FileOrigin `json:"origin,omitempty"`
+	// AddedColumns are the columns added to the table schema in SchemaModeExtend.
+	AddedColumns []string `json:"added_columns,omitempty"`
+	// Schema is the schema the table is created with; set only if the table is created.
+	Schema []ReportColumn `json:"schema,omitempty"`
+}
+
 // Upload executes given upload request.
-func Upload(ctx context.Context, yc yt.Client, req *UploadRequest) error {
+func Upload(ctx context.Context, yc yt.Client, req *UploadRequest) (*UploadResult, error) {
 	err := req.EnsureSheetName()
 	if err != nil {
-		return xerrors.Errorf("unable to ensure sheet name: %w", err)
+		return nil, xerrors.Errorf("unable to ensure sheet name: %w", err)
 	}
 
-	tx, err := yc.BeginTx(ctx, nil)
+	origin, err := req.readOrigin()
 	if err != nil {
-		return xerrors.Errorf("unable to start upload transaction: %w", err)
+		return nil, err
 	}
-	defer tx.Abort()
 
-	if req.create {
-		if err := CreateTable(ctx, tx, req); err != nil {
-			return xerrors.Errorf("unable to create table: %w", err)
-		}
+	result, err := uploadTable(ctx, yc, req)
+	if err != nil {
+		return nil, req.wrapParentTxError(err)
+	}
+	result.Origin = origin
+	return result, nil
+}
+
+// uploadTable writes the rows of the request to a static or dynamic table.
+func uploadTable(ctx context.Context, yc yt.Client, req *UploadRequest) (*UploadResult, error) {
+	if err := req.checkSchemaMode(); err != nil {
+		return nil, err
 	}
 
-	s, err := ReadSchema(ctx, tx, req.Path)
+	parent, err := req.attachParentTx(ctx, yc)
 	if err != nil {
-		if yterrors.ContainsErrorCode(err, yterrors.CodeResolveError) {
-			return ErrBadRequest.Wrap(xerrors.Errorf("error reading schema for %q: %w", req.Path, err))
+		return nil, err
+	}
+
+	// The table is read and written within the transaction of the request if it is set.
+	var cypress yt.CypressClient = yc
+	beginTx := yc.BeginTx
+	if parent != nil {
+		cypress = parent
+		beginTx = parent.BeginTx
+	}
+
+	if !req.create {
+		dynamic, err := readDynamic(ctx, cypress, req.Path)
+		if err != nil {
+			return nil, err
 		}
-		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
-			return ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when reading table schema for %q: %w", req.Path, err))
+		if dynamic {
+			if parent != nil {
+				return nil, ErrBadRequest.Wrap(xerrors.Errorf("transaction is not supported for dynamic tables"))
+			}
+			return uploadDynamic(ctx, yc, req)
 		}
-		return xerrors.Errorf("error reading schema for %q: %w", req.Path, err)
 	}
 
-	if len(req.Columns) == 0 {
-		if err := req.MakeColumnMapping(s); err != nil {
-			return err
+	if err := req.checkStaticRequest(); err != nil {
+		return nil, err
+	}
+
+	var added []string
+	if req.SchemaMode == SchemaModeExtend {
+		if added, err = extendTable(ctx, yc, req); err != nil {
+			return nil, err
 		}
 	}
 
-	if len(req.Columns) != len(s.Columns) {
-		err := xerrors.Errorf("schema has %d column(s), request - %d", len(s.Columns), len(req.Columns))
-		return ErrBadRequest.Wrap(err)
+	tx, err := beginTx(ctx, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to start upload transaction: %w", err)
 	}
+	defer tx.Abort()
 
-	if len(req.Columns) > ExcelMaxColCount {
-		return ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d", ExcelMaxColCount))
+	result, err := uploadStatic(ctx, tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := commitTx(tx); err != nil {
+		return nil, err
+	}
+	result.AddedColumns = added
+	return result, nil
+}
+
+// uploadStatic creates the static table if requested and writes the rows to it within the transaction.
+func uploadStatic(ctx context.Context, tx yt.Tx, req *UploadRequest) (*UploadResult, error) {
+	if req.create {
+		if err := CreateTable(ctx, tx, req); err != nil {
+			return nil, xerrors.Errorf("unable to create table: %w", err)
+		}
+	}
+
+	s, err := readTableSchema(ctx, tx, req.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := req.checkColumnMapping(s); err != nil {
+		return nil, err
 	}
 
 	out, err := tx.WriteTable(ctx, ypath.Rich{Path: req.Path, Append: &req.append}, nil)
 	if err != nil {
 		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
-			return ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when creating table writer: %w", err))
+			return nil, ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when creating table writer: %w", err))
 		}
-		return xerrors.Errorf("error creating writer: %w", err)
+		return nil, xerrors.Errorf("error creating writer: %w", err)
 	}
 
-	if err := upload(req, s, out); err != nil {
+	result, err := upload(req, s, out)
+	if err != nil {
 		_ = out.Rollback()
-		return xerrors.Errorf("error uploading %s: %w", req, err)
+		return nil, xerrors.Errorf("error uploading %s: %w", req, err)
+	}
+	if req.create {
+		result.Schema = makeReport(s)
 	}
+	return result, nil
+}
 
-	err = tx.Commit()
-	if err != nil && yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
-		return ErrUnauthorized.Wrap(err)
+func commitTx(tx yt.Tx) error {
+	if err := tx.Commit(); err != nil {
+		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
+			return ErrUnauthorized.Wrap(err)
+		}
+		return err
 	}
-	return err
+	return nil
 }
 
-func upload(req *UploadRequest, s *schema.Schema, out yt.TableWriter) error {
+func upload(req *UploadRequest, s *schema.Schema, out yt.TableWriter) (*UploadResult, error) {
+	result := &UploadResult{}
+	err := convertRows(req, s, &result.RowStats, func(row map[string]any) error {
+		// Rows are not written after the first error, since the upload is going to be aborted anyway.
+		if result.ErrorCount > 0 && req.OnError != OnErrorSkip {
+			return nil
+		}
+		if err := out.Write(row); err != nil {
+			return xerrors.Errorf("error writing row %+q: %w", row, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if result.ErrorCount > 0 && req.OnError != OnErrorSkip {
+		return nil, ErrBadRequest.Wrap(&ConversionError{Sheet: req.Sheet, Stats: &result.RowStats})
+	}
+
+	if err := out.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CellError describes a cell value that can not be converted to the type of the column.
+type CellError struct {
+	Sheet string `json:"sheet"`
+	// Cell is an excel cell address, e.g. B12.
+	Cell string `json:"cell"`
+	// Row is an excel row number starting from 1.
+	Row int `json:"row"`
+	// Column is a name of the YT column.
+	Column string `json:"column"`
+	// Type is a type of the YT column.
+	Type string `json:"type"`
+	// Value is a raw cell value.
+	Value string `json:"value"`
+	// Message describes the conversion error.
+	Message string `json:"message"`
+
+	err error
+}
+
+func (e *CellError) Error() string {
+	return fmt.Sprintf("unable to convert %q in cell %s (column %q) to %s: %s", e.Value, e.Cell, e.Column, e.Type, e.Message)
+}
+
+func (e *CellError) Unwrap() error {
+	return e.err
+}
+
+// maxErrorValueLen is the max length of the cell value in CellError.
+//
+// Errors are listed in the error attributes, so long values are truncated to keep the error reasonably small.
+const maxErrorValueLen = 256
+
+func truncateErrorValue(value string) string {
+	if len(value) <= maxErrorValueLen {
+		return value
+	}
+	return strings.ToValidUTF8(value[:maxErrorValueLen], "") + "..."
+}
+
+// RowStats describes conversion of the requested rows.
+type RowStats struct {
+	// RowCount is the number of non-empty rows in the requested range.
+	RowCount int64 `json:"row_count"`
+	// InvalidRowCount is the number of rows containing cells that can not be converted.
+	InvalidRowCount int64 `json:"invalid_row_count"`
+	// ErrorCount is the total number of cells that can not be converted.
+	ErrorCount int64 `json:"error_count"`
+	// Errors lists the first conversion errors.
+	Errors []*CellError `json:"errors"`
+
+	maxErrors int
+}
+
+func (s *RowStats) addError(e *CellError) {
+	s.ErrorCount++
+	if len(s.Errors) < s.maxErrors {
+		s.Errors = append(s.Errors, e)
+	}
+}
+
+// ConversionError is returned by Upload if some cells can not be converted to the column types.
+//
+// It is converted to YT error listing collected conversion errors in the attributes.
+type ConversionError struct {
+	Sheet string
+	Stats *RowStats
+}
+
+func (e *ConversionError) Error() string {
+	return e.YTError().Error()
+}
+
+// YTError implements yterrors.Converter.
+func (e *ConversionError) YTError() *yterrors.Error {
+	msg := fmt.Sprintf("unable to convert %d cell(s) in %d row(s) of sheet %q",
+		e.Stats.ErrorCount, e.Stats.InvalidRowCount, e.Sheet)
+	if len(e.Stats.Errors) > 0 {
+		msg += "; first error: " + e.Stats.Errors[0].Error()
+	}
+	return yterrors.Err(msg,
+		yterrors.Attr("error_count", e.Stats.ErrorCount),
+		yterrors.Attr("invalid_row_count", e.Stats.InvalidRowCount),
+		yterrors.Attr(ConversionErrorsAttr, e.Stats.Errors)).(*yterrors.Error)
+}
+
+// convertRows converts the requested rows of the sheet and passes them to write one by one.
+//
+// Rows containing cells that can not be converted are not passed to write.
+// Such cells are collected in stats instead, up to req.MaxErrors.
+func convertRows(req *UploadRequest, s *schema.Schema, stats *RowStats, write func(row map[string]any) error) error {
+	stats.maxErrors = req.MaxErrors
+	if stats.Errors == nil {
+		stats.Errors = []*CellError{}
+	}
+
 	columnToIndex := make(map[string]int)
 	for i, col := range s.Columns {
 		columnToIndex[col.Name] = i
@@ -342,6 +595,19 @@ func upload(req *UploadRequest, s *schema.Schema, out yt.TableWriter) error {
 		excelColToYTCols[excelCol] = append(excelColToYTCols[excelCol], columnToIndex[ytCol])
 	}
 
+	// Trailing empty cells are not listed in rows, while empty text cells of roundtrip files are values,
+	// so rows of such files are padded up to the last mapped column.
+	rowLen := 0
+	if req.Profile == ProfileRoundtrip {
+		for excelCol := range excelColToYTCols {
+			n, err := excelize.ColumnNameToNumber(excelCol)
+			if err != nil {
+				return xerrors.Errorf("invalid column name %q: %w", excelCol, err)
+			}
+			rowLen = max(rowLen, n)
+		}
+	}
+
 	rows, err := req.Data.Rows(req.Sheet)
 	if err != nil {
 		return ErrBadRequest.Wrap(xerrors.Errorf("unable to read rows of sheet %q: %w", req.Sheet, err))
@@ -353,7 +619,8 @@ func upload(req *UploadRequest, s *schema.Schema, out yt.TableWriter) error {
 			return ErrBadRequest.Wrap(xerrors.Errorf("unable to read row of sheet %q: %w", req.Sheet, err))
 		}
 
-		if len(row) == 0 {
+		// Rows of roundtrip files are empty if all their values are null.
+		if len(row) == 0 && req.Profile != ProfileRoundtrip {
 			continue
 		}
 
@@ -365,7 +632,13 @@ func upload(req *UploadRequest, s *schema.Schema, out yt.TableWriter) error {
 			break
 		}
 
+		stats.RowCount++
+		if len(row) < rowLen {
+			row = append(row, make([]string, rowLen-len(row))...)
+		}
+
 		m := make(map[string]any)
+		valid := true
 		for j, excelValue := range row {
 			name, _ := excelize.ColumnNumberToName(j + 1)
 			ytColumns, ok := excelColToYTCols[name]
@@ -375,23 +648,105 @@ func upload(req *UploadRequest, s *schema.Schema, out yt.TableWriter) error {
 			for _, index := range ytColumns {
 				col := s.Columns[index]
 				v, err := convert(excelValue, col)
-				if err != nil {
-					if errors.Is(err, errOptionalField) {
+				if errors.Is(err, errOptionalField) {
+					if req.Profile != ProfileRoundtrip {
+						continue
+					}
+					// Roundtrip files tell empty strings from nulls by the type of the cell.
+					text, cellErr := isTextCell(req.Data, req.Sheet, name+strconv.Itoa(i))
+					if cellErr != nil {
+						return ErrBadRequest.Wrap(xerrors.Errorf("unable to read cell %s%d of sheet %q: %w", name, i, req.Sheet, cellErr))
+					}
+					if !text {
 						continue
 					}
-					return ErrBadRequest.Wrap(xerrors.Errorf("unable to convert %q (column %q) of %q to %s: %w",
-						excelValue, name, row, col.Type, err))
+					v, err = convertEmptyText(col)
+				}
+				if err != nil {
+
+					stats.addError(&CellError{
+						Sheet:   req.Sheet,
+						Cell:    name + strconv.Itoa(i),
+						Row:     i,
+						Column:  col.Name,
+						Type:    formatType(columnType(col)),
+						Value:   truncateErrorValue(excelValue),
+						Message: err.Error(),
+						err:     err,
+					})
+					valid = false
+					continue
 				}
 				m[col.Name] = v
 			}
 		}
 
-		if err := out.Write(m); err != nil {
-			return xerrors.Errorf("error writing row %+q: %w", m, err)
+		if !valid {
+			stats.InvalidRowCount++
+			continue
+		}
+
+		if err := write(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readTableSchema reads schema of the table to upload to.
+func readTableSchema(ctx context.Context, yc yt.CypressClient, path ypath.Path) (*schema.Schema, error) {
+	s, err := ReadSchema(ctx, yc, path)
+	if err != nil {
+		if yterrors.ContainsErrorCode(err, yterrors.CodeResolveError) {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("error reading schema for %q: %w", path, err))
+		}
+		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
+			return nil, ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when reading table schema for %q: %w", path, err))
+		}
+		return nil, xerrors.Errorf("error reading schema for %q: %w", path, err)
+	}
+	return s, nil
+}
+
+// checkColumnMapping makes column mapping if it is not set and checks that it matches the schema.
+//
+// Optional columns of the schema may be missing from the mapping in relaxed schema modes.
+func (r *UploadRequest) checkColumnMapping(s *schema.Schema) error {
+	if len(r.Columns) == 0 {
+		if err := r.MakeColumnMapping(s); err != nil {
+			return err
+		}
+	}
+
+	if r.SchemaMode.relaxed() {
+		if err := r.checkRelaxedMapping(s); err != nil {
+			return err
 		}
+	} else if len(r.Columns) != len(s.Columns) {
+		err := xerrors.Errorf("schema has %d column(s), request - %d", len(s.Columns), len(r.Columns))
+		return ErrBadRequest.Wrap(err)
+	}
+
+	if len(r.Columns) > ExcelMaxColCount {
+		return ErrBadRequest.Wrap(xerrors.Errorf("exceeding max number of excel columns %d", ExcelMaxColCount))
 	}
+	return nil
+}
 
-	return out.Commit()
+// readDynamic returns the value of @dynamic table attribute.
+func readDynamic(ctx context.Context, yc yt.CypressClient, path ypath.Path) (bool, error) {
+	var dynamic bool
+	if err := yc.GetNode(ctx, path.Attr("dynamic"), &dynamic, nil); err != nil {
+		if yterrors.ContainsErrorCode(err, yterrors.CodeResolveError) {
+			return false, ErrBadRequest.Wrap(xerrors.Errorf("error reading table %q: %w", path, err))
+		}
+		if yterrors.ContainsErrorCode(err, yterrors.CodeAuthorizationError) {
+			return false, ErrUnauthorized.Wrap(xerrors.Errorf("authorization error when reading table %q: %w", path, err))
+		}
+		return false, xerrors.Errorf("error reading table %q: %w", path, err)
+	}
+	return dynamic, nil
 }
 
 // ReadSchema returns the value of @schema table attribute.
@@ -427,8 +782,22 @@ func CreateTable(ctx context.Context, yc yt.CypressClient, req *UploadRequest) e
 // Column types are determined using the following logic:
 //  1. Read column types from the first row if types is set to true and header is set to false.
 //  2. Read column types from the second row if types is set to true and header is set to true.
-//  3. Use Any if none of the above works.
+//  3. Infer types from the cells of the requested rows otherwise.
+//
+// Inferred types are boolean, int64, double, date, datetime and utf8: cell types and number formats of dates
+// are used for excel cells, text cells are parsed as literals. Conflicting values widen the type,
+// e.g. int64 and double cells make a double column and any other mix makes an utf8 column.
+// The column is required if none of its cells is empty. Columns without values are of type any.
+//
+// Types row may contain type_v3 descriptions accepted by ParseType, e.g. list<int64> or decimal(10,2).
+// Columns of such types are created with optional type_v3.
+//
+// Files uploaded with ProfileRoundtrip carry the schema in the hidden sheet, which is used as is.
 func MakeSchema(req *UploadRequest) (*schema.Schema, error) {
+	if req.Profile == ProfileRoundtrip {
+		return readSchemaSheet(req.Data)
+	}
+
 	excelColToYTCols := make(map[string][]string)
 	for ytCol, excelCol := range req.Columns {
 		excelColToYTCols[excelCol] = append(excelColToYTCols[excelCol], ytCol)
@@ -507,6 +876,12 @@ func MakeSchema(req *UploadRequest) (*schema.Schema, error) {
 		}
 	}
 
+	if len(typeRow) == 0 {
+		if err := req.inferColumnTypes(colByName, excelColToYTCols); err != nil {
+			return nil, err
+		}
+	}
+
 	if len(typeRow) > 0 {
 		for i, typeStr := range typeRow {
 			excelCol, err := excelize.ColumnNumberToName(i + 1)
@@ -516,11 +891,11 @@ func MakeSchema(req *UploadRequest) (*schema.Schema, error) {
 
 			t, err := GetColumnType(typeStr)
 			if err != nil {
-				return nil, xerrors.Errorf("unable to read column type from %q", typeStr)
+				return nil, ErrBadRequest.Wrap(xerrors.Errorf("unable to read column type from %q: %w", typeStr, err))
 			}
 
 			for _, name := range excelColToYTCols[excelCol] {
-				colByName[name].Type = t
+				setColumnType(colByName[name], t)
 			}
 		}
 	}
@@ -533,10 +908,24 @@ func MakeSchema(req *UploadRequest) (*schema.Schema, error) {
 	return &s, nil
 }
 
-func GetColumnType(typeStr string) (schema.Type, error) {
-	var t schema.Type
-	normalized := strings.TrimSpace(typeStr)
-	return t, t.UnmarshalText([]byte(normalized))
+// setColumnType sets type of the nullable column created by MakeSchema.
+//
+// Types that can not be expressed by the legacy schema are set as type_v3 wrapped into optional.
+func setColumnType(col *schema.Column, t schema.ComplexType) {
+	if isV1Type(t) {
+		col.Type = t.(schema.Type)
+		return
+	}
+
+	col.Type = ""
+	col.ComplexType = schema.Optional{Item: t}
+}
+
+// GetColumnType parses column type from the types row.
+//
+// Both simple type names and type_v3 descriptions accepted by ParseType are supported.
+func GetColumnType(typeStr string) (schema.ComplexType, error) {
+	return ParseType(typeStr)
 }
 
 // errOptionalField is an error returned by convert function
@@ -544,11 +933,26 @@ func GetColumnType(typeStr string) (schema.Type, error) {
 var errOptionalField = xerrors.NewSentinel("optional field")
 
 func convert(value string, c schema.Column) (any, error) {
-	if value == "" && !c.Required {
-		return "", errOptionalField
+	t := columnType(c)
+	if o, ok := t.(schema.Optional); ok {
+		if value == "" {
+			return "", errOptionalField
+		}
+		// Values of nested optionals are written as lists, e.g. [#] or [42].
+		if _, nested := unwrapTagged(o.Item).(schema.Optional); !nested {
+			t = o.Item
+		}
+	}
+
+	if st, ok := t.(schema.Type); ok {
+		return convertSimple(value, st)
 	}
+	return convertComplex(value, t)
+}
 
-	switch c.Type {
+// convertType converts cell text to the value of legacy column type.
+func convertType(value string, t schema.Type) (any, error) {
+	switch t {
 	case schema.TypeInt64:
 		return strconv.ParseInt(value, 10, 64)
 	case schema.TypeInt32:
@@ -590,7 +994,7 @@ func convert(value string, c schema.Column) (any, error) {
 	case schema.TypeInterval:
 		return strconv.ParseInt(value, 10, 64)
 	default:
-		return nil, xerrors.Errorf("unexpected type %s", c.Type)
+		return nil, xerrors.Errorf("unexpected type %s", t)
 	}
 }
 
@@ -601,9 +1005,14 @@ func convert(value string, c schema.Column) (any, error) {
 //
 // Excel does not recognize dates before January 1, 1900.
 // YT does not support dates before January 1, 1970.
+//
+// Dates formatted as text, e.g. 2000-12-31 in csv files, are accepted too.
 func convertDate(value string) (schema.Date, error) {
 	v, err := strconv.ParseUint(value, 10, 64)
 	if err != nil {
+		if t, parseErr := time.Parse(time.DateOnly, value); parseErr == nil {
+			return schema.NewDate(t)
+		}
 		return 0, xerrors.Errorf("unable to convert %q to uint64: %w", value, err)
 	}
 
@@ -618,9 +1027,17 @@ func convertDate(value string) (schema.Date, error) {
 //
 // Excel does not recognize dates before January 1, 1900.
 // YT does not support dates before January 1, 1970.
+//
+// RFC 3339 text values, e.g. 2000-12-31T10:00:00Z, and dates, e.g. 2000-12-31, are accepted too.
 func convertDatetime(value string) (schema.Datetime, error) {
 	v, err := strconv.ParseFloat(value, 64)
 	if err != nil {
+		if t, parseErr := time.Parse(time.RFC3339, value); parseErr == nil {
+			return schema.NewDatetime(t)
+		}
+		if t, parseErr := time.Parse(time.DateOnly, value); parseErr == nil {
+			return schema.NewDatetime(t)
+		}
 		return 0, xerrors.Errorf("unable to convert %q to float64: %w", value, err)
 	}
 
@@ -628,7 +1045,8 @@ func convertDatetime(value string) (schema.Datetime, error) {
 		return 0, xerrors.Errorf("datetime value must be positive; got %v", v)
 	}
 
-	ytDatetime := schema.Datetime(uint64(v*86400) - uint64(unixEpoch.Add(day).Sub(excelEpoch).Seconds()))
+	// Serial numbers are not exact, so the value is rounded to the nearest second.
+	ytDatetime := schema.Datetime(uint64(math.Round(v*86400)) - uint64(unixEpoch.Add(day).Sub(excelEpoch).Seconds()))
 	return ytDatetime, nil
 }
 
@@ -639,9 +1057,14 @@ func convertDatetime(value string) (schema.Datetime, error) {
 //
 // Excel does not recognize dates before January 1, 1900.
 // YT does not support dates before January 1, 1970.
+//
+// RFC 3339 text values with fractional seconds, e.g. 2000-12-31T10:00:00.123456Z, are accepted too.
 func convertTimestamp(value string) (schema.Timestamp, error) {
 	v, err := strconv.ParseFloat(value, 64)
 	if err != nil {
+		if t, parseErr := time.Parse(time.RFC3339Nano, value); parseErr == nil {
+			return schema.NewTimestamp(t)
+		}
 		return 0, xerrors.Errorf("unable to convert %q to float64: %w", value, err)
 	}
 
@@ -649,6 +1072,9 @@ func convertTimestamp(value string) (schema.Timestamp, error) {
 		return 0, xerrors.Errorf("datetime value must be positive; got %v", v)
 	}
 
-	ytTimestamp := schema.Timestamp(uint64(v*86400*1e6) - uint64(unixEpoch.Add(day).Sub(excelEpoch).Microseconds()))
+	// Excel stores times with millisecond precision, and serial numbers are not exact,
+	// so the value is rounded to the nearest millisecond.
+	ms := uint64(math.Round(v * 86400 * 1e3))
+	ytTimestamp := schema.Timestamp(ms*1e3 - uint64(unixEpoch.Add(day).Sub(excelEpoch).Microseconds()))
 	return ytTimestamp, nil
 }
@@ -1,8 +1,10 @@
 package uploader
 
 import (
+	"errors"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"go.ytsaurus.tech/yt/go/schema"
 	"go.ytsaurus.tech/yt/go/ypath"
 	"go.ytsaurus.tech/yt/go/yt"
+	"go.ytsaurus.tech/yt/go/yterrors"
 	"go.ytsaurus.tech/yt/go/yttest"
 )
 
@@ -424,7 +427,7 @@ func TestUpload_existingTable(t *testing.T) {
 			require.NoError(t, err)
 			defer func() { _ = env.YT.RemoveNode(env.Ctx, tc.req.Path, nil) }()
 
-			err = Upload(env.Ctx, env.YT, tc.req)
+			_, err = Upload(env.Ctx, env.YT, tc.req)
 			if !tc.error {
 				require.NoError(t, err)
 
@@ -452,6 +455,13 @@ type S3 struct {
 	UI64 uint64 `yson:"B"`
 }
 
+type S4 struct {
+	I64    int64       `yson:"i_64"`
+	UI64   uint64      `yson:"ui_64"`
+	Date   schema.Date `yson:"date"`
+	String string      `yson:"string"`
+}
+
 func TestUpload_createTable(t *testing.T) {
 	env, cancel := yttest.NewEnv(t)
 	defer cancel()
@@ -588,6 +598,28 @@ func TestUpload_createTable(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "csv",
+			req: &UploadRequest{
+				Path:     ypath.Path("//tmp/csv"),
+				Header:   true,
+				Types:    true,
+				StartRow: 3,
+				RowCount: ExcelMaxRowCount,
+				create:   true,
+				Data: readCSVFile(t, "i_64,ui_64,date,string\r\n"+
+					"int64,uint64,date,utf8\r\n"+
+					"-1,1,2000-12-12,\"a, b\"\r\n"),
+			},
+			expected: []any{
+				&S4{
+					I64:    -1,
+					UI64:   1,
+					Date:   NewDate(time.Date(2000, time.December, 12, 0, 0, 0, 0, time.UTC)),
+					String: "a, b",
+				},
+			},
+		},
 		{
 			name: "bad-type-row",
 			req: &UploadRequest{
@@ -611,7 +643,7 @@ func TestUpload_createTable(t *testing.T) {
 
 			saveExcelFile(t, tc.req.Data, tc.name+".xlsx")
 
-			err := Upload(env.Ctx, env.YT, tc.req)
+			_, err := Upload(env.Ctx, env.YT, tc.req)
 			if !tc.error {
 				require.NoError(t, err)
 
@@ -655,7 +687,7 @@ func TestMakeSchema(t *testing.T) {
 					"A2": 2, "B2": 2,
 				}),
 			},
-			// In this testcase types are not inferred at all.
+			// In this testcase no rows are requested, so types are not inferred at all.
 			// Thus we don't care that there are no "C" column in data.
 			expected: &schema.Schema{
 				Columns: []schema.Column{
@@ -676,7 +708,7 @@ func TestMakeSchema(t *testing.T) {
 					"A3": 2, "B3": 2,
 				}),
 			},
-			// In this testcase types are not inferred at all.
+			// In this testcase no rows are requested, so types are not inferred at all.
 			// Column with empty name is skipped.
 			expected: &schema.Schema{
 				Columns: []schema.Column{
@@ -696,7 +728,7 @@ func TestMakeSchema(t *testing.T) {
 					"A2": 2, "B2": 2,
 				}),
 			},
-			// In this testcase types are not inferred at all.
+			// In this testcase no rows are requested, so types are not inferred at all.
 			expected: &schema.Schema{
 				Columns: []schema.Column{
 					{Name: "A", Type: schema.TypeAny},
@@ -744,6 +776,40 @@ func TestMakeSchema(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "type-row-v3",
+			req: &UploadRequest{
+				Sheet:    testSheet,
+				Header:   true,
+				Types:    true,
+				StartRow: 3,
+				Data: makeExcelFile(t, table{
+					"A1": "id", "B1": "price", "C1": "tags",
+					"A2": "uuid", "B2": "decimal(10,2)", "C2": "list<utf8>",
+					"A3": "01234567-89ab-cdef-0123-456789abcdef", "B3": 1.5, "C3": "[a;b]",
+				}),
+			},
+			// Types that are not expressible by the legacy schema are set as optional type_v3.
+			expected: &schema.Schema{
+				Columns: []schema.Column{
+					{Name: "id", ComplexType: schema.Optional{Item: schema.Type("uuid")}},
+					{Name: "price", ComplexType: schema.Optional{Item: schema.Decimal{Precision: 10, Scale: 2}}},
+					{Name: "tags", ComplexType: schema.Optional{Item: schema.List{Item: schema.TypeString}}},
+				},
+			},
+		},
+		{
+			name: "bad-type-row",
+			req: &UploadRequest{
+				Sheet:    testSheet,
+				Types:    true,
+				StartRow: 2,
+				Data: makeExcelFile(t, table{
+					"A1": "list<int64",
+				}),
+			},
+			error: true,
+		},
 		{
 			name: "missing-header-row",
 			req: &UploadRequest{
@@ -830,7 +896,7 @@ func TestMakeSchema(t *testing.T) {
 func TestGetColumnType(t *testing.T) {
 	for _, tc := range []struct {
 		typeStr  string
-		expected schema.Type
+		expected schema.ComplexType
 		error    bool
 	}{
 		{typeStr: "int64", expected: schema.TypeInt64},
@@ -853,6 +919,9 @@ func TestGetColumnType(t *testing.T) {
 		{typeStr: "interval", expected: schema.TypeInterval},
 		{typeStr: "some-bad-type", expected: schema.Type("some-bad-type")}, // no error
 		{typeStr: " string ", expected: schema.TypeBytes},                  // whitespace is trimmed
+		{typeStr: "list<int64>", expected: schema.List{Item: schema.TypeInt64}},
+		{typeStr: "decimal(10,2)", expected: schema.Decimal{Precision: 10, Scale: 2}},
+		{typeStr: "struct<a:int64", error: true},
 	} {
 		t.Run(tc.typeStr, func(t *testing.T) {
 			typ, err := GetColumnType(tc.typeStr)
@@ -873,6 +942,7 @@ func TestConvertDate(t *testing.T) {
 		error    bool
 	}{
 		{value: "25569", expected: NewDate(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))},
+		{value: "2000-12-12", expected: NewDate(time.Date(2000, time.December, 12, 0, 0, 0, 0, time.UTC))},
 		{value: "1.5", error: true},
 		{value: "-1", error: true},
 	} {
@@ -895,6 +965,8 @@ func TestConvertDatetime(t *testing.T) {
 		error    bool
 	}{
 		{value: "25569.5", expected: NewDatetime(time.Date(1970, time.January, 1, 12, 0, 0, 0, time.UTC))},
+		{value: "2000-12-12T10:22:17Z", expected: NewDatetime(time.Date(2000, time.December, 12, 10, 22, 17, 0, time.UTC))},
+		{value: "36872.43216435185", expected: NewDatetime(time.Date(2000, time.December, 12, 10, 22, 19, 0, time.UTC))},
 		{value: "-1", error: true},
 	} {
 		t.Run(tc.value, func(t *testing.T) {
@@ -916,6 +988,14 @@ func TestConvertTimestamp(t *testing.T) {
 		error    bool
 	}{
 		{value: "25569", expected: NewTimestamp(time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC))},
+		{
+			value:    "2000-12-12T10:22:17.302001Z",
+			expected: NewTimestamp(time.Date(2000, time.December, 12, 10, 22, 17, 302001000, time.UTC)),
+		},
+		{
+			value:    "45351.999999988424",
+			expected: NewTimestamp(time.Date(2024, time.February, 29, 23, 59, 59, 999000000, time.UTC)),
+		},
 		{value: "-1", error: true},
 	} {
 		t.Run(tc.value, func(t *testing.T) {
@@ -970,3 +1050,94 @@ func NewTimestamp(t time.Time) schema.Timestamp {
 	ts, _ := schema.NewTimestamp(t)
 	return ts
 }
+
+// rowsWriter is a yt.TableWriter that keeps written rows in memory.
+type rowsWriter struct {
+	rows      []any
+	committed bool
+}
+
+func (w *rowsWriter) Write(value any) error {
+	w.rows = append(w.rows, value)
+	return nil
+}
+
+func (w *rowsWriter) Commit() error {
+	w.committed = true
+	return nil
+}
+
+func (w *rowsWriter) Rollback() error { return nil }
+
+func TestUpload_conversionErrors(t *testing.T) {
+	s := &schema.Schema{Columns: []schema.Column{
+		{Name: "id", Type: schema.TypeInt64, Required: true},
+		{Name: "name", Type: schema.TypeString},
+	}}
+
+	makeRequest := func(onError OnErrorMode, maxErrors int) *UploadRequest {
+		return &UploadRequest{
+			Sheet:     testSheet,
+			allRows:   true,
+			Columns:   map[string]string{"id": "A", "name": "B"},
+			OnError:   onError,
+			MaxErrors: maxErrors,
+			Data: makeExcelFile(t, table{
+				"A1": 1, "B1": "a",
+				"A2": "x", "B2": "b",
+				"A3": 3, "B3": "c",
+				"A4": "y",
+				"A5": "z", "B5": strings.Repeat("e", 1000),
+			}),
+		}
+	}
+
+	t.Run("fail", func(t *testing.T) {
+		out := &rowsWriter{}
+		_, err := upload(makeRequest(OnErrorFail, 2), s, out)
+		require.ErrorIs(t, err, ErrBadRequest)
+		require.False(t, out.committed)
+		require.Len(t, out.rows, 1, "rows after the first error are not written")
+
+		var convErr *ConversionError
+		require.True(t, errors.As(err, &convErr))
+		require.Equal(t, int64(3), convErr.Stats.ErrorCount)
+
+		ytErr := yterrors.FromError(err).(*yterrors.Error)
+		require.Contains(t, ytErr.Error(), "3 cell(s) in 3 row(s)")
+		ytErr = convErr.YTError()
+		require.Contains(t, ytErr.Message, "3 cell(s) in 3 row(s)")
+		require.Contains(t, ytErr.Message, "A2")
+		require.Equal(t, int64(3), ytErr.Attributes["error_count"])
+
+		listed := ytErr.Attributes["conversion_errors"].([]*CellError)
+		require.Len(t, listed, 2)
+		require.Equal(t, "A2", listed[0].Cell)
+		require.Equal(t, "A4", listed[1].Cell)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		out := &rowsWriter{}
+		result, err := upload(makeRequest(OnErrorSkip, 10), s, out)
+		require.NoError(t, err)
+		require.True(t, out.committed)
+		require.Equal(t, []any{
+			map[string]any{"id": int64(1), "name": "a"},
+			map[string]any{"id": int64(3), "name": "c"},
+		}, out.rows)
+
+		require.Equal(t, int64(5), result.RowCount)
+		require.Equal(t, int64(3), result.InvalidRowCount)
+		require.Equal(t, int64(3), result.ErrorCount)
+		require.Len(t, result.Errors, 3)
+		require.Equal(t, "A5", result.Errors[2].Cell)
+		require.Equal(t, "z", result.Errors[2].Value)
+	})
+}
+
+func TestTruncateErrorValue(t *testing.T) {
+	require.Equal(t, "abc", truncateErrorValue("abc"))
+
+	long := strings.Repeat("a", maxErrorValueLen-1) + "ф"
+	require.Equal(t, strings.Repeat("a", maxErrorValueLen-1)+"...", truncateErrorValue(long))
+}
@@ -0,0 +1,191 @@
+package uploader
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+
+	"go.ytsaurus.tech/library/go/core/xerrors"
+	"go.ytsaurus.tech/yt/go/schema"
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yt"
+)
+
+// WorkbookManifest maps the sheets of a workbook to the tables they are uploaded to.
+type WorkbookManifest struct {
+	Sheets []SheetManifest `json:"sheets"`
+}
+
+// SheetManifest describes the upload of a single sheet; the settings have the same meaning as in UploadRequest.
+type SheetManifest struct {
+	Sheet    string            `json:"sheet"`
+	Path     string            `json:"path"`
+	StartRow int64             `json:"start_row"`
+	RowCount int64             `json:"row_count"`
+	Header   bool              `json:"header"`
+	Types    bool              `json:"types"`
+	Columns  map[string]string `json:"columns"`
+	Append   bool              `json:"append"`
+	Create   bool              `json:"create"`
+
+	SchemaMode SchemaMode `json:"schema_mode"`
+}
+
+// ParseWorkbookManifest parses json manifest of the workbook upload.
+func ParseWorkbookManifest(data []byte) (*WorkbookManifest, error) {
+	var m WorkbookManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, xerrors.Errorf("unable to parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// MakeUploadRequests creates upload request for each of the sheets.
+//
+// Data of the requests is not set.
+func (m *WorkbookManifest) MakeUploadRequests() ([]*UploadRequest, error) {
+	if len(m.Sheets) == 0 {
+		return nil, xerrors.New("manifest has no sheets")
+	}
+
+	var reqs []*UploadRequest
+	paths := make(map[ypath.Path]string)
+	for _, sheet := range m.Sheets {
+		if sheet.Sheet == "" {
+			return nil, xerrors.Errorf("sheet name of %q is not set", sheet.Path)
+		}
+		if sheet.Header && sheet.Columns != nil {
+			return nil, xerrors.Errorf("sheet %q: unable to use header=true together with column mapping", sheet.Sheet)
+		}
+
+		req, err := MakeUploadRequest(sheet.Path, sheet.StartRow, sheet.RowCount, sheet.Sheet,
+			sheet.Header, sheet.Types, sheet.Columns, sheet.Append, sheet.Create)
+		if err != nil {
+			return nil, xerrors.Errorf("sheet %q: error parsing path: %w", sheet.Sheet, err)
+		}
+
+		req.SchemaMode = sheet.SchemaMode
+
+		if other, ok := paths[req.Path]; ok {
+			return nil, xerrors.Errorf("sheets %q and %q are uploaded to the same table %q", other, sheet.Sheet, req.Path)
+		}
+		paths[req.Path] = sheet.Sheet
+
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// WorkbookUploadResult describes a completed workbook upload.
+type WorkbookUploadResult struct {
+	// Sheets are the results of the sheets in the order of the requests.
+	Sheets []SheetUploadResult `json:"sheets"`
+}
+
+// SheetUploadResult describes the upload of a single sheet.
+type SheetUploadResult struct {
+	Sheet string     `json:"sheet"`
+	Path  ypath.Path `json:"path"`
+
+	UploadResult
+}
+
+// UploadWorkbook uploads several sheets of a workbook to static tables in a single transaction,
+// so either all the tables are changed or none of them.
+//
+// The requests share Data and TransactionID.
+func UploadWorkbook(ctx context.Context, yc yt.Client, reqs []*UploadRequest) (*WorkbookUploadResult, error) {
+	if len(reqs) == 0 {
+		return nil, ErrBadRequest.Wrap(xerrors.New("no sheets to upload"))
+	}
+
+	result, err := uploadWorkbook(ctx, yc, reqs)
+	if err != nil {
+		return nil, reqs[0].wrapParentTxError(err)
+	}
+	return result, nil
+}
+
+func uploadWorkbook(ctx context.Context, yc yt.Client, reqs []*UploadRequest) (*WorkbookUploadResult, error) {
+	parent, err := reqs[0].attachParentTx(ctx, yc)
+	if err != nil {
+		return nil, err
+	}
+
+	beginTx := yc.BeginTx
+	if parent != nil {
+		beginTx = parent.BeginTx
+	}
+
+	for _, req := range reqs {
+		if !slices.Contains(req.Data.GetSheetList(), req.Sheet) {
+			return nil, ErrBadRequest.Wrap(xerrors.Errorf("sheet %q not found", req.Sheet))
+		}
+		if err := req.checkSchemaMode(); err != nil {
+			return nil, xerrors.Errorf("sheet %q: %w", req.Sheet, err)
+		}
+	}
+
+	// Columns are added in extend mode before the transaction starts, since alter_table can not be run within it.
+	extended := make([]*schema.Schema, len(reqs))
+	added := make([][]string, len(reqs))
+	extend := false
+	for i, req := range reqs {
+		if req.SchemaMode != SchemaModeExtend {
+			continue
+		}
+		if extended[i], added[i], err = planExtension(ctx, yc, req); err != nil {
+			return nil, xerrors.Errorf("error extending table of sheet %q: %w", req.Sheet, err)
+		}
+		extend = extend || len(added[i]) > 0
+	}
+
+	// All the sheets are checked before any column is added, so that a failing sheet leaves all the tables intact.
+	if extend {
+		for _, req := range reqs {
+			if err := checkUpload(ctx, yc, req); err != nil {
+				return nil, xerrors.Errorf("sheet %q: %w", req.Sheet, err)
+			}
+		}
+		for i, req := range reqs {
+			if len(added[i]) == 0 {
+				continue
+			}
+			if err := alterTable(ctx, yc, req, extended[i], added[i]); err != nil {
+				return nil, xerrors.Errorf("error extending table of sheet %q: %w", req.Sheet, err)
+			}
+		}
+	}
+
+	tx, err := beginTx(ctx, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("unable to start upload transaction: %w", err)
+	}
+	defer func() { _ = tx.Abort() }()
+
+	result := &WorkbookUploadResult{}
+	for i, req := range reqs {
+		if !req.create {
+			dynamic, err := readDynamic(ctx, tx, req.Path)
+			if err != nil {
+				return nil, err
+			}
+			if dynamic {
+				return nil, ErrBadRequest.Wrap(xerrors.Errorf("sheet %q: workbook upload supports only static tables; %q is dynamic",
+					req.Sheet, req.Path))
+			}
+		}
+
+		sheetResult, err := uploadStatic(ctx, tx, req)
+		if err != nil {
+			return nil, xerrors.Errorf("error uploading sheet %q: %w", req.Sheet, err)
+		}
+		sheetResult.AddedColumns = added[i]
+		result.Sheets = append(result.Sheets, SheetUploadResult{Sheet: req.Sheet, Path: req.Path, UploadResult: *sheetResult})
+	}
+
+	if err := commitTx(tx); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package uploader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"go.ytsaurus.tech/yt/go/ypath"
+	"go.ytsaurus.tech/yt/go/yttest"
+)
+
+func TestParseWorkbookManifest(t *testing.T) {
+	m, err := ParseWorkbookManifest([]byte(`{"sheets": [
+		{"sheet": "Q1", "path": "//tmp/q1", "header": true, "types": true, "create": true},
+		{"sheet": "Q2", "path": "//tmp/q2", "columns": {"id": "B"}, "start_row": 3, "append": true}
+	]}`))
+	require.NoError(t, err)
+
+	reqs, err := m.MakeUploadRequests()
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+
+	require.Equal(t, "Q1", reqs[0].Sheet)
+	require.Equal(t, ypath.Path("//tmp/q1"), reqs[0].Path)
+	require.True(t, reqs[0].Header)
+	require.True(t, reqs[0].Types)
+	require.True(t, reqs[0].create)
+	require.False(t, reqs[0].append)
+	require.Equal(t, int64(3), reqs[0].StartRow, "header and types are skipped")
+
+	require.Equal(t, "Q2", reqs[1].Sheet)
+	require.Equal(t, map[string]string{"id": "B"}, reqs[1].Columns)
+	require.True(t, reqs[1].append)
+	require.False(t, reqs[1].create)
+	require.Equal(t, int64(3), reqs[1].StartRow)
+	require.Equal(t, int64(ExcelMaxRowCount), reqs[1].RowCount)
+
+	_, err = ParseWorkbookManifest([]byte(`[]`))
+	require.Error(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		manifest string
+	}{
+		{name: "no_sheets", manifest: `{"sheets": []}`},
+		{name: "no_sheet_name", manifest: `{"sheets": [{"path": "//tmp/q1"}]}`},
+		{name: "bad_path", manifest: `{"sheets": [{"sheet": "Q1", "path": "tmp"}]}`},
+		{name: "header_and_columns", manifest: `{"sheets": [{"sheet": "Q1", "path": "//tmp/q1", "header": true, "columns": {"id": "A"}}]}`},
+		{
+			name:     "same_path",
+			manifest: `{"sheets": [{"sheet": "Q1", "path": "//tmp/q"}, {"sheet": "Q2", "path": "//tmp/q"}]}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := ParseWorkbookManifest([]byte(tc.manifest))
+			require.NoError(t, err)
+			_, err = m.MakeUploadRequests()
+			require.Error(t, err)
+		})
+	}
+}
+
+func makeWorkbook(t *testing.T, sheets map[string][][]any) *excelize.File {
+	t.Helper()
+
+	f := excelize.NewFile()
+	t.Cleanup(func() { _ = f.Close() })
+
+	for name, rows := range sheets {
+		_, err := f.NewSheet(name)
+		require.NoError(t, err)
+		for i, row := range rows {
+			cell, err := excelize.CoordinatesToCellName(1, i+1)
+			require.NoError(t, err)
+			require.NoError(t, f.SetSheetRow(name, cell, &row))
+		}
+	}
+	return f
+}
+
+func TestUploadWorkbook(t *testing.T) {
+	env, cancel := yttest.NewEnv(t)
+	defer cancel()
+
+	f := makeWorkbook(t, map[string][][]any{
+		"Q1":  {{"id", "name"}, {1, "a"}, {2, "b"}},
+		"Q2":  {{"id"}, {3}},
+		"Bad": {{"id"}, {"int64"}, {"x"}},
+	})
+
+	makeRequests := func(t *testing.T, manifest string) []*UploadRequest {
+		m, err := ParseWorkbookManifest([]byte(manifest))
+		require.NoError(t, err)
+		reqs, err := m.MakeUploadRequests()
+		require.NoError(t, err)
+		for _, req := range reqs {
+			req.Data = f
+		}
+		return reqs
+	}
+
+	q1, q2 := env.TmpPath(), env.TmpPath()
+
+	t.Run("atomic", func(t *testing.T) {
+		reqs := makeRequests(t, `{"sheets": [
+			{"sheet": "Q1", "path": "`+q1.String()+`", "header": true, "create": true},
+			{"sheet": "Bad", "path": "`+q2.String()+`", "header": true, "types": true, "create": true}
+		]}`)
+
+		_, err := UploadWorkbook(env.Ctx, env.YT, reqs)
+		require.ErrorIs(t, err, ErrBadRequest)
+
+		ok, err := env.YT.NodeExists(env.Ctx, q1, nil)
+		require.NoError(t, err)
+		require.False(t, ok, "table of the first sheet is not created if the second sheet fails")
+	})
+
+	t.Run("sheet_not_found", func(t *testing.T) {
+		reqs := makeRequests(t, `{"sheets": [{"sheet": "Q3", "path": "`+q1.String()+`", "create": true}]}`)
+
+		_, err := UploadWorkbook(env.Ctx, env.YT, reqs)
+		require.ErrorIs(t, err, ErrBadRequest)
+	})
+
+	t.Run("success", func(t *testing.T) {
+		reqs := makeRequests(t, `{"sheets": [
+			{"sheet": "Q1", "path": "`+q1.String()+`", "header": true, "create": true},
+			{"sheet": "Q2", "path": "`+q2.String()+`", "header": true, "create": true}
+		]}`)
+
+		result, err := UploadWorkbook(env.Ctx, env.YT, reqs)
+		require.NoError(t, err)
+		require.Len(t, result.Sheets, 2)
+		require.Equal(t, "Q1", result.Sheets[0].Sheet)
+		require.Equal(t, q1, result.Sheets[0].Path)
+		require.Equal(t, int64(2), result.Sheets[0].RowCount)
+		require.Equal(t, int64(1), result.Sheets[1].RowCount)
+
+		var rows []map[string]any
+		require.NoError(t, env.DownloadSlice(q2, &rows))
+		require.Len(t, rows, 1)
+	})
+}